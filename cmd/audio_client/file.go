@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pkg_flac "showcase-backend-audio_transcriber-go/pkg/flac"
+	pkg_wav "showcase-backend-audio_transcriber-go/pkg/wav"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+)
+
+// audioFile is a recording streamed instead of the microphone
+// wav data is sent as decoded samples, flac files as they are, the server decodes them
+type audioFile struct {
+	r              io.Reader
+	encoding       pb.AudioEncoding
+	sampleRate     int
+	channels       int
+	bytesPerSecond int // of the sent data, paces the stream
+	align          int // bytes sent together, whole wav frames
+	duration       time.Duration
+	truncated      func() bool
+	close          func() error
+}
+
+// openAudioFile picks the container by extension, .flac or .wav
+func openAudioFile(path string) (*audioFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := readAudioFile(f, strings.ToLower(filepath.Ext(path)))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	file.close = f.Close
+	return file, nil
+}
+
+func readAudioFile(f *os.File, ext string) (*audioFile, error) {
+	if ext == ".flac" {
+		info, err := pkg_flac.ReadStreamInfo(f)
+		if err != nil {
+			return nil, err
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		// compressed size per second of audio, about half of the pcm size when the length is unknown
+		bytesPerSecond := info.SampleRate * info.Channels * info.BitsPerSample / 16
+		if seconds := info.Duration().Seconds(); seconds > 0 {
+			bytesPerSecond = int(float64(stat.Size()) / seconds)
+		}
+		return &audioFile{
+			r:              bufio.NewReader(f),
+			encoding:       pb.AudioEncoding_AUDIO_ENCODING_FLAC,
+			sampleRate:     info.SampleRate,
+			channels:       info.Channels,
+			bytesPerSecond: max(bytesPerSecond, 1),
+			align:          1,
+			duration:       info.Duration(),
+			truncated:      func() bool { return false },
+		}, nil
+	}
+
+	wav, err := pkg_wav.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	return &audioFile{
+		r:              wav,
+		encoding:       pb.AudioEncoding(pb.AudioEncoding_value["AUDIO_ENCODING_"+strings.ToUpper(wav.Format.Encoding)]),
+		sampleRate:     wav.Format.SampleRate,
+		channels:       wav.Format.Channels,
+		bytesPerSecond: wav.Format.SampleRate * wav.Format.BlockAlign(),
+		align:          wav.Format.BlockAlign(),
+		duration:       wav.Duration(),
+		truncated:      wav.Truncated,
+	}, nil
+}
+
+// streamFile sends the file data into audioChan at playback speed, like a microphone would
+// the server buffers about a second per session, a file sent at once would overflow it
+// audioChan is closed at the end of the file
+func streamFile(ctx context.Context, file *audioFile, audioChan chan<- []byte) {
+	defer close(audioChan)
+
+	const interval = 100 * time.Millisecond
+	chunkSize := max(file.bytesPerSecond/10/file.align, 1) * file.align
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		buf := make([]byte, chunkSize)
+		n, err := io.ReadFull(file.r, buf)
+		// a truncated file may end inside a frame
+		if n -= n % file.align; n > 0 {
+			select {
+			case audioChan <- buf[:n]:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			if file.truncated() {
+				log.Print("audio file is truncated, streamed what was there")
+			}
+			fmt.Println("\nend of file, waiting for the last transcripts... press ctrl+c to stop")
+			return
+		}
+		if err != nil {
+			log.Printf("fail to read audio file: %v", err)
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -9,10 +9,10 @@ import (
     // _ "net/http/pprof"
     "os"
     "os/signal"
+    "strings"
     "syscall"
     "time"
 
-    pkg "showcase-backend-audio_transcriber-go/pkg"
     pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
     pkg_grpc "showcase-backend-audio_transcriber-go/pkg/grpc"
     pb "showcase-backend-audio_transcriber-go/protobuf"
@@ -28,6 +28,7 @@ var (
     framesPerBuf int
     audioChannels int
     audioBufferChannelSize int
+    bytesPerSecond int // of the sent audio
 )
 
 func main() {
@@ -57,6 +58,24 @@ func main() {
     framesPerBuf = audioCfg.Processing.FramesPerBuf
     audioChannels = audioCfg.Processing.AudioChannels
     audioBufferChannelSize = audioCfg.Processing.AudioBufChannelSize
+    bytesPerSecond = int(sampleRate) * 2 * audioChannels
+
+    // a recorded file declares its own format
+    encoding := pb.AudioEncoding_AUDIO_ENCODING_PCM16
+    var file *audioFile
+    if audioCfg.Stream.File != "" {
+        file, err = openAudioFile(audioCfg.Stream.File)
+        if err != nil {
+            log.Fatalf("fail to open audio file: %v", err)
+        }
+        defer file.close()
+        sampleRate = float64(file.sampleRate)
+        audioChannels = file.channels
+        bytesPerSecond = file.bytesPerSecond
+        encoding = file.encoding
+        fmt.Printf("streaming %s: %s, %d hz, %d channels, %v\n", audioCfg.Stream.File,
+            strings.ToLower(strings.TrimPrefix(encoding.String(), "AUDIO_ENCODING_")), file.sampleRate, file.channels, file.duration)
+    }
 
     // // uncomment this if you want to profile the program
     // // start pprof on :6061
@@ -83,74 +102,48 @@ func main() {
         log.Fatalf("fail to create transcribe stream: %v", err)
     }
 
-    // list available input devices
-    devices, err := portaudio.Devices()
+    // handshake: declare the stream format before any audio
+    err = stream.Send(&pb.AudioChunk{
+        Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{
+            SampleRate: uint32(sampleRate),
+            Channels: uint32(audioChannels),
+            Encoding: encoding,
+            Language: audioCfg.Stream.Language,
+            Translate: audioCfg.Stream.Translate,
+            KeywordPolicyId: audioCfg.Stream.KeywordPolicy,
+            Vocabulary: audioCfg.Stream.Vocabulary,
+            Metadata: map[string]string{"client": "audio_client"},
+        }},
+        SessionId: sessionID.String(),
+    })
     if err != nil {
-        log.Fatalf("failed to check devices: %v", err)
+        log.Fatalf("fail to send stream config: %v", err)
     }
-    fmt.Printf("available input devices:\n")
-    for i, dvc := range devices {
-        if dvc.MaxInputChannels > 0 {
-            fmt.Printf("#%d: %s\n", i, dvc.Name)
-        }
-    }
-
-    device, err := portaudio.DefaultInputDevice()
+    ack, err := stream.Recv()
     if err != nil {
-        log.Fatalf("no default input device")
+        log.Fatalf("fail to receive stream config ack: %v", err)
     }
-    fmt.Printf("using: %s\n", device.Name)
-
-    if device.DefaultSampleRate != sampleRate {
-        fmt.Printf("warning: device sample rate %.0f ≠ %f\n", device.DefaultSampleRate, sampleRate)
+    if ack.ConfigAck == nil || !ack.ConfigAck.Accepted {
+        log.Fatalf("stream config rejected: %s", ack.GetConfigAck().GetReason())
     }
 
-    audioChan := make(chan []int16, audioBufferChannelSize)
-
-    paramsInput := portaudio.StreamParameters{
-        Input: portaudio.StreamDeviceParameters{
-            Device:   device,
-            Channels: audioChannels,
-            Latency:  device.DefaultLowInputLatency,
-        },
-        SampleRate:      float64(sampleRate),
-        FramesPerBuffer: framesPerBuf,
+    // audio source: a wav or flac file when stream.file is set, the default microphone otherwise
+    audioChan := make(chan []byte, audioBufferChannelSize)
+    if file != nil {
+        go streamFile(ctx, file, audioChan)
+    } else {
+        stop := captureMicrophone(audioChan)
+        defer stop()
     }
 
-    streamCb, err := portaudio.OpenStream(paramsInput, func(in []int16) {
-        if len(in) == 0 {
-            return
-        }
-        buf := make([]int16, len(in))
-        copy(buf, in)
-
-        select {
-        case audioChan <- buf: {
-            // audio chunk accepted
-        }
-        default: {
-            // buffer full → drop to avoid blocking real-time callback
-            log.Print("audio buffer full, dropping chunk")
-        }
-        }
-    })
-    if err != nil {
-        log.Fatalf("fail to open audio stream: %v", err)
-    }
-    defer streamCb.Close()
-
-    err = streamCb.Start()
-    if err != nil {
-        log.Fatalf("fail to start audio stream: %v", err)
-    }
-    defer streamCb.Stop()
-
     // handle interrupt signals
     sigChan := make(chan os.Signal, 1)
     signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-    // feedback receiver goroutine
+    // feedback receiver goroutine, done once the server ends the stream
+    receiverDone := make(chan struct{})
     go func() {
+        defer close(receiverDone)
         for {
             select {
             case <-ctx.Done(): {
@@ -170,74 +163,140 @@ func main() {
                     return
                 }
 
+                if response.Status == pb.TranscriptStatus_TRANSCRIPT_STATUS_EMPTY {
+                    continue
+                }
+
+                if response.Error != nil {
+                    fmt.Printf("\n\033[33m[error] chunk #%d (%d-%d ms): %s\033[0m\n",
+                        response.Error.ChunkId, response.Error.StartMs, response.Error.EndMs, response.Error.Message)
+                    continue
+                }
+
                 if response.Warning {
-                    fmt.Printf("\n\033[31m[warning] %s\033[0m\n", response.Text)
+                    fmt.Printf("\n\033[31m[%s] %s\033[0m\n", actionLabel(response.Action), response.Text)
                     if len(response.DetectedKeywords) > 0 {
                         fmt.Printf("\033[31mkeywords: %v\033[0m\n", response.DetectedKeywords)
                     }
+                    if e := response.Escalation; e != nil {
+                        fmt.Printf("\033[31;1m[%s] %d strikes in %d s\033[0m\n", e.Level, e.Strikes, e.WindowMs/1000)
+                    }
+                    for _, match := range response.KeywordMatches {
+                        if match.Score < 1 {
+                            fmt.Printf("\033[31m~ '%s' sounds like '%s' (score %.2f)\033[0m\n", match.Text, match.Keyword, match.Score)
+                        }
+                    }
                 } else {
                     fmt.Printf("\n\033[32m[pass] %s\033[0m\n", response.Text)
                 }
+                for _, pii := range response.PiiMatches {
+                    fmt.Printf("\033[35m[pii] %s: '%s'\033[0m\n", strings.ToLower(strings.TrimPrefix(pii.Type.String(), "PII_TYPE_")), pii.Text)
+                }
             }
             }
         }
     }()
 
     // audio sender goroutine
+    // stopped by ctrl+c or the end of the file, it sends the rest & half-closes the stream
+    // the server then transcribes the audio it still holds & ends the stream
+    stopChan := make(chan struct{})
     go func() {
         ticker := time.NewTicker(time.Duration(audioCfg.Processing.SendingTicker) * time.Millisecond)
         defer ticker.Stop()
 
         // buffer to accumulate audio > 1 second
-        // 16 bit = 2 bytes per sample
-        bytesPerSecond := int(sampleRate * 2) 
         var sendBuffer []byte
+        source := audioChan
+        stop := stopChan
+        stopping, finished := false, false
 
         for {
             select {
             case <-ctx.Done(): {
                 return
             }
-            case <-ticker.C: {
-                // drain channel into local buffer
-                drainLoop:
-                for {
-                    select {
-                    case samples := <-audioChan:
-                        bytes := pkg.Int16SliceToBytes(samples)
-                        sendBuffer = append(sendBuffer, bytes...)
-                    default:
+            case <-stop: {
+                // the audio captured so far is still sent
+                stop, stopping = nil, true
+            }
+            case <-ticker.C:
+            }
+
+            // drain channel into local buffer
+            drainLoop:
+            for {
+                select {
+                case data, ok := <-source:
+                    if !ok {
+                        // end of file, send the rest
+                        source, finished = nil, true
                         break drainLoop
                     }
+                    sendBuffer = append(sendBuffer, data...)
+                default:
+                    break drainLoop
                 }
-                
-                // only send if buffer is larger than 1 second (16000 * 2 bytes)
-                if len(sendBuffer) >= bytesPerSecond {
-                    if err := stream.Send(&pb.AudioChunk{
-                        Data: sendBuffer,
-                        SessionId: sessionID.String(),
-                    }); err != nil {
-                        log.Printf("send error: %v", err)
-                        cancel()
-                        return
-                    }
-                    // clear buffer after sending
-                    sendBuffer = nil
+            }
+            if stopping {
+                source, finished = nil, true
+            }
+
+            // only send if buffer is larger than 1 second (16000 * 2 bytes)
+            if len(sendBuffer) >= bytesPerSecond || (finished && len(sendBuffer) > 0) {
+                if err := stream.Send(&pb.AudioChunk{
+                    Payload: &pb.AudioChunk_Data{Data: sendBuffer},
+                    SessionId: sessionID.String(),
+                }); err != nil {
+                    log.Printf("send error: %v", err)
+                    cancel()
+                    return
                 }
+                // clear buffer after sending
+                sendBuffer = nil
             }
+
+            if finished {
+                if err := stream.CloseSend(); err != nil {
+                    log.Printf("close send error: %v", err)
+                    cancel()
+                }
+                return
             }
         }
     }()
 
-    fmt.Println("\nstart talking... press ctrl+c to stop")
+    if file != nil {
+        fmt.Println("\nstreaming file... press ctrl+c to stop")
+    } else {
+        fmt.Println("\nstart talking... press ctrl+c to stop")
+    }
     fmt.Println("--------------------------------------------------")
 
-    <-sigChan
-    fmt.Println("\nstopping audio client...")
+    // a second ctrl+c doesn't wait for the last transcripts
+    go func() {
+        <-sigChan
+        fmt.Println("\nstopping audio client...")
+        close(stopChan)
+        <-sigChan
+        cancel()
+    }()
 
+    <-receiverDone
     cancel()
-    stream.CloseSend()
-
-    time.Sleep(500 * time.Millisecond)
     fmt.Println("session finished")
 }
+
+// actionLabel is the display tag of the keyword policy action
+func actionLabel(action pb.KeywordAction) string {
+    switch action {
+    case pb.KeywordAction_KEYWORD_ACTION_MASK:
+        return "masked"
+    case pb.KeywordAction_KEYWORD_ACTION_ESCALATE:
+        return "escalated"
+    case pb.KeywordAction_KEYWORD_ACTION_TERMINATE:
+        return "terminated"
+    default:
+        return "warning"
+    }
+}
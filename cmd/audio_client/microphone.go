@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// captureMicrophone streams the default input device as pcm16 into audioChan, stop closes the device
+func captureMicrophone(audioChan chan<- []byte) (stop func()) {
+	// list available input devices
+	devices, err := portaudio.Devices()
+	if err != nil {
+		log.Fatalf("failed to check devices: %v", err)
+	}
+	fmt.Printf("available input devices:\n")
+	for i, dvc := range devices {
+		if dvc.MaxInputChannels > 0 {
+			fmt.Printf("#%d: %s\n", i, dvc.Name)
+		}
+	}
+
+	device, err := portaudio.DefaultInputDevice()
+	if err != nil {
+		log.Fatalf("no default input device")
+	}
+	fmt.Printf("using: %s\n", device.Name)
+
+	if device.DefaultSampleRate != sampleRate {
+		fmt.Printf("warning: device sample rate %.0f ≠ %.0f, the server resamples the declared rate\n", device.DefaultSampleRate, sampleRate)
+	}
+
+	paramsInput := portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   device,
+			Channels: audioChannels,
+			Latency:  device.DefaultLowInputLatency,
+		},
+		SampleRate:      float64(sampleRate),
+		FramesPerBuffer: framesPerBuf,
+	}
+
+	streamCb, err := portaudio.OpenStream(paramsInput, func(in []int16) {
+		if len(in) == 0 {
+			return
+		}
+
+		select {
+		case audioChan <- pkg.Int16SliceToBytes(in):
+			// audio chunk accepted
+		default:
+			// buffer full → drop to avoid blocking real-time callback
+			log.Print("audio buffer full, dropping chunk")
+		}
+	})
+	if err != nil {
+		log.Fatalf("fail to open audio stream: %v", err)
+	}
+
+	err = streamCb.Start()
+	if err != nil {
+		streamCb.Close()
+		log.Fatalf("fail to start audio stream: %v", err)
+	}
+
+	return func() {
+		streamCb.Stop()
+		streamCb.Close()
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_wav "showcase-backend-audio_transcriber-go/pkg/wav"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	fileChunkDuration  = 25 * time.Second // whisper works on 30s windows
+	fileChunksInFlight = 4                // queued ahead of the awaited one, their prompt lacks the text in between
+	maxFileMB          = 64
+)
+
+// errFileTerminated stops a file transcription after a terminating transcript
+var errFileTerminated = errors.New("file transcription terminated")
+
+// Transcribe transcribes a complete recording, the whole transcript in one reply
+// a terminating action or strike level stops it, the reply has the transcript so far
+func (s *server) Transcribe(ctx context.Context, req *pb.TranscribeRequest) (*pb.TranscribeResponse, error) {
+	var chunks []*pb.Transcript
+	duration, err := s.transcribeFile(ctx, req, func(fb *pb.Transcript) error {
+		chunks = append(chunks, fb)
+		if terminationStatus(fb) != nil {
+			return errFileTerminated
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFileTerminated) {
+		return nil, err
+	}
+
+	resp := fileTranscriptResponse(chunks)
+	resp.DurationMs = duration.Milliseconds()
+	resp.Terminated = err != nil
+	return resp, nil
+}
+
+// TranscribeLong transcribes a complete recording, each chunk is sent once done
+// a terminating action or strike level ends the stream like TranscribeStream
+func (s *server) TranscribeLong(req *pb.TranscribeRequest, stream pb.SpeechService_TranscribeLongServer) error {
+	_, err := s.transcribeFile(stream.Context(), req, func(fb *pb.Transcript) error {
+		if err := stream.Send(fb); err != nil {
+			return err
+		}
+		return terminationStatus(fb)
+	})
+	return err
+}
+
+// transcribeFile splits the recording in chunks for the workers & delivers their transcripts in order through send
+// chunks are never dropped, submitting waits for the workers, an error of send stops the transcription
+func (s *server) transcribeFile(ctx context.Context, req *pb.TranscribeRequest, send func(*pb.Transcript) error) (time.Duration, error) {
+	sessionID := req.SessionId
+	if sessionID == "" {
+		sessionID = "unknown-session"
+	}
+
+	sessCfg := defaultSessionConfig()
+	if req.Config != nil {
+		var err error
+		if sessCfg, err = streamConfigApply(req.Config); err != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "config: %v", err)
+		}
+	}
+
+	audio, err := fileAudio(sessionID, req.Audio, sessCfg)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "audio: %v", err)
+	}
+	duration := pkg_audio.SamplesDuration(len(audio) / 2)
+	chunks := splitAudio(audio, int(fileChunkDuration*pkg_audio.WhisperSampleRate/time.Second))
+	log.Printf("[%s] transcribing %s of audio in %d chunks", sessionID, duration, len(chunks))
+
+	// queued chunks are cancelled once the transcription stops
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the strikes are queryable while the file is transcribed, like a stream
+	transcript := newSessionTranscript(sessCfg)
+	if sessionID != "unknown-session" {
+		s.sessions.Store(sessionID, transcript.strikes)
+		defer s.sessions.CompareAndDelete(sessionID, transcript.strikes)
+	}
+
+	type pending struct {
+		respChan chan *pkg_audio.TranscribeResult
+		ref      chunkRef
+	}
+	var queue []pending
+	var offset time.Duration
+	for i := 0; i < len(chunks) || len(queue) > 0; {
+		for ; i < len(chunks) && len(queue) < fileChunksInFlight; i++ {
+			p := pending{
+				respChan: make(chan *pkg_audio.TranscribeResult, 1),
+				ref: chunkRef{
+					id:       uint64(i + 1),
+					offset:   offset,
+					duration: pkg_audio.SamplesDuration(len(chunks[i]) / 2),
+				},
+			}
+			offset += p.ref.duration
+
+			select {
+			case s.reqChan <- &pkg_audio.TranscribeRequest{
+				Audio:     chunks[i],
+				Resp:      p.respChan,
+				Ctx:       ctx,
+				SessionID: sessionID,
+				Options:   transcript.options(),
+				Offset:    p.ref.offset,
+			}:
+			case <-ctx.Done():
+				return duration, status.FromContextError(ctx.Err()).Err()
+			}
+			queue = append(queue, p)
+		}
+
+		p := queue[0]
+		queue = queue[1:]
+
+		var fb *pb.Transcript
+		select {
+		case res := <-p.respChan:
+			if res.Err != nil {
+				log.Printf("[%s] transcription error: %v", sessionID, res.Err)
+				fb = streamErrorTranscript(p.ref, resultErrorCode(res.Err), res.Err.Error())
+			} else {
+				fb = transcript.deliver(res, p.ref, sessionID)
+			}
+		case <-ctx.Done():
+			return duration, status.FromContextError(ctx.Err()).Err()
+		case <-time.After(transcriptionTimeout):
+			log.Printf("[%s] transcription timeout", sessionID)
+			fb = streamErrorTranscript(p.ref, pb.StreamErrorCode_STREAM_ERROR_CODE_TIMEOUT, "transcription timeout")
+		}
+
+		if err := send(fb); err != nil {
+			return duration, err
+		}
+	}
+	return duration, nil
+}
+
+// fileAudio converts a recording to 16 kHz mono pcm16
+// wav & flac files are detected by their header, other audio is raw samples of the declared format
+// a wav file shorter than its header declares is transcribed as far as it goes, like the client streams it
+func fileAudio(sessionID string, data []byte, sessCfg sessionConfig) ([]byte, error) {
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		wav, err := pkg_wav.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		decoder, ok := pkg.LookupDecoder(wav.Format.Encoding)
+		if !ok {
+			return nil, fmt.Errorf("no decoder for wav encoding %s", wav.Format.Encoding)
+		}
+		if data, err = io.ReadAll(wav); err != nil {
+			return nil, err
+		}
+		if wav.Truncated() {
+			log.Printf("[%s] wav file truncated, transcribing the %d bytes of audio it has", sessionID, len(data))
+		}
+		sessCfg.encoding = pb.AudioEncoding(pb.AudioEncoding_value["AUDIO_ENCODING_"+strings.ToUpper(decoder.Name)])
+		sessCfg.decoder = decoder
+		sessCfg.sampleRate, sessCfg.channels = wav.Format.SampleRate, wav.Format.Channels
+
+	case bytes.HasPrefix(data, []byte("fLaC")) || bytes.HasPrefix(data, []byte("ID3")):
+		sessCfg.encoding = pb.AudioEncoding_AUDIO_ENCODING_FLAC
+	}
+
+	input, err := newAudioInput(sessCfg)
+	if err != nil {
+		return nil, err
+	}
+	// a file is complete, unlike streams a corrupt flac frame fails it
+	audio, err := input.write(data)
+	if err != nil {
+		return nil, err
+	}
+	tail, err := input.close()
+	if err != nil {
+		return nil, err
+	}
+	audio = append(audio, tail...)
+	// raw pcm16 passes through, a trailing half sample is dropped
+	audio = audio[:len(audio)-len(audio)%2]
+	if len(audio) == 0 {
+		return nil, errors.New("no audio")
+	}
+	return audio, nil
+}
+
+// splitAudio cuts pcm16 in chunks of at most maxSamples
+// each cut is at the quietest 20 ms of the chunk's last quarter, words are rarely split
+func splitAudio(audio []byte, maxSamples int) [][]byte {
+	const frame = pkg_audio.WhisperSampleRate / 50
+	samples, _ := pkg.BytesToFloat32(audio)
+
+	var chunks [][]byte
+	start := 0
+	for len(samples)-start > maxSamples {
+		cut := start + maxSamples
+		quietest := -1.0
+		for f := start + maxSamples*3/4; f+frame <= start+maxSamples; f += frame {
+			energy := 0.0
+			for _, v := range samples[f : f+frame] {
+				energy += float64(v) * float64(v)
+			}
+			if quietest < 0 || energy < quietest {
+				cut, quietest = f+frame/2, energy
+			}
+		}
+		chunks = append(chunks, audio[start*2:cut*2])
+		start = cut
+	}
+	return append(chunks, audio[start*2:len(samples)*2])
+}
+
+// fileTranscriptResponse joins the chunk transcripts, offsets are moved into the joined raw_text
+func fileTranscriptResponse(chunks []*pb.Transcript) *pb.TranscribeResponse {
+	resp := &pb.TranscribeResponse{Chunks: chunks}
+	var text strings.Builder
+	var runes int32
+	seen := map[string]bool{}
+	failed := false
+
+	for _, fb := range chunks {
+		if fb.Status == pb.TranscriptStatus_TRANSCRIPT_STATUS_ERROR {
+			failed = true
+			continue
+		}
+		if fb.RawText == "" {
+			continue
+		}
+		if text.Len() > 0 {
+			text.WriteByte(' ')
+			runes++
+		}
+		shift := runes
+		text.WriteString(fb.RawText)
+		runes += int32(utf8.RuneCountInString(fb.RawText))
+
+		if resp.Language == "" {
+			resp.Language = fb.Language
+		}
+		resp.Segments = append(resp.Segments, fb.Segments...)
+		resp.KeywordMatches = append(resp.KeywordMatches, shiftMatches(fb.KeywordMatches, shift)...)
+		resp.SuppressedMatches = append(resp.SuppressedMatches, shiftMatches(fb.SuppressedMatches, shift)...)
+		for _, match := range fb.PiiMatches {
+			match = proto.Clone(match).(*pb.PiiMatch)
+			match.Start += shift
+			match.End += shift
+			resp.PiiMatches = append(resp.PiiMatches, match)
+		}
+		for _, keyword := range fb.DetectedKeywords {
+			if !seen[keyword] {
+				seen[keyword] = true
+				resp.DetectedKeywords = append(resp.DetectedKeywords, keyword)
+			}
+		}
+		resp.Action = max(resp.Action, fb.Action)
+	}
+	resp.RawText = text.String()
+
+	switch {
+	case len(resp.DetectedKeywords) > 0:
+		resp.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_WARNING
+	case failed:
+		resp.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_ERROR
+	case resp.RawText != "":
+		resp.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_OK
+	default:
+		resp.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_EMPTY
+	}
+	return resp
+}
+
+func shiftMatches(matches []*pb.KeywordMatch, shift int32) []*pb.KeywordMatch {
+	out := make([]*pb.KeywordMatch, 0, len(matches))
+	for _, match := range matches {
+		match = proto.Clone(match).(*pb.KeywordMatch)
+		match.Start += shift
+		match.End += shift
+		out = append(out, match)
+	}
+	return out
+}
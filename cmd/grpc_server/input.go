@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_convert "showcase-backend-audio_transcriber-go/pkg/convert"
+	pkg_flac "showcase-backend-audio_transcriber-go/pkg/flac"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+)
+
+// errInputFormat means the stream audio can't be converted at all, i.e. a flac stream of an unsupported format
+var errInputFormat = errors.New("unsupported input format")
+
+// audioInput turns client audio of the declared stream format & encoding into 16 kHz mono pcm16
+// chunks of converted streams may split frames, the partial frame waits for the next chunk
+// one per stream, not thread safe
+type audioInput struct {
+	frameSize int // bytes per interleaved frame
+	pending   []byte
+	decoder   pkg.Decoder
+	converter *pkg_convert.Converter // nil until the flac stream info is decoded
+
+	flac    *pkg_flac.Decoder // flac streams carry their own format
+	written int               // pcm16 bytes returned
+}
+
+func newAudioInput(sessCfg sessionConfig) (*audioInput, error) {
+	if sessCfg.encoding == pb.AudioEncoding_AUDIO_ENCODING_FLAC {
+		return &audioInput{flac: pkg_flac.NewDecoder()}, nil
+	}
+
+	converter, err := pkg_convert.NewConverter(pkg_convert.Format{
+		SampleRate: sessCfg.sampleRate,
+		Channels:   sessCfg.channels,
+	}, pkg_audio.WhisperSampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &audioInput{
+		frameSize: sessCfg.decoder.SampleSize * sessCfg.channels,
+		decoder:   sessCfg.decoder,
+		converter: converter,
+	}, nil
+}
+
+// write returns the converted audio of the whole frames received so far
+// 16 kHz mono pcm16 goes through untouched, the workers report invalid pcm16
+// flac frames failing their crc are reported & skipped, the audio of the others is still returned
+func (a *audioInput) write(data []byte) ([]byte, error) {
+	if a.flac != nil {
+		return a.writeFlac(data)
+	}
+	if a.converter.Passthrough() && a.decoder.Name == pcm16Decoder.Name {
+		a.written += len(data)
+		return data, nil
+	}
+
+	a.pending = append(a.pending, data...)
+	whole := len(a.pending) - len(a.pending)%a.frameSize
+	if whole == 0 {
+		return nil, nil
+	}
+
+	// whole frames are always whole samples
+	samples, _ := a.decoder.Decode(a.pending[:whole])
+	a.pending = append(a.pending[:0], a.pending[whole:]...)
+
+	return a.output(a.converter.Convert(samples)), nil
+}
+
+func (a *audioInput) writeFlac(data []byte) ([]byte, error) {
+	samples, err := a.flac.Decode(data)
+	info, ok := a.flac.Info()
+	if !ok {
+		if err != nil {
+			err = fmt.Errorf("%w: %w", errInputFormat, err)
+		}
+		return nil, err
+	}
+
+	if a.converter == nil {
+		converter, convErr := pkg_convert.NewConverter(pkg_convert.Format{
+			SampleRate: info.SampleRate,
+			Channels:   info.Channels,
+		}, pkg_audio.WhisperSampleRate)
+		if convErr != nil {
+			return nil, fmt.Errorf("%w: flac stream: %w", errInputFormat, convErr)
+		}
+		a.converter = converter
+	}
+
+	return a.output(a.converter.Convert(samples)), err
+}
+
+// output quantizes to pcm16, the format of the segmentation & the workers
+// wider samples are rounded to the nearest value, out of range ones (float32 input, resampler overshoot) clip instead of wrapping
+func (a *audioInput) output(samples []float32) []byte {
+	data := pkg.Float32ToBytes(samples)
+	a.written += len(data)
+	return data
+}
+
+// offset returns the audio duration returned so far
+func (a *audioInput) offset() time.Duration {
+	return pkg_audio.SamplesDuration(a.written / 2)
+}
+
+// close ends the input & returns the audio still held by the resampler
+// a flac stream is checked against its md5 signature, the tail is returned either way
+func (a *audioInput) close() ([]byte, error) {
+	var tail []byte
+	if a.converter != nil && !a.converter.Passthrough() {
+		tail = a.output(a.converter.Flush())
+	}
+	if a.flac == nil {
+		return tail, nil
+	}
+	return tail, a.flac.Close()
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -13,31 +14,51 @@ import (
 	"os/signal"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	pkg "showcase-backend-audio_transcriber-go/pkg"
 	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
 	pkg_grpc "showcase-backend-audio_transcriber-go/pkg/grpc"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
 	pkg_whisper "showcase-backend-audio_transcriber-go/pkg/whisper"
 	pb "showcase-backend-audio_transcriber-go/protobuf"
 
-	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	forbiddenEnKeywords       []string
+	keywordEngine             *pkg_keyword.Engine
+	keywordPolicies           atomic.Pointer[map[string]pkg_keyword.Policy] // by id, selected per session, see policiesLoad
+	piiConfig                 atomic.Pointer[pkg_pii.Config]                // reloaded with the keywords
 	audioProcessingMs         int
 	transcribeStreamChunkSize int
+	audioSegmentation         string
+	vadConfig                 pkg_audio.VadConfig
+	slidingOverlapMs          int
+	transcriptTailWords       = 16 // words of the previous transcript matched again with the next one
+	whisperPrompt             atomic.Pointer[promptConfig] // reloaded with the keywords, see promptLoad
+	transcriptionTimeout      = 15 * time.Second
+	configWatchInterval       = 2 * time.Second
 )
 
 type server struct {
 	pb.UnimplementedSpeechServiceServer
-	reqChan chan *pkg_audio.TranscribeRequest
+	reqChan  chan *pkg_audio.TranscribeRequest
+	sessions sync.Map // session id -> *pkg_keyword.StrikeTracker, live streams & file transcriptions only
 }
 
 func (s *server) TranscribeStream(stream pb.SpeechService_TranscribeStreamServer) error {
 	var buffer bytes.Buffer
+	var bufferMu sync.Mutex
+	var streamPos int // bytes received so far, for offsets relative to the session start
+	var chunkSeq atomic.Uint64
+	timeout := transcriptionTimeout
+	// a late session id is set by the receive loop under bufferMu, other goroutines read it under bufferMu
 	currentSessionID := "unknown-session"
 
 	ctx, cancel := context.WithCancel(stream.Context())
@@ -47,171 +68,563 @@ func (s *server) TranscribeStream(stream pb.SpeechService_TranscribeStreamServer
 
 	log.Printf("new client connected")
 
+	// handshake: the first message should be a StreamConfig
+	// legacy clients start with audio right away, they get the default config
+	first, err := stream.Recv()
+	if err != nil {
+		if err.Error() == "EOF" {
+			log.Printf("[%s] client disconnected", currentSessionID)
+			return nil
+		}
+		log.Printf("[%s] stream recv error: %v", currentSessionID, err)
+		return err
+	}
+	if first.SessionId != "" {
+		currentSessionID = first.SessionId
+		log.Printf("session identified: %s", currentSessionID)
+	}
+
+	sessCfg := defaultSessionConfig()
+	if cfg := first.GetConfig(); cfg != nil {
+		sessCfg, err = streamConfigApply(cfg)
+		ack := &pb.StreamConfigAck{Accepted: err == nil}
+		if err != nil {
+			ack.Reason = err.Error()
+		}
+		if sendErr := stream.Send(&pb.Transcript{ConfigAck: ack}); sendErr != nil {
+			log.Printf("[%s] send config ack error: %v", currentSessionID, sendErr)
+			return sendErr
+		}
+		if err != nil {
+			log.Printf("[%s] stream config rejected: %v", currentSessionID, err)
+			return status.Errorf(codes.InvalidArgument, "stream config: %v", err)
+		}
+		log.Printf("[%s] stream config accepted: %d hz, %d ch, %s, language %s, policy %s, metadata %v",
+			currentSessionID, sessCfg.sampleRate, sessCfg.channels, sessCfg.encoding, sessCfg.options.Language, sessCfg.policyID, sessCfg.metadata)
+		first = nil
+	} else {
+		log.Printf("[%s] no stream config, using defaults", currentSessionID)
+	}
+
+	// converts the declared format to 16 kHz mono pcm16
+	input, err := newAudioInput(sessCfg)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "stream config: %v", err)
+	}
+
+	// strikes are tracked from the start, the session is queryable once identified
+	transcript := newSessionTranscript(sessCfg)
+	strikes := transcript.strikes
+	var registeredID string
+	register := func(sessionID string) {
+		if sessionID == "unknown-session" || registeredID != "" {
+			return
+		}
+		registeredID = sessionID
+		s.sessions.Store(sessionID, strikes)
+	}
+	defer func() {
+		if registeredID != "" {
+			s.sessions.CompareAndDelete(registeredID, strikes)
+		}
+	}()
+	register(currentSessionID)
+
+	// set by the feedback sender once a terminating message is delivered, the stream ends with it
+	terminated := make(chan error, 1)
+
 	// feedback sender
+	// feedbackChan is never closed, senders outlive this goroutine
+	// a nil message marks the end of the stream, everything queued before it is sent
+	senderDone := make(chan struct{})
 	go func() {
-		defer close(feedbackChan)
+		defer close(senderDone)
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case fb, ok := <-feedbackChan:
-				if !ok {
+			case fb := <-feedbackChan:
+				if fb == nil {
 					return
 				}
 				if err := stream.Send(fb); err != nil {
-					log.Printf("[%s] send feedback error: %v", currentSessionID, err)
+					bufferMu.Lock()
+					sessionID := currentSessionID
+					bufferMu.Unlock()
+					log.Printf("[%s] send feedback error: %v", sessionID, err)
+					return
+				}
+				if err := terminationStatus(fb); err != nil {
+					terminated <- err
 					return
 				}
 			}
 		}
 	}()
 
-	// audio processor trigger
-	processTicker := time.NewTicker(time.Duration(audioProcessingMs) * time.Millisecond)
-	defer processTicker.Stop()
+	// sendFeedback queues a message for the feedback sender, never blocks the caller
+	sendFeedback := func(fb *pb.Transcript, sessionID string) {
+		select {
+		case feedbackChan <- fb:
+		case <-ctx.Done():
+			return
+		default:
+			log.Printf("[%s] timeout sending feedback", sessionID)
+		}
+	}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
+	// submit queues audio for the workers, false when the chunk is dropped
+	// offset is the audio start relative to the session start
+	submit := func(audioData []byte, offset time.Duration, sessionID string) (<-chan *pkg_audio.TranscribeResult, chunkRef, bool) {
+		ref := chunkRef{
+			id:       chunkSeq.Add(1),
+			offset:   offset,
+			duration: pkg_audio.SamplesDuration(len(audioData) / 2),
+		}
+
+		respChan := make(chan *pkg_audio.TranscribeResult, 1)
+		req := &pkg_audio.TranscribeRequest{
+			Audio:     audioData,
+			Resp:      respChan,
+			Ctx:       ctx,
+			SessionID: sessionID,
+			Options:   transcript.options(),
+			Offset:    offset,
+		}
+
+		select {
+		case s.reqChan <- req:
+			// request queued
+			return respChan, ref, true
+		case <-ctx.Done():
+			return nil, ref, false
+		default:
+			log.Printf("[%s] dropping chunk: workers busy", sessionID)
+			sendFeedback(streamErrorTranscript(ref, pb.StreamErrorCode_STREAM_ERROR_CODE_WORKERS_BUSY, "dropping chunk: workers busy"), sessionID)
+			return nil, ref, false
+		}
+	}
+
+	// await waits for the worker result, nil when cancelled, timed out or failed
+	// timeouts & failures are reported to the client
+	await := func(respChan <-chan *pkg_audio.TranscribeResult, ref chunkRef, sessionID string) *pkg_audio.TranscribeResult {
+		var res *pkg_audio.TranscribeResult
+		select {
+		case res = <-respChan:
+			// got result
+		case <-ctx.Done():
+			return nil
+		case <-time.After(timeout):
+			log.Printf("[%s] transcription timeout", sessionID)
+			sendFeedback(streamErrorTranscript(ref, pb.StreamErrorCode_STREAM_ERROR_CODE_TIMEOUT, "transcription timeout"), sessionID)
+			return nil
+		}
+
+		if res.Err != nil {
+			log.Printf("[%s] transcription error: %v", sessionID, res.Err)
+			sendFeedback(streamErrorTranscript(ref, resultErrorCode(res.Err), res.Err.Error()), sessionID)
+			return nil
+		}
+
+		return res
+	}
+
+	// deliver sends the result to the client once the session policy is applied
+	// results must be delivered in transcript order
+	deliver := func(res *pkg_audio.TranscribeResult, ref chunkRef, sessionID string) {
+		sendFeedback(transcript.deliver(res, ref, sessionID), sessionID)
+	}
+
+	// enqueue sends audio to the workers & forwards the result in background
+	// workers run concurrently, results are delivered in submit order
+	// called from one goroutine only, the receive loop or the ticker
+	delivered := make(chan struct{})
+	close(delivered)
+	enqueue := func(audioData []byte, offset time.Duration, sessionID string) {
+		respChan, ref, ok := submit(audioData, offset, sessionID)
+		if !ok {
+			return
+		}
+		prev, done := delivered, make(chan struct{})
+		delivered = done
+		go func() {
+			defer close(done)
+			res := await(respChan, ref, sessionID)
+			<-prev
+			if res != nil {
+				deliver(res, ref, sessionID)
+			}
+		}()
+	}
+
+	// segmentation:
+	// - vad: enqueue each utterance once it's complete (see receive loop)
+	// - sliding: every audio_processing ms, keep sliding_overlap ms of trailing audio for the next window
+	// - ticker: fallback, flush the buffer every audio_processing ms
+	// closing eof makes the segmentation goroutine process the buffered audio & exit
+	eof := make(chan struct{})
+	var segmentation sync.WaitGroup
+	var vad *pkg_audio.Vad
+	switch audioSegmentation {
+	case "vad":
+		vad = pkg_audio.NewVad(vadConfig)
+	case "sliding":
+		processTicker := time.NewTicker(time.Duration(audioProcessingMs) * time.Millisecond)
+		defer processTicker.Stop()
+
+		// pcm16 mono, 2 bytes per sample
+		overlapBytes := pkg_audio.WhisperSampleRate * slidingOverlapMs / 1000 * 2
+
+		// text of the previous window, its tail is re-transcribed in the next one
+		var prevText string
+
+		// slide transcribes the buffer & keeps the overlap for the next window
+		// windows are processed one at a time so the texts merge in order
+		// ticks are skipped while waiting, audio keeps accumulating in the buffer
+		slide := func() {
+			bufferMu.Lock()
+			if buffer.Len() <= overlapBytes {
+				bufferMu.Unlock()
+				return
+			}
+			window := make([]byte, buffer.Len())
+			copy(window, buffer.Bytes())
+			buffer.Reset()
+			buffer.Write(window[len(window)-overlapBytes:])
+			offset := pkg_audio.SamplesDuration((streamPos - len(window)) / 2)
+			sessionID := currentSessionID
+			bufferMu.Unlock()
+
+			respChan, ref, ok := submit(window, offset, sessionID)
+			if !ok {
 				return
-			case <-processTicker.C:
-				if buffer.Len() > 0 {
-					audioData := buffer.Bytes()
+			}
+			res := await(respChan, ref, sessionID)
+			if res == nil {
+				return
+			}
 
-					if len(audioData) == 0 {
-						continue
-					}
+			delta := pkg.MergeOverlappingText(prevText, res.Text)
+			prevText = res.Text
 
-					dataToSend := make([]byte, len(audioData))
-					copy(dataToSend, audioData)
-					buffer.Reset()
+			// phrases reaching back into the previous window are matched by deliver on the transcript tail
+			matches, suppressed := keywordEngine.Snapshot().Match(res.Language, delta)
 
-					respChan := make(chan *pkg_audio.TranscribeResult, 1)
-					req := &pkg_audio.TranscribeRequest{
-						Audio:     dataToSend,
-						Resp:      respChan,
-						Ctx:       ctx,
-						SessionID: currentSessionID,
-					}
+			// segments fully inside the overlap were already sent with the previous window
+			newAudioStart := offset + time.Duration(slidingOverlapMs)*time.Millisecond
+			segments := []pkg_audio.Segment{}
+			for _, segment := range res.Segments {
+				if segment.End > newAudioStart {
+					segments = append(segments, segment)
+				}
+			}
 
-					select {
-					case s.reqChan <- req:
-						// request queued
-					case <-ctx.Done():
-						return
-					default:
-						log.Printf("[%s] dropping chunk: workers busy", currentSessionID)
-						continue
-					}
+			deliver(&pkg_audio.TranscribeResult{
+				Text:       delta,
+				Language:   res.Language,
+				Warning:    len(matches) > 0,
+				Keywords:   pkg_keyword.MatchedTerms(matches),
+				Matches:    matches,
+				Suppressed: suppressed,
+				Segments:   segments,
+			}, ref, sessionID)
+		}
 
-					// listen for response in background
-					go func(sessionID string) {
-						var res *pkg_audio.TranscribeResult
-						select {
-						case res = <-respChan:
-							// got result
-						case <-ctx.Done():
-							return
-						case <-time.After(15 * time.Second):
-							log.Printf("[%s] transcription timeout", sessionID)
-							return
-						}
-
-						if res.Err != nil {
-							log.Printf("[%s] transcription error: %v", sessionID, res.Err)
-							return
-						}
-
-						var fb *pb.Transcript
-						if res.Warning {
-							fb = &pb.Transcript{
-								Text:             fmt.Sprintf("detected forbidden keyword: %v - '%s'", res.Keywords, res.Text),
-								Warning:          true,
-								DetectedKeywords: res.Keywords,
-							}
-							log.Printf("[%s] forbidden keywords detected: %v", sessionID, res.Keywords)
-						} else if res.Text != "" {
-							fb = &pb.Transcript{
-								Text:    fmt.Sprintf("ok: '%s'", res.Text),
-								Warning: false,
-							}
-							log.Printf("[%s] processed: '%s'", sessionID, res.Text)
-						}
-
-						if fb != nil {
-							select {
-							case feedbackChan <- fb:
-							case <-ctx.Done():
-								return
-							default:
-								log.Printf("[%s] timeout sending feedback", sessionID)
-							}
-						}
-					}(currentSessionID)
+		segmentation.Add(1)
+		go func() {
+			defer segmentation.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-eof:
+					slide()
+					return
+				case <-processTicker.C:
+					slide()
 				}
 			}
+		}()
+	default:
+		processTicker := time.NewTicker(time.Duration(audioProcessingMs) * time.Millisecond)
+		defer processTicker.Stop()
+
+		flush := func() {
+			bufferMu.Lock()
+			if buffer.Len() == 0 {
+				bufferMu.Unlock()
+				return
+			}
+			dataToSend := make([]byte, buffer.Len())
+			copy(dataToSend, buffer.Bytes())
+			buffer.Reset()
+			offset := pkg_audio.SamplesDuration((streamPos - len(dataToSend)) / 2)
+			sessionID := currentSessionID
+			bufferMu.Unlock()
+
+			enqueue(dataToSend, offset, sessionID)
+		}
+
+		segmentation.Add(1)
+		go func() {
+			defer segmentation.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-eof:
+					flush()
+					return
+				case <-processTicker.C:
+					flush()
+				}
+			}
+		}()
+	}
+
+	// push feeds converted audio to the active segmentation
+	push := func(data []byte) {
+		if vad != nil {
+			for _, utterance := range vad.Write(data) {
+				enqueue(utterance.Audio, utterance.Start, currentSessionID)
+			}
+			return
+		}
+
+		bufferMu.Lock()
+		buffer.Write(data)
+		streamPos += len(data)
+
+		if buffer.Len() > transcribeStreamChunkSize*10 {
+			log.Printf("[%s] buffer overflow, resetting", currentSessionID)
+			buffer.Reset()
+		}
+
+		bufferMu.Unlock()
+	}
+
+	// handle buffers an audio chunk for the active segmentation
+	handle := func(chunk *pb.AudioChunk) {
+		if chunk.GetConfig() != nil {
+			log.Printf("[%s] ignoring stream config after handshake", currentSessionID)
+			return
+		}
+
+		data, err := input.write(chunk.GetData())
+		if errors.Is(err, errInputFormat) {
+			select {
+			case terminated <- status.Errorf(codes.InvalidArgument, "audio input: %v", err):
+			default:
+			}
+			return
+		}
+		if err != nil {
+			// corrupt frames are lost, the rest of the chunk goes on
+			log.Printf("[%s] audio input: %v", currentSessionID, err)
+			ref := chunkRef{id: chunkSeq.Add(1), offset: input.offset()}
+			sendFeedback(streamErrorTranscript(ref, pb.StreamErrorCode_STREAM_ERROR_CODE_AUDIO_CONVERSION, err.Error()), currentSessionID)
+		}
+
+		// ignore empty data
+		if len(data) == 0 {
+			return
+		}
+
+		// update session id if present
+		bufferMu.Lock()
+		if chunk.SessionId != "" && currentSessionID == "unknown-session" {
+			currentSessionID = chunk.SessionId
+			log.Printf("session identified: %s", currentSessionID)
+			register(currentSessionID)
+		}
+		bufferMu.Unlock()
+
+		push(data)
+	}
+
+	// finish transcribes the audio still buffered when the client closes its side
+	// results in flight are delivered & sent before the stream ends
+	finish := func() error {
+		close(eof)
+		segmentation.Wait()
+		if vad != nil {
+			if utterance := vad.Flush(); utterance != nil {
+				enqueue(utterance.Audio, utterance.Start, currentSessionID)
+			}
 		}
-	}()
 
-	// receive audio chunks from client
-	for {
 		select {
+		case <-delivered:
 		case <-ctx.Done():
 			return nil
+		}
+		select {
+		case feedbackChan <- nil:
+		case <-senderDone:
+		case <-ctx.Done():
+			return nil
+		}
+		select {
+		case <-senderDone:
+		case <-ctx.Done():
+			return nil
+		}
+
+		// the last transcript may have ended the stream
+		select {
+		case err := <-terminated:
+			log.Printf("[%s] stream terminated: %v", currentSessionID, err)
+			return err
 		default:
+			return nil
+		}
+	}
+
+	// legacy clients: the first message is already audio
+	if first != nil {
+		handle(first)
+	}
+
+	// receive audio chunks from client
+	// recv blocks, it runs aside so a terminate action can end the stream meanwhile
+	type received struct {
+		chunk *pb.AudioChunk
+		err   error
+	}
+	recvChan := make(chan received)
+	go func() {
+		for {
 			chunk, err := stream.Recv()
-			if err != nil {
-				if err.Error() == "EOF" {
-					log.Printf("[%s] client disconnected", currentSessionID)
-					return nil
-				}
-				log.Printf("[%s] stream recv error: %v", currentSessionID, err)
-				return err
+			select {
+			case recvChan <- received{chunk, err}:
+			case <-ctx.Done():
+				return
 			}
-
-			// ignore empty data
-			if len(chunk.Data) == 0 {
-				continue
+			if err != nil {
+				return
 			}
+		}
+	}()
 
-			// update session id if present
-			if chunk.SessionId != "" && currentSessionID == "unknown-session" {
-				currentSessionID = chunk.SessionId
-				log.Printf("session identified: %s", currentSessionID)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-terminated:
+			log.Printf("[%s] stream terminated: %v", currentSessionID, err)
+			return err
+		case r := <-recvChan:
+			if r.err != nil {
+				if r.err.Error() == "EOF" {
+					// the resampler holds the last few ms
+					tail, err := input.close()
+					if err != nil {
+						log.Printf("[%s] audio input: %v", currentSessionID, err)
+					}
+					if len(tail) > 0 {
+						push(tail)
+					}
+					log.Printf("[%s] client disconnected", currentSessionID)
+					return finish()
+				}
+				log.Printf("[%s] stream recv error: %v", currentSessionID, r.err)
+				return r.err
 			}
 
-			buffer.Write(chunk.Data)
+			handle(r.chunk)
+		}
+	}
+}
 
-			if buffer.Len() > transcribeStreamChunkSize*10 {
-				log.Printf("[%s] buffer overflow, resetting", currentSessionID)
-				buffer.Reset()
-			}
+// transcriberBackendLoad picks the inference backend from the audio config
+func transcriberBackendLoad(audioCfg pkg_audio.AudioConfig) (pkg_audio.NewTranscriberFunc, func() error, error) {
+	switch audioCfg.Transcriber.Backend {
+	case "", "whisper":
+		// load whisper model once
+		backend, err := pkg_whisper.WhisperBackendLoad(audioCfg.Whisper.Model)
+		if err != nil {
+			return nil, nil, fmt.Errorf("whisper model: %w", err)
 		}
+		log.Printf("whisper model loaded: %s", audioCfg.Whisper.Model)
+		return backend.NewTranscriber, backend.Close, nil
+	case "scripted":
+		// one shared instance so the script order is kept across workers
+		scripted := pkg_audio.NewScriptedTranscriber(audioCfg.Transcriber.Script...)
+		log.Printf("scripted transcriber loaded: %d lines", len(audioCfg.Transcriber.Script))
+		newTranscriber := func() (pkg_audio.Transcriber, error) {
+			return scripted, nil
+		}
+		return newTranscriber, func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown transcriber backend %q", audioCfg.Transcriber.Backend)
 	}
 }
 
+const (
+	audioConfigPath = "../../config.audio.json"
+	grpcConfigPath  = "../../config.grpc.json"
+)
+
 func main() {
-	grpcCfg, err := pkg_grpc.GrpcConfigLoad("../../config.grpc.json")
+	grpcCfg, err := pkg_grpc.GrpcConfigLoad(grpcConfigPath)
 	if err != nil {
 		log.Fatalf("failed to load grpc config: %v", err)
 	}
-	audioCfg, err := pkg_audio.AudioConfigLoad("../../config.audio.json")
+	audioCfg, err := pkg_audio.AudioConfigLoad(audioConfigPath)
 	if err != nil {
 		log.Fatalf("failed to load audio config: %v", err)
 	}
 
-	forbiddenEnKeywords = audioCfg.Keywords.Forbidden.En
+	// compiled up front, shared by all workers & sessions, swapped on reload
+	if err := audioConfigApply(audioCfg); err != nil {
+		log.Fatalf("invalid keyword config: %v", err)
+	}
+	audioReloader, err := newConfigReloader(audioConfigPath, audioCfg, pkg_audio.AudioConfigLoad, audioConfigApply, audioConfigLive)
+	if err != nil {
+		log.Fatalf("failed to watch audio config: %v", err)
+	}
+	// server settings apply on restart only, a reload names the changed ones
+	grpcReloader, err := newConfigReloader(grpcConfigPath, grpcCfg, pkg_grpc.GrpcConfigLoad, nil, nil)
+	if err != nil {
+		log.Fatalf("failed to watch grpc config: %v", err)
+	}
+
 	audioProcessingMs = grpcCfg.Processing.AudioProcessing
 	transcribeStreamChunkSize = grpcCfg.Processing.TranscribeStreamChunkSize
+	audioSegmentation = grpcCfg.Processing.Segmentation
+	vadConfig = grpcCfg.Processing.Vad
+	slidingOverlapMs = grpcCfg.Processing.SlidingOverlap
+	if grpcCfg.Processing.FileChunk > 0 {
+		fileChunkDuration = time.Duration(grpcCfg.Processing.FileChunk) * time.Millisecond
+	}
+	if grpcCfg.Processing.MaxFileMB > 0 {
+		maxFileMB = grpcCfg.Processing.MaxFileMB
+	}
+	switch tailWords := grpcCfg.Processing.TranscriptTailWords; {
+	case tailWords < 0:
+		transcriptTailWords = 0
+	case tailWords > 0:
+		transcriptTailWords = tailWords
+	}
 
-	// load whisper model once
-	model, err := whisper.New(audioCfg.Whisper.Model)
+	switch audioSegmentation {
+	case "", "ticker":
+		audioSegmentation = "ticker"
+	case "vad":
+	case "sliding":
+		if slidingOverlapMs < 0 || slidingOverlapMs >= audioProcessingMs {
+			log.Fatalf("sliding_overlap must be within 0 and audio_processing (%d ms)", audioProcessingMs)
+		}
+	default:
+		log.Fatalf("unknown segmentation %q, expected vad, sliding or ticker", audioSegmentation)
+	}
+	log.Printf("audio segmentation: %s, transcript tail %d words", audioSegmentation, transcriptTailWords)
+
+	newTranscriber, closeTranscriber, err := transcriberBackendLoad(audioCfg)
 	if err != nil {
-		log.Fatalf("failed to load whisper model: %v", err)
+		log.Fatalf("failed to load transcriber backend: %v", err)
 	}
-	defer model.Close()
-	log.Printf("whisper model loaded: %s", audioCfg.Whisper.Model)
+	defer closeTranscriber()
 
 	// note: 
 	// - this one is to protect cgo inference calls
@@ -228,14 +641,15 @@ func main() {
 	reqChan := make(chan *pkg_audio.TranscribeRequest, 100)
 	
 	log.Printf("starting %d whisper workers", numWorkers)
-	pkg_whisper.WhisperWorkerPool(model, reqChan, numWorkers, &inferenceMu, forbiddenEnKeywords)
+	pkg_whisper.WhisperWorkerPool(newTranscriber, reqChan, numWorkers, &inferenceMu, keywordEngine)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", grpcCfg.Listener.Address, grpcCfg.Listener.Port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	// Transcribe requests carry whole recordings
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxFileMB << 20))
 	pb.RegisterSpeechServiceServer(grpcServer, &server{reqChan: reqChan})
 
 	// graceful shutdown on signal
@@ -249,6 +663,22 @@ func main() {
 		close(reqChan)
 	}()
 
+	// hot reload of the keyword config on SIGHUP & on file change
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			if err := audioReloader.reload("SIGHUP"); err != nil {
+				log.Printf("config reload failed, keeping the active config: %v", err)
+			}
+			if err := grpcReloader.reload("SIGHUP"); err != nil {
+				log.Printf("config reload failed, keeping the active config: %v", err)
+			}
+		}
+	}()
+	go audioReloader.watch(context.Background(), configWatchInterval)
+	go grpcReloader.watch(context.Background(), configWatchInterval)
+
 	log.Printf("server running on %s:%d", grpcCfg.Listener.Address, grpcCfg.Listener.Port)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("server failed: %v", err)
@@ -3,15 +3,30 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
 	"io"
+	"log"
+	"math"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	pb "showcase-backend-audio_transcriber-go/protobuf"
+	pkg "showcase-backend-audio_transcriber-go/pkg"
 	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_grpc "showcase-backend-audio_transcriber-go/pkg/grpc"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
+	pkg_wav "showcase-backend-audio_transcriber-go/pkg/wav"
+	pkg_whisper "showcase-backend-audio_transcriber-go/pkg/whisper"
 )
 
 // TestMain initializes global config variables for tests
@@ -42,9 +57,13 @@ func newMockStream(ctx context.Context) *mockStream {
 	}
 }
 
+// Recv returns io.EOF once recvChan is closed, like a client calling CloseSend
 func (m *mockStream) Recv() (*pb.AudioChunk, error) {
 	select {
-	case msg := <-m.recvChan:
+	case msg, ok := <-m.recvChan:
+		if !ok {
+			return nil, io.EOF
+		}
 		return msg, nil
 	case <-m.ctx.Done():
 		return nil, io.EOF
@@ -72,6 +91,19 @@ func (d *dummyServerStream) Context() context.Context         { return d.ctx }
 func (d *dummyServerStream) SendMsg(m interface{}) error      { return nil }
 func (d *dummyServerStream) RecvMsg(m interface{}) error      { return nil }
 
+// newTestEngine compiles word mode keywords
+func newTestEngine(t *testing.T, terms ...string) *pkg_keyword.Engine {
+	t.Helper()
+	engine, err := pkg_keyword.NewEngine(pkg_keyword.Lists{
+		Forbidden:       map[string][]pkg_keyword.Keyword{"en": pkg_keyword.Words(terms...)},
+		DefaultLanguage: "en",
+	})
+	if err != nil {
+		t.Fatalf("failed to compile keywords: %v", err)
+	}
+	return engine
+}
+
 // --- tests ---
 func TestTranscribeStreamBasicFlow(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -85,7 +117,7 @@ func TestTranscribeStreamBasicFlow(t *testing.T) {
 	go func() {
 		time.Sleep(10 * time.Millisecond)
 		stream.recvChan <- &pb.AudioChunk{
-			Data:      []byte{0x00, 0x00, 0x00, 0x00},
+			Payload:   &pb.AudioChunk_Data{Data: []byte{0x00, 0x00, 0x00, 0x00}},
 			SessionId: "test-session",
 		}
 		time.Sleep(50 * time.Millisecond)
@@ -115,19 +147,1596 @@ func TestTranscribeStreamBasicFlow(t *testing.T) {
 	}
 }
 
-func TestBufferOverflowProtection(t *testing.T) {
-	var buf bytes.Buffer
-	transcribeStreamChunkSize = 10 // small limit
+func TestTranscribeStreamScriptedBackend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	for i := 0; i < 15; i++ {
-		buf.Write(make([]byte, 100))
+	scripted := pkg_audio.NewScriptedTranscriber("please transfer the money")
+	newTranscriber := func() (pkg_audio.Transcriber, error) {
+		return scripted, nil
 	}
 
-	if buf.Len() > transcribeStreamChunkSize*10 {
-		buf.Reset()
+	var inferenceMu sync.Mutex
+	reqChan := make(chan *pkg_audio.TranscribeRequest, 10)
+	defer close(reqChan)
+	pkg_whisper.WhisperWorkerPool(newTranscriber, reqChan, 2, &inferenceMu, newTestEngine(t, "transfer", "money"))
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: reqChan}
+
+	go func() {
+		stream.recvChan <- &pb.AudioChunk{
+			Payload:   &pb.AudioChunk_Data{Data: make([]byte, 3200)},
+			SessionId: "scripted-session",
+		}
+	}()
+
+	go srv.TranscribeStream(stream)
+
+	select {
+	case fb := <-stream.sendChan:
+		if !fb.Warning {
+			t.Errorf("expected warning feedback, got %q", fb.Text)
+		}
+		if len(fb.DetectedKeywords) != 2 {
+			t.Errorf("expected 2 keywords, got %v", fb.DetectedKeywords)
+		}
+		if fb.Status != pb.TranscriptStatus_TRANSCRIPT_STATUS_WARNING {
+			t.Errorf("expected warning status, got %s", fb.Status)
+		}
+		if fb.RawText != "please transfer the money" {
+			t.Errorf("unexpected raw text %q", fb.RawText)
+		}
+		if fb.NormalizedText != "please transfer the money" {
+			t.Errorf("unexpected normalized text %q", fb.NormalizedText)
+		}
+		if len(fb.KeywordMatches) != 2 || fb.KeywordMatches[0].Start != 7 || fb.KeywordMatches[0].End != 15 {
+			t.Errorf("unexpected keyword matches %v", fb.KeywordMatches)
+		}
+		// 3200 bytes = 100 ms of pcm16
+		if len(fb.Segments) != 1 || fb.Segments[0].StartMs != 0 || fb.Segments[0].EndMs != 100 {
+			t.Errorf("unexpected segments %v", fb.Segments)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received from scripted backend")
+	}
+}
+
+func TestTranscribeStreamVadSegmentation(t *testing.T) {
+	audioSegmentation = "vad"
+	vadConfig = pkg_audio.VadConfig{HangoverMs: 300}
+	defer func() { audioSegmentation = "" }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{
+		reqChan: make(chan *pkg_audio.TranscribeRequest, 10),
 	}
 
-	if buf.Len() != 0 {
-		t.Errorf("buffer should be reset after overflow, got %d bytes", buf.Len())
+	// 1 s of loud square wave followed by 0.5 s of silence
+	speech := make([]int16, 16000)
+	for i := range speech {
+		speech[i] = 8000
+		if (i/20)%2 == 0 {
+			speech[i] = -8000
+		}
+	}
+	silence := make([]int16, 8000)
+
+	go func() {
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: pkg.Int16SliceToBytes(speech)}, SessionId: "vad-session"}
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: pkg.Int16SliceToBytes(silence)}}
+	}()
+
+	go srv.TranscribeStream(stream)
+
+	select {
+	case req := <-srv.reqChan:
+		if req.SessionID != "vad-session" {
+			t.Errorf("unexpected session id %q", req.SessionID)
+		}
+		// 1 s speech + 300 ms hangover
+		if ms := len(req.Audio) / 32; ms < 1250 || ms > 1350 {
+			t.Errorf("unexpected utterance length %d ms", ms)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("vad did not enqueue the utterance")
+	}
+
+	select {
+	case req := <-srv.reqChan:
+		t.Errorf("unexpected extra request of %d bytes", len(req.Audio))
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestTranscribeStreamSlidingWindow(t *testing.T) {
+	audioSegmentation = "sliding"
+	slidingOverlapMs = 50
+	keywordEngine = newTestEngine(t, "transfer the money")
+	defer func() {
+		audioSegmentation = ""
+		keywordEngine = nil
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scripted := pkg_audio.NewScriptedTranscriber("please transfer", "transfer the money now")
+	newTranscriber := func() (pkg_audio.Transcriber, error) {
+		return scripted, nil
+	}
+
+	var inferenceMu sync.Mutex
+	reqChan := make(chan *pkg_audio.TranscribeRequest, 10)
+	defer close(reqChan)
+	pkg_whisper.WhisperWorkerPool(newTranscriber, reqChan, 1, &inferenceMu, nil)
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: reqChan}
+
+	go srv.TranscribeStream(stream)
+
+	// 200 ms per window, 100 ms ticker
+	go func() {
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 6400)}, SessionId: "sliding-session"}
+		time.Sleep(150 * time.Millisecond)
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 6400)}}
+	}()
+
+	var fbs []*pb.Transcript
+	for len(fbs) < 2 {
+		select {
+		case fb := <-stream.sendChan:
+			fbs = append(fbs, fb)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected 2 feedbacks, got %d", len(fbs))
+		}
+	}
+
+	if fbs[0].Warning || fbs[0].Text != "ok: 'please transfer'" {
+		t.Errorf("unexpected first feedback: %q", fbs[0].Text)
+	}
+	if !fbs[1].Warning || len(fbs[1].DetectedKeywords) != 1 {
+		t.Errorf("expected boundary phrase warning, got %q", fbs[1].Text)
+	}
+	if fbs[1].Text != "detected forbidden keyword: [transfer the money] - 'the money now'" {
+		t.Errorf("expected de-duplicated text, got %q", fbs[1].Text)
+	}
+}
+
+func TestTranscribeStreamCrossChunkPhrase(t *testing.T) {
+	keywordEngine = newTestEngine(t, "wire the money")
+	defer func() { keywordEngine = nil }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the first chunk finishes last, results must still be delivered in order
+	script := []struct {
+		text  string
+		delay time.Duration
+	}{
+		{"please wire the", 150 * time.Millisecond},
+		{"money now", 0},
+		{"thanks", 0},
+	}
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+	go func() {
+		i := 0
+		for req := range srv.reqChan {
+			line := script[min(i, len(script)-1)]
+			i++
+			go func() {
+				time.Sleep(line.delay)
+				req.Resp <- &pkg_audio.TranscribeResult{Text: line.text}
+			}()
+		}
+	}()
+	defer close(srv.reqChan)
+
+	stream := newMockStream(ctx)
+	go srv.TranscribeStream(stream)
+	go func() {
+		for range script {
+			stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}, SessionId: "tail-session"}
+			time.Sleep(150 * time.Millisecond)
+		}
+	}()
+
+	var fbs []*pb.Transcript
+	for len(fbs) < len(script) {
+		select {
+		case fb := <-stream.sendChan:
+			fbs = append(fbs, fb)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %d feedbacks, got %d", len(script), len(fbs))
+		}
+	}
+
+	if fbs[0].RawText != "please wire the" || fbs[0].Warning {
+		t.Errorf("unexpected first feedback %q", fbs[0].Text)
+	}
+	if fbs[1].RawText != "money now" || !fbs[1].Warning || len(fbs[1].KeywordMatches) != 1 {
+		t.Fatalf("expected the split phrase in the second feedback, got %q", fbs[1].Text)
+	}
+	if match := fbs[1].KeywordMatches[0]; match.Keyword != "wire the money" || match.Start != 0 || match.End != 5 {
+		t.Errorf("unexpected crossing match %v", match)
+	}
+	// reported once, the phrase is still in the tail
+	if fbs[2].Warning {
+		t.Errorf("phrase reported again: %q", fbs[2].Text)
+	}
+}
+
+func TestTranscribeStreamRollingPrompt(t *testing.T) {
+	whisperPrompt.Store(&promptConfig{words: defaultPromptWords, vocabulary: []string{"Kubernetes"}})
+	defer whisperPrompt.Store(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// one fake worker for both sessions, it answers with the session & chunk number
+	type call struct{ session, prompt string }
+	calls := make(chan call, 10)
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+	go func() {
+		count := map[string]int{}
+		for req := range srv.reqChan {
+			count[req.SessionID]++
+			calls <- call{req.SessionID, req.Options.Prompt}
+			req.Resp <- &pkg_audio.TranscribeResult{Text: fmt.Sprintf("%s said %d", req.SessionID, count[req.SessionID])}
+		}
+	}()
+	defer close(srv.reqChan)
+
+	alice, bob := newMockStream(ctx), newMockStream(ctx)
+	alice.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{Vocabulary: []string{"Ana", "kubernetes"}}}}
+	bob.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{}}}
+	go srv.TranscribeStream(alice)
+	go srv.TranscribeStream(bob)
+	<-alice.sendChan // config acks
+	<-bob.sendChan
+
+	send := func(stream *mockStream, session string) call {
+		t.Helper()
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}, SessionId: session}
+		select {
+		case c := <-calls:
+			<-stream.sendChan // wait for the result to be delivered
+			return c
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no request from %s", session)
+		}
+		return call{}
+	}
+
+	if c := send(alice, "alice"); c.prompt != "Kubernetes, Ana." {
+		t.Errorf("first prompt %q", c.prompt)
+	}
+	if c := send(bob, "bob"); c.prompt != "Kubernetes." {
+		t.Errorf("bob must not get alice's prompt, got %q", c.prompt)
+	}
+	if c := send(alice, "alice"); c.prompt != "Kubernetes, Ana. alice said 1" {
+		t.Errorf("second prompt %q", c.prompt)
+	}
+	if c := send(bob, "bob"); c.prompt != "Kubernetes. bob said 1" {
+		t.Errorf("bob prompt %q", c.prompt)
+	}
+}
+
+func TestTranscribeStreamConfigAccepted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{
+		reqChan: make(chan *pkg_audio.TranscribeRequest, 10),
+	}
+
+	go func() {
+		stream.recvChan <- &pb.AudioChunk{
+			Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{
+				SampleRate: 16000,
+				Channels:   1,
+				Encoding:   pb.AudioEncoding_AUDIO_ENCODING_PCM16,
+				Language:   "id",
+				Metadata:   map[string]string{"app": "test"},
+			}},
+			SessionId: "config-session",
+		}
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	}()
+
+	go srv.TranscribeStream(stream)
+
+	select {
+	case fb := <-stream.sendChan:
+		if fb.ConfigAck == nil || !fb.ConfigAck.Accepted {
+			t.Fatalf("expected accepted config ack, got %v", fb)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no config ack received")
+	}
+
+	select {
+	case req := <-srv.reqChan:
+		if req.SessionID != "config-session" {
+			t.Errorf("unexpected session id %q", req.SessionID)
+		}
+		if req.Options.Language != "id" {
+			t.Errorf("expected language hint to reach the worker, got %q", req.Options.Language)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("audio was not enqueued after handshake")
+	}
+}
+
+// expectConverted collects the requests of a converted stream until about want bytes of 16 kHz mono pcm16
+// the resampler delay may hold a few samples back, every request must keep the level of a constant signal
+func expectConverted(t *testing.T, srv *server, want int, level float64) {
+	t.Helper()
+	total := 0
+	for total < want-200 {
+		select {
+		case req := <-srv.reqChan:
+			if len(req.Audio)%2 != 0 {
+				t.Fatalf("odd pcm16 length %d", len(req.Audio))
+			}
+			samples, _ := pkg.BytesToFloat32(req.Audio)
+			if mid := samples[len(samples)/2]; math.Abs(float64(mid)-level) > 0.01 {
+				t.Errorf("level %f not kept, got %f", level, mid)
+			}
+			total += len(req.Audio)
+			req.Resp <- &pkg_audio.TranscribeResult{}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %d bytes, want about %d", total, want)
+		}
+	}
+	if total > want {
+		t.Errorf("got %d bytes, want at most %d", total, want)
+	}
+}
+
+// sendSplit streams the config, then the audio in chunks that split frames
+func sendSplit(stream *mockStream, cfg *pb.StreamConfig, audio []byte) {
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: cfg}}
+	for i := 0; i < len(audio); i += 1001 {
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: audio[i:min(i+1001, len(audio))]}}
+	}
+}
+
+func TestTranscribeStreamResamplesDeclaredFormat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+	// 0.2s of 48 kHz stereo
+	audio := make([]byte, 48000/5*2*2)
+	for i := 0; i < len(audio); i += 2 {
+		binary.LittleEndian.PutUint16(audio[i:], uint16(int16(8000)))
+	}
+	go sendSplit(stream, &pb.StreamConfig{SampleRate: 48000, Channels: 2}, audio)
+	go srv.TranscribeStream(stream)
+
+	if fb := <-stream.sendChan; fb.ConfigAck == nil || !fb.ConfigAck.Accepted {
+		t.Fatalf("expected accepted config ack, got %v", fb)
+	}
+	expectConverted(t, srv, 16000/5*2, 8000.0/32768)
+}
+
+func TestTranscribeStreamDecodesDeclaredEncoding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+	// 0.2s of 8 kHz telephony audio
+	code := pkg.MulawEncode(8000)
+	audio := bytes.Repeat([]byte{code}, 8000/5)
+	go sendSplit(stream, &pb.StreamConfig{SampleRate: 8000, Encoding: pb.AudioEncoding_AUDIO_ENCODING_MULAW}, audio)
+	go srv.TranscribeStream(stream)
+
+	if fb := <-stream.sendChan; fb.ConfigAck == nil || !fb.ConfigAck.Accepted {
+		t.Fatalf("expected accepted config ack, got %v", fb)
+	}
+	expectConverted(t, srv, 16000/5*2, float64(pkg.MulawDecode(code))/32768)
+}
+
+// flacConstant encodes 48 kHz mono 16 bit flac of constant subframes, frames of 2400 samples
+func flacConstant(level int16, frames int) []byte {
+	crc := func(data []byte, poly uint16, width uint) uint16 {
+		var c uint16
+		for _, b := range data {
+			c ^= uint16(b) << (width - 8)
+			for range 8 {
+				if c&(1<<(width-1)) != 0 {
+					c = c<<1 ^ poly
+				} else {
+					c <<= 1
+				}
+			}
+		}
+		return c & (1<<width - 1)
+	}
+	const blockSize = 2400
+
+	raw := make([]byte, 0, frames*blockSize*2)
+	for range frames * blockSize {
+		raw = binary.LittleEndian.AppendUint16(raw, uint16(level))
+	}
+	sum := md5.Sum(raw)
+
+	out := []byte("fLaC")
+	out = append(out, 0x80, 0, 0, 34) // last block, streaminfo
+	out = binary.BigEndian.AppendUint16(out, blockSize)
+	out = binary.BigEndian.AppendUint16(out, blockSize)
+	out = append(out, 0, 0, 0, 0, 0, 0)
+	// 20 bits rate, 3 bits channels-1, 5 bits bps-1, 36 bits total samples
+	out = binary.BigEndian.AppendUint64(out, 48000<<44|15<<36|uint64(frames*blockSize))
+	out = append(out, sum[:]...)
+
+	for i := range frames {
+		// fixed blocking, 16 bit block size at the end, 48 kHz, mono, 16 bit, frame number
+		frame := []byte{0xFF, 0xF8, 0x7A, 0x08, byte(i)}
+		frame = binary.BigEndian.AppendUint16(frame, blockSize-1)
+		frame = append(frame, byte(crc(frame, 0x07, 8)))
+		frame = append(frame, 0x00) // constant subframe
+		frame = binary.BigEndian.AppendUint16(frame, uint16(level))
+		frame = binary.BigEndian.AppendUint16(frame, crc(frame, 0x8005, 16))
+		out = append(out, frame...)
+	}
+	return out
+}
+
+func TestTranscribeStreamDecodesFlac(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+	// 0.2s, the stream info declares the format, not the config
+	audio := flacConstant(8000, 4)
+	cfg := &pb.StreamConfig{Encoding: pb.AudioEncoding_AUDIO_ENCODING_FLAC}
+	go func() {
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: cfg}}
+		for i := 0; i < len(audio); i += 7 {
+			stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: audio[i:min(i+7, len(audio))]}}
+		}
+	}()
+	go srv.TranscribeStream(stream)
+
+	if fb := <-stream.sendChan; fb.ConfigAck == nil || !fb.ConfigAck.Accepted {
+		t.Fatalf("expected accepted config ack, got %v", fb)
+	}
+	expectConverted(t, srv, 16000/5*2, 8000.0/32768)
+}
+
+func TestTranscribeStreamRejectsUnsupportedFlac(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+	audio := append([]byte("fLaC\x00\x00\x00\x22"), make([]byte, 34)...)
+	go sendSplit(stream, &pb.StreamConfig{Encoding: pb.AudioEncoding_AUDIO_ENCODING_FLAC}, audio)
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- srv.TranscribeStream(stream) }()
+
+	select {
+	case err := <-errChan:
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected InvalidArgument, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream not terminated")
+	}
+}
+
+func TestStreamConfigEncodings(t *testing.T) {
+	for encoding := range pb.AudioEncoding_name {
+		sessCfg, err := streamConfigApply(&pb.StreamConfig{Encoding: pb.AudioEncoding(encoding)})
+		if err != nil {
+			t.Errorf("%s rejected: %v", pb.AudioEncoding(encoding), err)
+			continue
+		}
+		if sessCfg.decoder.Sample == nil {
+			t.Errorf("%s has no decoder", pb.AudioEncoding(encoding))
+		}
+		if encoding != 0 && sessCfg.encoding != pb.AudioEncoding(encoding) {
+			t.Errorf("%s kept as %s", pb.AudioEncoding(encoding), sessCfg.encoding)
+		}
+	}
+	if _, err := streamConfigApply(&pb.StreamConfig{Encoding: pb.AudioEncoding(99)}); err == nil {
+		t.Error("expected unknown encoding to be rejected")
+	}
+}
+
+func TestStreamConfigLanguage(t *testing.T) {
+	for _, lang := range []string{"en", "id", "haw", "yue", "auto"} {
+		sessCfg, err := streamConfigApply(&pb.StreamConfig{Language: lang})
+		if err != nil {
+			t.Errorf("language %q rejected: %v", lang, err)
+			continue
+		}
+		if sessCfg.options.Language != lang {
+			t.Errorf("language %q kept as %q", lang, sessCfg.options.Language)
+		}
+	}
+	for _, lang := range []string{"e", "EN", "engl", "en-US", "zh_", "1a"} {
+		if _, err := streamConfigApply(&pb.StreamConfig{Language: lang}); err == nil {
+			t.Errorf("expected language %q to be rejected", lang)
+		}
+	}
+}
+
+func TestTranscribeStreamConfigRejected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{
+		reqChan: make(chan *pkg_audio.TranscribeRequest, 10),
+	}
+
+	stream.recvChan <- &pb.AudioChunk{
+		Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{SampleRate: 4000}},
+	}
+
+	err := srv.TranscribeStream(stream)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument, got %v", err)
+	}
+
+	select {
+	case fb := <-stream.sendChan:
+		if fb.ConfigAck == nil || fb.ConfigAck.Accepted || fb.ConfigAck.Reason == "" {
+			t.Errorf("expected rejected config ack with reason, got %v", fb)
+		}
+	default:
+		t.Error("no config ack received")
+	}
+}
+
+// expectStreamError waits for an error feedback with the given code
+func expectStreamError(t *testing.T, stream *mockStream, code pb.StreamErrorCode) *pb.StreamError {
+	t.Helper()
+	select {
+	case fb := <-stream.sendChan:
+		if fb.Status != pb.TranscriptStatus_TRANSCRIPT_STATUS_ERROR || fb.Error == nil {
+			t.Fatalf("expected error feedback, got %v", fb)
+		}
+		if fb.Error.Code != code {
+			t.Fatalf("expected %s, got %s (%s)", code, fb.Error.Code, fb.Error.Message)
+		}
+		if fb.Error.ChunkId == 0 || fb.ChunkId != fb.Error.ChunkId {
+			t.Errorf("expected chunk id on error, got %d / %d", fb.ChunkId, fb.Error.ChunkId)
+		}
+		return fb.Error
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no %s feedback received", code)
+	}
+	return nil
+}
+
+func TestTranscribeStreamReportsWorkersBusy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	// unbuffered & nobody listening: every chunk is dropped
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest)}
+
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	streamErr := expectStreamError(t, stream, pb.StreamErrorCode_STREAM_ERROR_CODE_WORKERS_BUSY)
+	// 3200 bytes = 100 ms of pcm16
+	if streamErr.StartMs != 0 || streamErr.EndMs != 100 {
+		t.Errorf("unexpected lost audio range %d-%d ms", streamErr.StartMs, streamErr.EndMs)
+	}
+}
+
+func TestTranscribeStreamReportsWorkerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code pb.StreamErrorCode
+	}{
+		{"conversion", fmt.Errorf("%w: odd length", pkg_audio.ErrAudioConversion), pb.StreamErrorCode_STREAM_ERROR_CODE_AUDIO_CONVERSION},
+		{"transcription", fmt.Errorf("%w: process failed", pkg_audio.ErrTranscribe), pb.StreamErrorCode_STREAM_ERROR_CODE_TRANSCRIPTION},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream := newMockStream(ctx)
+			srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+			go func() {
+				for req := range srv.reqChan {
+					req.Resp <- &pkg_audio.TranscribeResult{Err: tt.err}
+				}
+			}()
+			defer close(srv.reqChan)
+
+			stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+			go srv.TranscribeStream(stream)
+
+			streamErr := expectStreamError(t, stream, tt.code)
+			if streamErr.Message != tt.err.Error() {
+				t.Errorf("unexpected error message %q", streamErr.Message)
+			}
+		})
+	}
+}
+
+func TestTranscribeStreamReportsTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { transcriptionTimeout = timeout }(transcriptionTimeout)
+	transcriptionTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	// queued but never answered
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	expectStreamError(t, stream, pb.StreamErrorCode_STREAM_ERROR_CODE_TIMEOUT)
+}
+
+func TestTranscribeStreamConversionErrorFromPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newTranscriber := func() (pkg_audio.Transcriber, error) {
+		return pkg_audio.NewScriptedTranscriber("never reached"), nil
+	}
+
+	var inferenceMu sync.Mutex
+	reqChan := make(chan *pkg_audio.TranscribeRequest, 10)
+	defer close(reqChan)
+	pkg_whisper.WhisperWorkerPool(newTranscriber, reqChan, 1, &inferenceMu, nil)
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: reqChan}
+
+	// odd length, not valid pcm16
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3201)}}
+	go srv.TranscribeStream(stream)
+
+	expectStreamError(t, stream, pb.StreamErrorCode_STREAM_ERROR_CODE_AUDIO_CONVERSION)
+}
+
+func TestTranscribeStreamLanguageKeywords(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := pkg_keyword.NewEngine(pkg_keyword.Lists{
+		Forbidden: map[string][]pkg_keyword.Keyword{
+			"en": pkg_keyword.Words("transfer the money"),
+			"id": pkg_keyword.Words("kirim uang"),
+		},
+		DefaultLanguage: "en",
+	})
+	if err != nil {
+		t.Fatalf("failed to compile keywords: %v", err)
+	}
+
+	scripted := pkg_audio.NewScriptedTranscriber("[id] tolong kirim uang sekarang")
+	newTranscriber := func() (pkg_audio.Transcriber, error) {
+		return scripted, nil
+	}
+
+	var inferenceMu sync.Mutex
+	reqChan := make(chan *pkg_audio.TranscribeRequest, 10)
+	defer close(reqChan)
+	pkg_whisper.WhisperWorkerPool(newTranscriber, reqChan, 1, &inferenceMu, engine)
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: reqChan}
+
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	select {
+	case fb := <-stream.sendChan:
+		if fb.Language != "id" {
+			t.Errorf("expected detected language id, got %q", fb.Language)
+		}
+		if !fb.Warning || len(fb.DetectedKeywords) != 1 || fb.DetectedKeywords[0] != "kirim uang" {
+			t.Errorf("expected indonesian keyword, got %v", fb.DetectedKeywords)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+}
+
+func TestTranscribeStreamReportsSuppressedMatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := pkg_keyword.NewEngine(pkg_keyword.Lists{
+		Forbidden:       map[string][]pkg_keyword.Keyword{"en": pkg_keyword.Words("train")},
+		Exceptions:      map[string]pkg_keyword.Exceptions{"en": {Allow: []string{"train station"}}},
+		DefaultLanguage: "en",
+	})
+	if err != nil {
+		t.Fatalf("failed to compile keywords: %v", err)
+	}
+
+	scripted := pkg_audio.NewScriptedTranscriber("meet me at the train station")
+	newTranscriber := func() (pkg_audio.Transcriber, error) {
+		return scripted, nil
+	}
+
+	var inferenceMu sync.Mutex
+	reqChan := make(chan *pkg_audio.TranscribeRequest, 10)
+	defer close(reqChan)
+	pkg_whisper.WhisperWorkerPool(newTranscriber, reqChan, 1, &inferenceMu, engine)
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: reqChan}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	select {
+	case fb := <-stream.sendChan:
+		if fb.Warning || fb.Status != pb.TranscriptStatus_TRANSCRIPT_STATUS_OK || len(fb.KeywordMatches) != 0 {
+			t.Errorf("allowed phrase must not warn, got %q", fb.Text)
+		}
+		if len(fb.SuppressedMatches) != 1 || fb.SuppressedMatches[0].Keyword != "train" || fb.SuppressedMatches[0].SuppressedBy == "" {
+			t.Errorf("expected informational suppressed match, got %v", fb.SuppressedMatches)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+}
+
+// policyServer answers every request with one scam match on "wire the money"
+func policyServer(t *testing.T, policy pkg_keyword.Policy) *server {
+	t.Helper()
+	prev := keywordPolicies.Load()
+	keywordPolicies.Store(&map[string]pkg_keyword.Policy{"strict": policy})
+	t.Cleanup(func() { keywordPolicies.Store(prev) })
+
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+	go func() {
+		for req := range srv.reqChan {
+			req.Resp <- &pkg_audio.TranscribeResult{
+				Text:     "please wire the money",
+				Warning:  true,
+				Keywords: []string{"wire the money"},
+				Matches: []pkg_keyword.Match{{
+					Keyword: "wire the money", Text: "wire the money", Start: 7, End: 21, Score: 1,
+					Category: "scam", Severity: pkg_keyword.SeverityHigh,
+				}},
+			}
+		}
+	}()
+	t.Cleanup(func() { close(srv.reqChan) })
+	return srv
+}
+
+func TestTranscribeStreamPolicyMask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := policyServer(t, pkg_keyword.Policy{
+		Rules: []pkg_keyword.PolicyRule{{Category: "scam", Action: pkg_keyword.ActionMask}},
+	})
+	stream := newMockStream(ctx)
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{KeywordPolicyId: "strict"}}}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	if ack := (<-stream.sendChan).ConfigAck; ack == nil || !ack.Accepted {
+		t.Fatalf("expected accepted config, got %v", ack)
+	}
+
+	select {
+	case fb := <-stream.sendChan:
+		if fb.Action != pb.KeywordAction_KEYWORD_ACTION_MASK {
+			t.Errorf("expected mask action, got %s", fb.Action)
+		}
+		if fb.RawText != "please **** *** *****" {
+			t.Errorf("expected masked raw text, got %q", fb.RawText)
+		}
+		if len(fb.KeywordMatches) != 1 || fb.KeywordMatches[0].Category != "scam" ||
+			fb.KeywordMatches[0].Severity != pb.KeywordSeverity_KEYWORD_SEVERITY_HIGH || fb.KeywordMatches[0].Text != "**** *** *****" {
+			t.Errorf("unexpected matches %v", fb.KeywordMatches)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+}
+
+func TestMaskedKeywordsNeverSent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the match is masked & terminates, every way the transcript leaves the server is checked
+	srv := policyServer(t, pkg_keyword.Policy{
+		Rules: []pkg_keyword.PolicyRule{{Category: "scam", Action: pkg_keyword.ActionTerminate}},
+	})
+	leaks := func(where, sent string) {
+		t.Helper()
+		for _, word := range []string{"wire", "money"} {
+			if strings.Contains(sent, word) {
+				t.Errorf("%s reveals the masked word %q: %s", where, word, sent)
+			}
+		}
+	}
+
+	stream := newMockStream(ctx)
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{KeywordPolicyId: "strict"}}}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	errChan := make(chan error, 1)
+	go func() { errChan <- srv.TranscribeStream(stream) }()
+	<-stream.sendChan // config ack
+
+	select {
+	case fb := <-stream.sendChan:
+		if len(fb.DetectedKeywords) != 1 || len(fb.KeywordMatches) != 1 {
+			t.Fatalf("expected the masked detection, got %v", fb)
+		}
+		leaks("stream transcript", fb.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+	select {
+	case err := <-errChan:
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected PermissionDenied, got %v", err)
+		}
+		leaks("termination status", err.Error())
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream not terminated")
+	}
+
+	resp, err := srv.Transcribe(ctx, &pb.TranscribeRequest{Audio: make([]byte, 3200), Config: &pb.StreamConfig{KeywordPolicyId: "strict"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.DetectedKeywords) != 1 {
+		t.Fatalf("expected the masked detection, got %v", resp)
+	}
+	leaks("file response", resp.String())
+}
+
+func TestTranscribeStreamMetadataMask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the policy only warns, the session asks for category tags
+	srv := policyServer(t, pkg_keyword.Policy{DefaultAction: pkg_keyword.ActionWarn})
+	stream := newMockStream(ctx)
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{
+		KeywordPolicyId: "strict",
+		Metadata:        map[string]string{"mask": "category"},
+	}}}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	if ack := (<-stream.sendChan).ConfigAck; ack == nil || !ack.Accepted {
+		t.Fatalf("expected accepted config, got %v", ack)
+	}
+
+	select {
+	case fb := <-stream.sendChan:
+		if fb.Action != pb.KeywordAction_KEYWORD_ACTION_WARN {
+			t.Errorf("expected warn action, got %s", fb.Action)
+		}
+		if fb.RawText != "please [scam]" || fb.Text != "detected forbidden keyword: [[scam]] - 'please [scam]'" {
+			t.Errorf("expected category masked text, got %q / %q", fb.RawText, fb.Text)
+		}
+		if len(fb.KeywordMatches) != 1 || fb.KeywordMatches[0].Text != "[scam]" ||
+			fb.KeywordMatches[0].Start != 7 || fb.KeywordMatches[0].End != 13 {
+			t.Errorf("matches must point into the masked text, got %v", fb.KeywordMatches)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+}
+
+func TestTranscribeStreamMasksPII(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	prev := piiConfig.Load()
+	piiConfig.Store(&pkg_pii.Config{Enabled: true, Mask: true})
+	t.Cleanup(func() { piiConfig.Store(prev) })
+
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+	go func() {
+		for req := range srv.reqChan {
+			req.Resp <- &pkg_audio.TranscribeResult{Text: "my card is four one one one 1111 1111 1111 ok"}
+		}
+	}()
+	t.Cleanup(func() { close(srv.reqChan) })
+
+	stream := newMockStream(ctx)
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{
+		Metadata: map[string]string{"mask": "category"},
+	}}}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+	go srv.TranscribeStream(stream)
+
+	<-stream.sendChan // config ack
+	select {
+	case fb := <-stream.sendChan:
+		if fb.RawText != "my card is [card] ok" {
+			t.Errorf("expected masked card, got %q", fb.RawText)
+		}
+		if len(fb.PiiMatches) != 1 || fb.PiiMatches[0].Type != pb.PiiType_PII_TYPE_CARD || fb.PiiMatches[0].Text != "[card]" ||
+			fb.PiiMatches[0].Start != 11 || !fb.PiiMatches[0].Masked {
+			t.Errorf("unexpected pii matches %v", fb.PiiMatches)
+		}
+		if fb.Warning || len(fb.KeywordMatches) != 0 {
+			t.Errorf("pii is not a keyword warning, got %q", fb.Text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+}
+
+func TestStreamConfigMaskMetadata(t *testing.T) {
+	valid := []map[string]string{
+		{"mask": "asterisks"},
+		{"mask": "replacement", "mask_replacement": "[beep]"},
+		{"app": "test"},
+	}
+	for _, metadata := range valid {
+		if _, err := streamConfigApply(&pb.StreamConfig{Metadata: metadata}); err != nil {
+			t.Errorf("metadata %v: unexpected error %v", metadata, err)
+		}
+	}
+
+	invalid := []map[string]string{
+		{"mask": "blur"},
+		{"mask": "replacement"},
+	}
+	for _, metadata := range invalid {
+		if _, err := streamConfigApply(&pb.StreamConfig{Metadata: metadata}); err == nil {
+			t.Errorf("metadata %v: expected error", metadata)
+		}
+	}
+}
+
+func TestTranscribeStreamPolicyTerminate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := policyServer(t, pkg_keyword.Policy{
+		Rules: []pkg_keyword.PolicyRule{{MinSeverity: pkg_keyword.SeverityHigh, Action: pkg_keyword.ActionTerminate}},
+	})
+	stream := newMockStream(ctx)
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Config{Config: &pb.StreamConfig{KeywordPolicyId: "strict"}}}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- srv.TranscribeStream(stream) }()
+
+	<-stream.sendChan // config ack
+	select {
+	case fb := <-stream.sendChan:
+		if fb.Action != pb.KeywordAction_KEYWORD_ACTION_TERMINATE {
+			t.Errorf("expected terminate action, got %s", fb.Action)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no feedback received")
+	}
+
+	select {
+	case err := <-errChan:
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("expected PermissionDenied, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream not terminated")
+	}
+}
+
+func TestTranscribeStreamStrikeEscalation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := policyServer(t, pkg_keyword.Policy{
+		Strikes: pkg_keyword.StrikePolicy{
+			WindowSec: 60,
+			Levels: []pkg_keyword.StrikeLevel{
+				{Name: "final_warning", Strikes: 2},
+				{Name: "end", Strikes: 3, Terminate: true, StatusCode: "RESOURCE_EXHAUSTED"},
+			},
+		},
+	})
+	stream := newMockStream(ctx)
+	stream.recvChan <- &pb.AudioChunk{
+		SessionId: "strike-session",
+		Payload:   &pb.AudioChunk_Config{Config: &pb.StreamConfig{KeywordPolicyId: "strict"}},
+	}
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- srv.TranscribeStream(stream) }()
+	<-stream.sendChan // config ack
+
+	// one transcript per chunk, chunks are further apart than the processing ticker
+	next := func() *pb.Transcript {
+		t.Helper()
+		stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 3200)}}
+		select {
+		case fb := <-stream.sendChan:
+			return fb
+		case <-time.After(2 * time.Second):
+			t.Fatal("no feedback received")
+			return nil
+		}
+	}
+
+	if fb := next(); fb.Escalation != nil {
+		t.Errorf("unexpected escalation on first strike: %v", fb.Escalation)
+	}
+
+	fb := next()
+	if fb.Escalation == nil || fb.Escalation.Level != "final_warning" || fb.Escalation.Strikes != 2 || fb.Escalation.Terminate {
+		t.Fatalf("expected final_warning escalation, got %v", fb.Escalation)
+	}
+
+	state, err := srv.GetStrikeState(ctx, &pb.StrikeStateRequest{SessionId: "strike-session"})
+	if err != nil {
+		t.Fatalf("GetStrikeState() error = %v", err)
+	}
+	if state.Strikes != 2 || state.TotalStrikes != 2 || state.Level != "final_warning" || state.WindowMs != 60000 {
+		t.Errorf("unexpected strike state %v", state)
+	}
+
+	fb = next()
+	if fb.Escalation == nil || !fb.Escalation.Terminate || codes.Code(fb.Escalation.StatusCode) != codes.ResourceExhausted {
+		t.Fatalf("expected terminating escalation, got %v", fb.Escalation)
+	}
+
+	select {
+	case err := <-errChan:
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("expected ResourceExhausted, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream not terminated")
+	}
+
+	if _, err := srv.GetStrikeState(ctx, &pb.StrikeStateRequest{SessionId: "strike-session"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound after the stream ended, got %v", err)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	prevEngine, prevPolicies, prevPrompt := keywordEngine, keywordPolicies.Load(), whisperPrompt.Load()
+	defer func() {
+		keywordEngine = prevEngine
+		keywordPolicies.Store(prevPolicies)
+		whisperPrompt.Store(prevPrompt)
+	}()
+	keywordEngine = nil
+
+	path := t.TempDir() + "/config.audio.json"
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	matches := func(text string) int {
+		matcher, _ := keywordEngine.Matcher("en")
+		return len(matcher.Match(text))
+	}
+
+	write(`{"keywords": {"forbidden": {"en": ["train"]}}}`)
+	audioCfg, err := pkg_audio.AudioConfigLoad(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keywordConfigApply(audioCfg); err != nil {
+		t.Fatalf("keywordConfigApply() error = %v", err)
+	}
+	reloader, err := newConfigReloader(path, audioCfg, pkg_audio.AudioConfigLoad, audioConfigApply, audioConfigLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := keywordEngine.Version()
+
+	// invalid policy: rejected, previous config kept
+	write(`{"keywords": {"forbidden": {"en": ["money"]}, "policies": {"default": {"default_action": "ban"}}}}`)
+	if err := reloader.reload("test"); err == nil {
+		t.Error("expected invalid config to fail")
+	}
+	if keywordEngine.Version() != version || matches("take the train") != 1 || matches("money") != 0 {
+		t.Error("failed reload must keep the previous keywords")
+	}
+
+	// valid change picked up by the file watcher
+	ctx, cancel := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	go func() {
+		reloader.watch(ctx, 10*time.Millisecond)
+		close(watchDone)
+	}()
+	// stop the watcher before the globals are restored
+	defer func() {
+		cancel()
+		<-watchDone
+	}()
+	write(`{"keywords": {"forbidden": {"en": ["money"]}, "policies": {"strict": {"default_action": "mask"}}}}`)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for keywordEngine.Version() == version && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if keywordEngine.Version() != version+1 {
+		t.Fatalf("expected version %d after reload, got %d", version+1, keywordEngine.Version())
+	}
+	if matches("take the train") != 0 || matches("money") != 1 {
+		t.Error("reload must swap the keyword lists")
+	}
+	if _, ok := policiesLoad()["strict"]; !ok {
+		t.Error("reload must swap the policies")
+	}
+}
+
+func TestConfigReloadRestartKeys(t *testing.T) {
+	prevEngine, prevPolicies, prevPrompt := keywordEngine, keywordPolicies.Load(), whisperPrompt.Load()
+	defer func() {
+		keywordEngine = prevEngine
+		keywordPolicies.Store(prevPolicies)
+		whisperPrompt.Store(prevPrompt)
+	}()
+	keywordEngine = nil
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// whisper prompt settings are reloaded, the model & the backend are named
+	audioPath := dir + "/config.audio.json"
+	write(audioPath, `{"whisper": {"model": "base.bin", "vocabulary": ["Ana"], "prompt_words": 8}, "processing": {"sending_ticker": 100}}`)
+	audioCfg, err := pkg_audio.AudioConfigLoad(audioPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := audioConfigApply(audioCfg); err != nil {
+		t.Fatal(err)
+	}
+	audioReloader, err := newConfigReloader(audioPath, audioCfg, pkg_audio.AudioConfigLoad, audioConfigApply, audioConfigLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(audioPath, `{"whisper": {"model": "small.bin", "vocabulary": ["Bob"], "prompt_words": -1}, `+
+		`"transcriber": {"backend": "scripted"}, "processing": {"sending_ticker": 200}}`)
+	if err := audioReloader.reload("test"); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := promptLoad(); prompt.words != 0 || len(prompt.vocabulary) != 1 || prompt.vocabulary[0] != "Bob" {
+		t.Errorf("reload must swap the whisper prompt, got %+v", prompt)
+	}
+	if !strings.Contains(logs.String(), "transcriber.backend, whisper.model changed, needs a restart") {
+		t.Errorf("expected the ignored audio keys in the log, got %q", logs.String())
+	}
+
+	// every server setting needs a restart
+	logs.Reset()
+	grpcPath := dir + "/config.grpc.json"
+	write(grpcPath, `{"processing": {"segmentation": "vad", "transcript_tail_words": 16, "vad": {"energy_threshold": 0.01}}}`)
+	grpcCfg, err := pkg_grpc.GrpcConfigLoad(grpcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcReloader, err := newConfigReloader(grpcPath, grpcCfg, pkg_grpc.GrpcConfigLoad, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(grpcPath, `{"processing": {"segmentation": "vad", "transcript_tail_words": 8, "vad": {"energy_threshold": 0.02}}}`)
+	if err := grpcReloader.reload("test"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "processing.transcript_tail_words, processing.vad.energy_threshold changed, needs a restart") {
+		t.Errorf("expected the ignored grpc keys in the log, got %q", logs.String())
+	}
+}
+
+func TestBufferOverflowProtection(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(size int) { transcribeStreamChunkSize = size }(transcribeStreamChunkSize)
+	transcribeStreamChunkSize = 10 // small limit
+
+	for i := 0; i < 15; i++ {
+		buf.Write(make([]byte, 100))
+	}
+
+	if buf.Len() > transcribeStreamChunkSize*10 {
+		buf.Reset()
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("buffer should be reset after overflow, got %d bytes", buf.Len())
+	}
+}
+
+// scriptedFileServer answers the chunks of a file in order with the texts, "money" is a keyword
+func scriptedFileServer(t *testing.T, texts ...string) *server {
+	t.Helper()
+	prev := fileChunkDuration
+	fileChunkDuration = time.Second
+	t.Cleanup(func() { fileChunkDuration = prev })
+
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+	go func() {
+		i := 0
+		for req := range srv.reqChan {
+			res := &pkg_audio.TranscribeResult{Text: texts[i%len(texts)]}
+			if start := strings.Index(res.Text, "money"); start >= 0 {
+				res.Warning, res.Keywords = true, []string{"money"}
+				res.Matches = []pkg_keyword.Match{{
+					Keyword: "money", Text: "money", Start: start, End: start + 5, Score: 1, Severity: pkg_keyword.SeverityHigh,
+				}}
+			}
+			i++
+			req.Resp <- res
+		}
+	}()
+	t.Cleanup(func() { close(srv.reqChan) })
+	return srv
+}
+
+func TestTranscribeJoinsChunks(t *testing.T) {
+	srv := scriptedFileServer(t, "hello there", "please send the money", "")
+
+	// 2.5s of silence, cut at 0.76s & 1.52s
+	resp, err := srv.Transcribe(context.Background(), &pb.TranscribeRequest{Audio: make([]byte, 16000*5/2*2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Chunks) != 3 || resp.DurationMs != 2500 || resp.Terminated {
+		t.Fatalf("expected 3 chunks of 2.5s, got %d chunks, %d ms, terminated %t", len(resp.Chunks), resp.DurationMs, resp.Terminated)
+	}
+	if resp.RawText != "hello there please send the money" || resp.Status != pb.TranscriptStatus_TRANSCRIPT_STATUS_WARNING {
+		t.Errorf("unexpected transcript %q, status %s", resp.RawText, resp.Status)
+	}
+	if len(resp.KeywordMatches) != 1 {
+		t.Fatalf("expected one keyword match, got %v", resp.KeywordMatches)
+	}
+	if m := resp.KeywordMatches[0]; string([]rune(resp.RawText)[m.Start:m.End]) != "money" {
+		t.Errorf("match offsets %d-%d not moved into the joined text", m.Start, m.End)
+	}
+	if resp.Chunks[1].KeywordMatches[0].Start != 16 {
+		t.Errorf("chunk offsets must stay relative to the chunk, got %d", resp.Chunks[1].KeywordMatches[0].Start)
+	}
+	if resp.Chunks[2].Error != nil || resp.Chunks[2].ChunkId != 3 {
+		t.Errorf("unexpected last chunk %v", resp.Chunks[2])
+	}
+}
+
+func TestTranscribeTruncatedWav(t *testing.T) {
+	srv := scriptedFileServer(t, "the first half")
+
+	// 1s declared, the upload stopped after 0.5s & half a sample
+	var wav bytes.Buffer
+	if err := pkg_wav.Write(&wav, pkg_wav.Format{Encoding: "pcm16", SampleRate: 16000, Channels: 1}, nil, make([]byte, 16000*2)); err != nil {
+		t.Fatalf("failed to write wav: %v", err)
+	}
+	truncated := wav.Bytes()[:wav.Len()-16000-1]
+
+	resp, err := srv.Transcribe(context.Background(), &pb.TranscribeRequest{SessionId: "truncated-session", Audio: truncated})
+	if err != nil {
+		t.Fatalf("truncated wav must be transcribed, got %v", err)
+	}
+	// the half sample is dropped
+	if resp.DurationMs != 499 || resp.RawText != "the first half" {
+		t.Errorf("expected the 499 ms received, got %d ms, %q", resp.DurationMs, resp.RawText)
+	}
+}
+
+func TestTranscribeTerminates(t *testing.T) {
+	srv := policyServer(t, pkg_keyword.Policy{
+		Rules: []pkg_keyword.PolicyRule{{MinSeverity: pkg_keyword.SeverityHigh, Action: pkg_keyword.ActionTerminate}},
+	})
+	prev := fileChunkDuration
+	fileChunkDuration = time.Second
+	t.Cleanup(func() { fileChunkDuration = prev })
+
+	req := &pb.TranscribeRequest{Audio: make([]byte, 16000*3*2), Config: &pb.StreamConfig{KeywordPolicyId: "strict"}}
+	resp, err := srv.Transcribe(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Terminated || len(resp.Chunks) != 1 || resp.Action != pb.KeywordAction_KEYWORD_ACTION_TERMINATE {
+		t.Errorf("expected to stop after the first chunk, got %d chunks, terminated %t, action %s", len(resp.Chunks), resp.Terminated, resp.Action)
+	}
+
+	// the streaming variant ends like TranscribeStream
+	stream := &longStream{GenericServerStream: &grpc.GenericServerStream[pb.TranscribeRequest, pb.Transcript]{
+		ServerStream: &dummyServerStream{ctx: context.Background()},
+	}}
+	if err := srv.TranscribeLong(req, stream); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %v", err)
+	}
+	if len(stream.sent) != 1 {
+		t.Errorf("expected the terminating transcript only, got %d", len(stream.sent))
+	}
+}
+
+func TestTranscribeLongStrikeState(t *testing.T) {
+	srv := policyServer(t, pkg_keyword.Policy{
+		Rules: []pkg_keyword.PolicyRule{{Category: "scam", Action: pkg_keyword.ActionWarn}},
+	})
+	prev := fileChunkDuration
+	fileChunkDuration = time.Second
+	t.Cleanup(func() { fileChunkDuration = prev })
+
+	ctx := context.Background()
+	req := &pb.TranscribeRequest{SessionId: "file-session", Audio: make([]byte, 16000*3*2), Config: &pb.StreamConfig{KeywordPolicyId: "strict"}}
+	var strikes []int32
+	stream := &longStream{
+		GenericServerStream: &grpc.GenericServerStream[pb.TranscribeRequest, pb.Transcript]{
+			ServerStream: &dummyServerStream{ctx: ctx},
+		},
+		onSend: func(*pb.Transcript) {
+			state, err := srv.GetStrikeState(ctx, &pb.StrikeStateRequest{SessionId: "file-session"})
+			if err != nil {
+				t.Errorf("GetStrikeState() during TranscribeLong error = %v", err)
+				return
+			}
+			strikes = append(strikes, state.TotalStrikes)
+		},
+	}
+	if err := srv.TranscribeLong(req, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(strikes) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(strikes))
+	}
+	for i, total := range strikes {
+		if total != int32(i+1) {
+			t.Errorf("expected one strike per chunk, got %v", strikes)
+			break
+		}
+	}
+
+	// unregistered once done
+	if _, err := srv.GetStrikeState(ctx, &pb.StrikeStateRequest{SessionId: "file-session"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound after the transcription, got %v", err)
+	}
+}
+
+type longStream struct {
+	*grpc.GenericServerStream[pb.TranscribeRequest, pb.Transcript]
+	sent   []*pb.Transcript
+	onSend func(*pb.Transcript)
+}
+
+func (l *longStream) Send(fb *pb.Transcript) error {
+	l.sent = append(l.sent, fb)
+	if l.onSend != nil {
+		l.onSend(fb)
+	}
+	return nil
+}
+
+func TestFileAudioFormats(t *testing.T) {
+	// 1s of 48 kHz stereo wav
+	var wav bytes.Buffer
+	pcm := make([]byte, 48000*2*2)
+	for i := 0; i < len(pcm); i += 2 {
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(int16(8000)))
+	}
+	if err := pkg_wav.Write(&wav, pkg_wav.Format{Encoding: "pcm16", SampleRate: 48000, Channels: 2}, nil, pcm); err != nil {
+		t.Fatalf("failed to write wav: %v", err)
+	}
+
+	mulaw := defaultSessionConfig()
+	mulaw.sampleRate, mulaw.encoding, mulaw.decoder = 8000, pb.AudioEncoding_AUDIO_ENCODING_MULAW, mustDecoder(t, "mulaw")
+
+	tests := []struct {
+		name  string
+		data  []byte
+		cfg   sessionConfig
+		bytes int
+	}{
+		{"wav", wav.Bytes(), defaultSessionConfig(), 32000},
+		{"flac", flacConstant(8000, 4), defaultSessionConfig(), 6400},
+		{"raw", bytes.Repeat([]byte{pkg.MulawEncode(8000)}, 8000), mulaw, 32000},
+	}
+	for _, tt := range tests {
+		audio, err := fileAudio("test", tt.data, tt.cfg)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if len(audio) < tt.bytes-400 || len(audio) > tt.bytes {
+			t.Errorf("%s: got %d bytes, want about %d", tt.name, len(audio), tt.bytes)
+		}
+	}
+
+	// a file is complete, a corrupt frame fails it
+	corrupt := flacConstant(8000, 4)
+	corrupt[len(corrupt)-3] ^= 0x01
+	if _, err := fileAudio("test", corrupt, defaultSessionConfig()); err == nil {
+		t.Error("expected corrupt flac to be rejected")
+	}
+	if _, err := fileAudio("test", nil, defaultSessionConfig()); err == nil {
+		t.Error("expected empty audio to be rejected")
+	}
+}
+
+func mustDecoder(t *testing.T, name string) pkg.Decoder {
+	t.Helper()
+	decoder, ok := pkg.LookupDecoder(name)
+	if !ok {
+		t.Fatalf("no %s decoder", name)
+	}
+	return decoder
+}
+
+func TestSplitAudioCutsAtSilence(t *testing.T) {
+	// 2s of noise with a 40ms pause at 0.9s
+	audio := make([]byte, 16000*2*2)
+	for i := 0; i < len(audio); i += 2 {
+		if i/2 < 14400 || i/2 >= 15040 {
+			binary.LittleEndian.PutUint16(audio[i:], uint16(int16(1000*(i%7-3))))
+		}
+	}
+
+	chunks := splitAudio(audio, 16000)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if cut := len(chunks[0]) / 2; cut < 14400 || cut > 15040 {
+		t.Errorf("cut at sample %d, expected in the pause", cut)
+	}
+	total := 0
+	for _, chunk := range chunks {
+		total += len(chunk)
+	}
+	if total != len(audio) {
+		t.Errorf("chunks hold %d bytes of %d", total, len(audio))
+	}
+}
+
+func TestAudioInputCloseFlushesResampler(t *testing.T) {
+	sessCfg := defaultSessionConfig()
+	sessCfg.sampleRate = 44100
+	input, err := newAudioInput(sessCfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 0.1s, the resampler holds back half its filter
+	data, _ := input.write(make([]byte, 4410*2))
+	tail, err := input.close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tail) == 0 {
+		t.Fatal("expected the resampler tail")
+	}
+	if n := len(data) + len(tail); n < 3200-4 || n > 3200+4 {
+		t.Errorf("got %d bytes, want about 3200", n)
+	}
+}
+
+func TestAudioInputRoundsAndClips(t *testing.T) {
+	tests := []struct {
+		decoder string
+		data    []byte
+		want    []int16
+	}{
+		// 1/256 of a pcm16 step per pcm24 step: 0.5, 1.496, 1.5 & -1.5 steps
+		{"pcm24", []byte{0x80, 0x00, 0x00, 0x7F, 0x01, 0x00, 0x80, 0x01, 0x00, 0x80, 0xFE, 0xFF}, []int16{1, 1, 2, -2}},
+		// full scale stays in range
+		{"pcm32", []byte{0xFF, 0xFF, 0xFF, 0x7F, 0x00, 0x00, 0x00, 0x80}, []int16{32767, -32768}},
+		// 1.5, -1.5 & 0.25
+		{"float32", []byte{0x00, 0x00, 0xC0, 0x3F, 0x00, 0x00, 0xC0, 0xBF, 0x00, 0x00, 0x80, 0x3E}, []int16{32767, -32768, 8192}},
+	}
+	for _, tt := range tests {
+		sessCfg := defaultSessionConfig()
+		sessCfg.decoder = mustDecoder(t, tt.decoder)
+		input, err := newAudioInput(sessCfg)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.decoder, err)
+		}
+		data, err := input.write(tt.data)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.decoder, err)
+		}
+		if len(data) != len(tt.want)*2 {
+			t.Fatalf("%s: got %d bytes, want %d", tt.decoder, len(data), len(tt.want)*2)
+		}
+		for i, want := range tt.want {
+			if got := int16(binary.LittleEndian.Uint16(data[i*2:])); got != want {
+				t.Errorf("%s sample %d: got %d, want %d", tt.decoder, i, got, want)
+			}
+		}
+	}
+}
+
+func TestTranscribeStreamDeliversOnCloseSend(t *testing.T) {
+	audioSegmentation = "vad"
+	vadConfig = pkg_audio.VadConfig{HangoverMs: 300}
+	defer func() { audioSegmentation = "" }()
+
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+	go func() {
+		for req := range srv.reqChan {
+			time.Sleep(50 * time.Millisecond)
+			req.Resp <- &pkg_audio.TranscribeResult{Text: "last words"}
+		}
+	}()
+	defer close(srv.reqChan)
+
+	// the client stops mid speech, no silence ends the utterance
+	speech := make([]int16, 16000)
+	for i := range speech {
+		speech[i] = 8000
+		if (i/20)%2 == 0 {
+			speech[i] = -8000
+		}
+	}
+	stream := newMockStream(context.Background())
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: pkg.Int16SliceToBytes(speech)}}
+	close(stream.recvChan)
+
+	if err := srv.TranscribeStream(stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case fb := <-stream.sendChan:
+		if fb.RawText != "last words" {
+			t.Errorf("unexpected transcript %q", fb.RawText)
+		}
+	default:
+		t.Fatal("final utterance not delivered before the stream ended")
+	}
+}
+
+func TestTranscribeStreamLateSessionID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newMockStream(ctx)
+	srv := &server{reqChan: make(chan *pkg_audio.TranscribeRequest, 10)}
+
+	// legacy client, the session id comes with a later chunk while the ticker reads it
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 320)}}
+	stream.recvChan <- &pb.AudioChunk{Payload: &pb.AudioChunk_Data{Data: make([]byte, 320)}, SessionId: "late-session"}
+	go srv.TranscribeStream(stream)
+
+	select {
+	case req := <-srv.reqChan:
+		if req.SessionID != "late-session" && req.SessionID != "unknown-session" {
+			t.Errorf("unexpected session id %q", req.SessionID)
+		}
+		req.Resp <- &pkg_audio.TranscribeResult{Text: "hello"}
+	case <-time.After(time.Second):
+		t.Fatal("no request received")
 	}
+	<-stream.sendChan
 }
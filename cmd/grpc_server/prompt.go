@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+)
+
+const defaultPromptWords = 32
+
+// promptConfig is the whisper prompt setup of new sessions, from the whisper section of config.audio.json
+type promptConfig struct {
+	words      int // recent transcript words in the initial prompt
+	vocabulary []string
+}
+
+// whisperPromptApply swaps the vocabulary & prompt length of sessions started after it
+func whisperPromptApply(audioCfg pkg_audio.AudioConfig) {
+	prompt := promptConfig{words: defaultPromptWords, vocabulary: audioCfg.Whisper.Vocabulary}
+	switch words := audioCfg.Whisper.PromptWords; {
+	case words < 0:
+		prompt.words = 0
+	case words > 0:
+		prompt.words = words
+	}
+	whisperPrompt.Store(&prompt)
+}
+
+// promptLoad returns the active whisper prompt config
+func promptLoad() promptConfig {
+	if prompt := whisperPrompt.Load(); prompt != nil {
+		return *prompt
+	}
+	return promptConfig{words: defaultPromptWords}
+}
+
+// sessionPrompt is the whisper initial prompt of one session: its vocabulary & the end of its transcript
+// chunks are transcribed one by one, the prompt keeps names & spelling consistent between them
+// each request carries its own copy, workers never keep it for another session
+type sessionPrompt struct {
+	mu         sync.Mutex
+	vocabulary string
+	maxWords   int
+	recent     string
+}
+
+// newSessionPrompt joins the vocabularies without duplicates, maxWords 0 leaves the transcript out
+func newSessionPrompt(maxWords int, vocabularies ...[]string) *sessionPrompt {
+	var words []string
+	seen := map[string]bool{}
+	for _, vocabulary := range vocabularies {
+		for _, word := range vocabulary {
+			word = strings.TrimSpace(word)
+			if word == "" || seen[strings.ToLower(word)] {
+				continue
+			}
+			seen[strings.ToLower(word)] = true
+			words = append(words, word)
+		}
+	}
+
+	vocabulary := ""
+	if len(words) > 0 {
+		vocabulary = strings.Join(words, ", ") + "."
+	}
+	return &sessionPrompt{vocabulary: vocabulary, maxWords: maxWords}
+}
+
+// add appends transcribed text, only the last maxWords words are kept
+func (p *sessionPrompt) add(text string) {
+	text = strings.TrimSpace(text)
+	if p.maxWords <= 0 || text == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recent = lastWords(strings.TrimSpace(p.recent+" "+text), p.maxWords)
+}
+
+// string returns the prompt for the next request, empty when there's nothing to say
+func (p *sessionPrompt) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return strings.TrimSpace(p.vocabulary + " " + p.recent)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+)
+
+// policiesLoad returns the active keyword policies by id, never modify the map
+func policiesLoad() map[string]pkg_keyword.Policy {
+	if policies := keywordPolicies.Load(); policies != nil {
+		return *policies
+	}
+	return nil
+}
+
+// keywordConfigApply validates the keyword & pii sections & swaps them in, all or nothing
+// the engine compiles before swapping, policies are swapped only after the engine
+func keywordConfigApply(audioCfg pkg_audio.AudioConfig) error {
+	for id, policy := range audioCfg.Keywords.Policies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("keyword policy %q: %w", id, err)
+		}
+		for _, level := range policy.Strikes.Levels {
+			if _, err := strikeStatusCode(level); err != nil {
+				return fmt.Errorf("keyword policy %q: %w", id, err)
+			}
+		}
+	}
+
+	if err := audioCfg.Pii.Validate(); err != nil {
+		return err
+	}
+
+	defaultLanguage := audioCfg.Keywords.DefaultLanguage
+	if defaultLanguage == "" {
+		defaultLanguage = "en"
+	}
+	lists := pkg_keyword.Lists{
+		Forbidden:       audioCfg.Keywords.Forbidden,
+		Exceptions:      audioCfg.Keywords.Exceptions,
+		DefaultLanguage: defaultLanguage,
+	}
+	if keywordEngine == nil {
+		engine, err := pkg_keyword.NewEngine(lists)
+		if err != nil {
+			return fmt.Errorf("forbidden keywords: %w", err)
+		}
+		keywordEngine = engine
+	} else if err := keywordEngine.Update(lists); err != nil {
+		return fmt.Errorf("forbidden keywords: %w", err)
+	}
+
+	policies := audioCfg.Keywords.Policies
+	keywordPolicies.Store(&policies)
+	pii := audioCfg.Pii
+	piiConfig.Store(&pii)
+
+	snapshot := keywordEngine.Snapshot()
+	log.Printf("keywords version %d active: %d keywords in %d languages, default %s, %d policies, pii detection %t",
+		snapshot.Version(), snapshot.Len(), snapshot.Languages(), defaultLanguage, len(policies), pii.Enabled)
+	return nil
+}
+
+// audioConfigApply swaps the reloadable parts of config.audio.json, see audioConfigLive
+func audioConfigApply(audioCfg pkg_audio.AudioConfig) error {
+	if err := keywordConfigApply(audioCfg); err != nil {
+		return err
+	}
+	whisperPromptApply(audioCfg)
+	return nil
+}
+
+// json keys of config.audio.json that need no restart, swapped in by audioConfigApply or read by the client only
+// changes to the others are logged as needing a restart, every key of config.grpc.json does
+var audioConfigLive = []string{"keywords", "pii", "whisper.vocabulary", "whisper.prompt_words", "processing", "stream"}
+
+// configReloader re-reads a config file on SIGHUP or when the file changes
+// keyword lists apply to live streams right away, policies & the whisper prompt to streams started after the reload
+// changed keys that apply only on restart are named in the log
+// a file that fails to load or validate is logged & the active config is kept
+type configReloader[T any] struct {
+	path  string
+	load  func(path string) (T, error)
+	apply func(cfg T) error // nil when nothing is reloadable
+	live  []string          // json keys that need no restart
+
+	mu     sync.Mutex // one reload at a time
+	hash   [sha256.Size]byte
+	active T
+
+	// file state seen by watch, taken when the active config was read
+	modTime time.Time
+	size    int64
+}
+
+func newConfigReloader[T any](path string, active T, load func(string) (T, error), apply func(T) error, live []string) (*configReloader[T], error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &configReloader[T]{
+		path:    path,
+		load:    load,
+		apply:   apply,
+		live:    live,
+		hash:    sha256.Sum256(content),
+		active:  active,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}, nil
+}
+
+// reload swaps the config in if the file content changed
+func (r *configReloader[T]) reload(reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("reload (%s): %w", reason, err)
+	}
+	hash := sha256.Sum256(content)
+	if hash == r.hash {
+		log.Printf("reload (%s): %s unchanged", reason, r.path)
+		return nil
+	}
+
+	cfg, err := r.load(r.path)
+	if err != nil {
+		return fmt.Errorf("reload (%s): %w", reason, err)
+	}
+	if r.apply != nil {
+		if err := r.apply(cfg); err != nil {
+			return fmt.Errorf("reload (%s): %w", reason, err)
+		}
+	}
+
+	// the inference backend & the server settings are built once, tell instead of silently ignoring
+	if keys := r.restartKeys(cfg); len(keys) > 0 {
+		log.Printf("reload (%s): %s changed, needs a restart", reason, strings.Join(keys, ", "))
+	}
+
+	r.hash = hash
+	r.active = cfg
+	log.Printf("reload (%s): %s applied", reason, r.path)
+	return nil
+}
+
+// restartKeys returns the changed json keys of cfg that the reload did not apply
+func (r *configReloader[T]) restartKeys(cfg T) []string {
+	var keys []string
+	for _, key := range changedKeys(r.active, cfg) {
+		live := false
+		for _, prefix := range r.live {
+			if key == prefix || strings.HasPrefix(key, prefix+".") {
+				live = true
+				break
+			}
+		}
+		if !live {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// watch polls the file every interval & reloads when its size or modification time changes
+func (r *configReloader[T]) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastMod, lastSize := r.modTime, r.size
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil {
+				// editors replace files with a rename, it's back on the next tick
+				continue
+			}
+			if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+				continue
+			}
+			lastMod, lastSize = info.ModTime(), info.Size()
+			if err := r.reload("file change"); err != nil {
+				log.Printf("config reload failed, keeping the active config: %v", err)
+			}
+		}
+	}
+}
+
+// changedKeys returns the sorted dotted json paths that differ between a & b, down to the first non object value
+func changedKeys(a, b any) []string {
+	var keys []string
+	diffJSON("", jsonValue(a), jsonValue(b), &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonValue(v any) any {
+	var out any
+	if data, err := json.Marshal(v); err == nil {
+		json.Unmarshal(data, &out)
+	}
+	return out
+}
+
+func diffJSON(path string, a, b any, keys *[]string) {
+	objA, okA := a.(map[string]any)
+	objB, okB := b.(map[string]any)
+	if !okA || !okB {
+		if !reflect.DeepEqual(a, b) {
+			*keys = append(*keys, path)
+		}
+		return
+	}
+	for key, value := range objA {
+		diffJSON(joinKey(path, key), value, objB[key], keys)
+	}
+	for key, value := range objB {
+		if _, ok := objA[key]; !ok {
+			diffJSON(joinKey(path, key), nil, value, keys)
+		}
+	}
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
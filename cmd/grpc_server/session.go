@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+)
+
+// sessionTranscript applies the session keyword policy to worker results, shared by streams & file transcriptions
+// results must be delivered in transcript order
+type sessionTranscript struct {
+	cfg     sessionConfig
+	tail    *transcriptTail // phrases split between two results are matched on the tail of the transcript
+	prompt  *sessionPrompt  // whisper initial prompt, per session
+	strikes *pkg_keyword.StrikeTracker
+}
+
+func newSessionTranscript(sessCfg sessionConfig) *sessionTranscript {
+	prompt := promptLoad()
+	return &sessionTranscript{
+		cfg:     sessCfg,
+		tail:    newTranscriptTail(transcriptTailWords),
+		prompt:  newSessionPrompt(prompt.words, prompt.vocabulary, sessCfg.vocabulary),
+		strikes: pkg_keyword.NewStrikeTracker(sessCfg.policy.Strikes),
+	}
+}
+
+// options returns the transcribe options of the next chunk, with the prompt so far
+func (t *sessionTranscript) options() pkg_audio.TranscribeOptions {
+	options := t.cfg.options
+	options.Prompt = t.prompt.String()
+	return options
+}
+
+// deliver applies the session keyword policy & turns a result into client feedback
+func (t *sessionTranscript) deliver(res *pkg_audio.TranscribeResult, ref chunkRef, sessionID string) *pb.Transcript {
+	crossing, crossingSuppressed := t.tail.add(res.Language, res.Text)
+	t.prompt.add(res.Text)
+	res.Matches = append(crossing, res.Matches...)
+	res.Suppressed = append(crossingSuppressed, res.Suppressed...)
+
+	var suppressed []pkg_keyword.Match
+	res.Matches, suppressed = t.cfg.policy.Suppress(res.Language, res.Text, res.Matches)
+	res.Suppressed = append(res.Suppressed, suppressed...)
+	res.Warning = len(res.Matches) > 0
+	res.Keywords = pkg_keyword.MatchedTerms(res.Matches)
+	res.Matches, res.Action = t.cfg.policy.Apply(res.Matches)
+
+	if len(res.Suppressed) > 0 {
+		log.Printf("[%s] keywords cleared by exceptions: %v", sessionID, suppressedReasons(res.Suppressed))
+	}
+
+	if pii := piiConfig.Load(); pii != nil && pii.Enabled {
+		res.PII = pkg_pii.Detect(res.Text, pii.Types)
+		for i := range res.PII {
+			res.PII[i].Masked = pii.Mask
+		}
+		if len(res.PII) > 0 {
+			log.Printf("[%s] personal data detected: %v", sessionID, piiTypes(res.PII))
+		}
+	}
+
+	switch {
+	case res.Action == pkg_keyword.ActionTerminate:
+		log.Printf("[%s] forbidden keywords detected: %v, terminating stream", sessionID, res.Keywords)
+	case res.Action == pkg_keyword.ActionEscalate:
+		log.Printf("[%s] forbidden keywords detected: %v, escalated: '%s'", sessionID, res.Keywords, res.Text)
+	case res.Warning:
+		// the unmasked text stays in the server log, clients may only get it masked
+		log.Printf("[%s] forbidden keywords detected: %v, action %s: '%s'", sessionID, res.Keywords, res.Action, res.Text)
+	case res.Text != "":
+		log.Printf("[%s] processed: '%s'", sessionID, res.Text)
+	}
+
+	fb := transcriptFromResult(res, ref, t.cfg.masking)
+
+	// every transcript with an action is a strike
+	if res.Action != "" {
+		state, level := t.strikes.Strike(time.Now())
+		if level != nil {
+			log.Printf("[%s] strike level %s reached: %d strikes in %s, terminate %t",
+				sessionID, level.Name, state.Strikes, state.Window, level.Terminate)
+			fb.Escalation = escalationEventToPb(*level, state)
+		}
+	}
+
+	return fb
+}
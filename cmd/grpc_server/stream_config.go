@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_convert "showcase-backend-audio_transcriber-go/pkg/convert"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+)
+
+// sessionConfig is the negotiated configuration of a single stream
+type sessionConfig struct {
+	sampleRate int
+	channels   int
+	encoding   pb.AudioEncoding
+	decoder    pkg.Decoder
+	options    pkg_audio.TranscribeOptions
+	policyID   string
+	policy     pkg_keyword.Policy
+	masking    pkg_keyword.Masking
+	vocabulary []string
+	metadata   map[string]string
+}
+
+// whisper prompts are short, longer vocabularies are cut by the model anyway
+const maxSessionVocabulary = 50
+
+// metadata keys selecting the masking of the outgoing text
+// "mask": asterisks, category or replacement masks every detected keyword, "mask_replacement" is the replacement text
+// without them only the keywords the policy masks are masked, by asterisks
+const (
+	metadataMask            = "mask"
+	metadataMaskReplacement = "mask_replacement"
+)
+
+// pcm16Decoder decodes streams of legacy clients & streams without a declared encoding
+var pcm16Decoder, _ = decoderFor(pb.AudioEncoding_AUDIO_ENCODING_PCM16)
+
+// decoderFor looks up the decoder registered under the encoding name, AUDIO_ENCODING_MULAW is mulaw
+func decoderFor(encoding pb.AudioEncoding) (pkg.Decoder, bool) {
+	return pkg.LookupDecoder(strings.ToLower(strings.TrimPrefix(encoding.String(), "AUDIO_ENCODING_")))
+}
+
+// defaultSessionConfig is used by legacy clients that start streaming audio without a StreamConfig
+func defaultSessionConfig() sessionConfig {
+	return sessionConfig{
+		sampleRate: pkg_audio.WhisperSampleRate,
+		channels:   1,
+		encoding:   pb.AudioEncoding_AUDIO_ENCODING_PCM16,
+		decoder:    pcm16Decoder,
+		options:    pkg_audio.TranscribeOptions{Language: "auto"},
+		policyID:   "default",
+		policy:     policiesLoad()["default"],
+	}
+}
+
+// streamConfigApply validates the client StreamConfig & fills in defaults
+func streamConfigApply(cfg *pb.StreamConfig) (sessionConfig, error) {
+	sessCfg := defaultSessionConfig()
+
+	// any rate & channel layout is converted to 16 kHz mono, see audioInput
+	if cfg.SampleRate != 0 {
+		sessCfg.sampleRate = int(cfg.SampleRate)
+	}
+	if cfg.Channels != 0 {
+		sessCfg.channels = int(cfg.Channels)
+	}
+	format := pkg_convert.Format{SampleRate: sessCfg.sampleRate, Channels: sessCfg.channels}
+	if err := format.Validate(); err != nil {
+		return sessCfg, err
+	}
+
+	switch cfg.Encoding {
+	case pb.AudioEncoding_AUDIO_ENCODING_UNSPECIFIED:
+		// pcm16
+	case pb.AudioEncoding_AUDIO_ENCODING_FLAC:
+		// not sample by sample, audioInput decodes the frames
+		sessCfg.encoding = cfg.Encoding
+	default:
+		decoder, ok := decoderFor(cfg.Encoding)
+		if !ok {
+			return sessCfg, fmt.Errorf("unsupported encoding %s, expected flac or one of %s", cfg.Encoding, strings.Join(pkg.DecoderNames(), ", "))
+		}
+		sessCfg.encoding, sessCfg.decoder = cfg.Encoding, decoder
+	}
+
+	if cfg.Language != "" {
+		if cfg.Language != "auto" && !languageCode(cfg.Language) {
+			return sessCfg, fmt.Errorf("invalid language %q, expected a 2 or 3 letter lowercase code or auto", cfg.Language)
+		}
+		sessCfg.options.Language = cfg.Language
+	}
+	sessCfg.options.Translate = cfg.Translate
+
+	if cfg.KeywordPolicyId != "" {
+		policy, ok := policiesLoad()[cfg.KeywordPolicyId]
+		if !ok && cfg.KeywordPolicyId != "default" {
+			return sessCfg, fmt.Errorf("unknown keyword policy %q", cfg.KeywordPolicyId)
+		}
+		sessCfg.policyID = cfg.KeywordPolicyId
+		sessCfg.policy = policy
+	}
+
+	if len(cfg.Vocabulary) > maxSessionVocabulary {
+		return sessCfg, fmt.Errorf("vocabulary of %d words, expected at most %d", len(cfg.Vocabulary), maxSessionVocabulary)
+	}
+	sessCfg.vocabulary = cfg.Vocabulary
+
+	masking, err := maskingFromMetadata(cfg.Metadata)
+	if err != nil {
+		return sessCfg, err
+	}
+	sessCfg.masking = masking
+	sessCfg.metadata = cfg.Metadata
+
+	return sessCfg, nil
+}
+
+// languageCode reports whether lang looks like a whisper language code, iso 639-1 or 639-3 for "haw" & "yue"
+func languageCode(lang string) bool {
+	if len(lang) < 2 || len(lang) > 3 {
+		return false
+	}
+	for _, c := range lang {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func maskingFromMetadata(metadata map[string]string) (pkg_keyword.Masking, error) {
+	style, ok := metadata[metadataMask]
+	if !ok {
+		return pkg_keyword.Masking{}, nil
+	}
+	masking := pkg_keyword.Masking{
+		Style:       pkg_keyword.MaskStyle(style),
+		Replacement: metadata[metadataMaskReplacement],
+		All:         true,
+	}
+	if err := masking.Validate(); err != nil {
+		return masking, fmt.Errorf("metadata %s: %w", metadataMask, err)
+	}
+	return masking, nil
+}
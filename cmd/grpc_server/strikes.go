@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetStrikeState reports the strikes of a live session
+func (s *server) GetStrikeState(ctx context.Context, req *pb.StrikeStateRequest) (*pb.StrikeState, error) {
+	value, ok := s.sessions.Load(req.SessionId)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no live session %q", req.SessionId)
+	}
+
+	state := value.(*pkg_keyword.StrikeTracker).State(time.Now())
+	out := &pb.StrikeState{
+		SessionId:    req.SessionId,
+		Strikes:      int32(state.Strikes),
+		TotalStrikes: int32(state.Total),
+		Level:        state.Level,
+		WindowMs:     state.Window.Milliseconds(),
+	}
+	if !state.LastStrike.IsZero() {
+		out.LastStrikeUnixMs = state.LastStrike.UnixMilli()
+	}
+	return out, nil
+}
+
+// strikeStatusCode parses the grpc status code name of a strike level, PERMISSION_DENIED if empty
+func strikeStatusCode(level pkg_keyword.StrikeLevel) (codes.Code, error) {
+	if level.StatusCode == "" {
+		return codes.PermissionDenied, nil
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(level.StatusCode))); err != nil {
+		return code, fmt.Errorf("strike level %q: invalid status code %q", level.Name, level.StatusCode)
+	}
+	if code == codes.OK {
+		return code, fmt.Errorf("strike level %q: status code OK doesn't end the stream", level.Name)
+	}
+	return code, nil
+}
+
+// escalationEventToPb reports a reached strike level
+func escalationEventToPb(level pkg_keyword.StrikeLevel, state pkg_keyword.StrikeState) *pb.EscalationEvent {
+	event := &pb.EscalationEvent{
+		Level:     level.Name,
+		Strikes:   int32(state.Strikes),
+		WindowMs:  state.Window.Milliseconds(),
+		Terminate: level.Terminate,
+	}
+	if level.Terminate {
+		// validated on startup
+		code, _ := strikeStatusCode(level)
+		event.StatusCode = int32(code)
+	}
+	return event
+}
+
+// terminationStatus is the status ending the stream after fb was sent, nil to keep it open
+func terminationStatus(fb *pb.Transcript) error {
+	if e := fb.Escalation; e != nil && e.Terminate {
+		return status.Errorf(codes.Code(e.StatusCode), "stream terminated: strike level %s reached, %d strikes in %s",
+			e.Level, e.Strikes, time.Duration(e.WindowMs)*time.Millisecond)
+	}
+	if fb.Action == pb.KeywordAction_KEYWORD_ACTION_TERMINATE {
+		return status.Errorf(codes.PermissionDenied, "stream terminated by keyword policy: %v", fb.DetectedKeywords)
+	}
+	return nil
+}
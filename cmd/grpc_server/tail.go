@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+)
+
+// transcriptTail keeps the last words of a session transcript
+// phrases split between two chunks ("wire the" | "money") are matched across the boundary
+// results must be added in transcript order
+type transcriptTail struct {
+	mu       sync.Mutex
+	maxWords int
+	text     string
+}
+
+// newTranscriptTail returns nil when maxWords is 0, a nil tail finds nothing
+func newTranscriptTail(maxWords int) *transcriptTail {
+	if maxWords <= 0 {
+		return nil
+	}
+	return &transcriptTail{maxWords: maxWords}
+}
+
+// add matches the tail followed by text & moves the tail to the end of text
+// only matches starting in the tail & ending in text are returned, the others are reported with their own text
+// offsets are relative to text, a crossing match starts at 0
+func (t *transcriptTail) add(language, text string) (crossing, suppressed []pkg_keyword.Match) {
+	crossing, suppressed = []pkg_keyword.Match{}, []pkg_keyword.Match{}
+	if t == nil || text == "" {
+		return crossing, suppressed
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.text == "" {
+		t.text = lastWords(text, t.maxWords)
+		return crossing, suppressed
+	}
+
+	// the joining space belongs to the new text
+	boundary := utf8.RuneCountInString(t.text) + 1
+	combined := t.text + " " + text
+	t.text = lastWords(combined, t.maxWords)
+
+	keep := func(in []pkg_keyword.Match) []pkg_keyword.Match {
+		out := []pkg_keyword.Match{}
+		for _, match := range in {
+			if match.Start >= boundary || match.End <= boundary {
+				continue
+			}
+			match.Start = 0
+			match.End -= boundary
+			out = append(out, match)
+		}
+		return out
+	}
+
+	matches, cleared := keywordEngine.Snapshot().Match(language, combined)
+	return keep(matches), keep(cleared)
+}
+
+// lastWords returns text from its last n words on
+func lastWords(text string, n int) string {
+	tokens := pkg_keyword.Tokenize(text)
+	if len(tokens) <= n {
+		return text
+	}
+	return string([]rune(text)[tokens[len(tokens)-n].Start:])
+}
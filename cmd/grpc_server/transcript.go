@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
+	pb "showcase-backend-audio_transcriber-go/protobuf"
+)
+
+// chunkRef identifies an audio chunk sent to the workers
+type chunkRef struct {
+	id       uint64
+	offset   time.Duration // relative to the session start
+	duration time.Duration
+}
+
+// transcriptFromResult builds the client feedback for a worker result
+// text keeps the legacy display format, raw_text & status are meant for parsing
+// matches selected by the session masking are masked everywhere the text is sent, keywords included, offsets point into the masked raw_text
+// normalized_text is derived from the masked text so it never reveals masked words
+func transcriptFromResult(res *pkg_audio.TranscribeResult, ref chunkRef, masking pkg_keyword.Masking) *pb.Transcript {
+	masked := masking.Apply(res.Text, append(piiMaskMatches(res.PII), res.Matches...))
+	text := masked.Text
+
+	fb := &pb.Transcript{
+		ChunkId:           ref.id,
+		RawText:           text,
+		NormalizedText:    pkg.Normalize(text).Text,
+		Language:          res.Language,
+		Segments:          segmentsToPb(maskSegments(res.Segments, res.Text, masked)),
+		KeywordMatches:    matchesToPb(masked.Matches(res.Matches)),
+		Action:            actionToPb(res.Action),
+		SuppressedMatches: matchesToPb(masked.Matches(res.Suppressed)),
+		PiiMatches:        piiToPb(res.PII, masked),
+	}
+
+	switch {
+	case res.Warning:
+		fb.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_WARNING
+		fb.Text = fmt.Sprintf("detected forbidden keyword: %v - '%s'", keywordLabels(res.Matches, masking), text)
+		fb.Warning = true
+		fb.DetectedKeywords = keywordLabels(res.Matches, masking)
+	case res.Text != "":
+		fb.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_OK
+		fb.Text = fmt.Sprintf("ok: '%s'", text)
+	default:
+		fb.Status = pb.TranscriptStatus_TRANSCRIPT_STATUS_EMPTY
+	}
+
+	return fb
+}
+
+// keywordLabels lists the detected keywords for display, masked keywords as they appear in the text
+func keywordLabels(matches []pkg_keyword.Match, masking pkg_keyword.Masking) []string {
+	labels := []string{}
+	seen := map[string]bool{}
+	for _, match := range matches {
+		label := masking.Label(match)
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// maskSegments takes the segment texts from the masked text
+// segments are the pieces text was joined from, a segment not found there is masked as a whole
+func maskSegments(segments []pkg_audio.Segment, text string, masked pkg_keyword.MaskedText) []pkg_audio.Segment {
+	if masked.Text == text {
+		return segments
+	}
+
+	runes, maskedRunes := []rune(text), []rune(masked.Text)
+	out := make([]pkg_audio.Segment, len(segments))
+	cursor := 0
+	for i, segment := range segments {
+		out[i] = segment
+		segmentRunes := []rune(segment.Text)
+		offset := runesIndex(runes[cursor:], segmentRunes)
+		if offset < 0 {
+			out[i].Text = pkg_keyword.Mask(segment.Text)
+			continue
+		}
+		start, end := masked.Span(cursor+offset, cursor+offset+len(segmentRunes))
+		out[i].Text = string(maskedRunes[start:end])
+		cursor += offset + len(segmentRunes)
+	}
+	return out
+}
+
+func runesIndex(runes, sub []rune) int {
+	for i := 0; i+len(sub) <= len(runes); i++ {
+		if slices.Equal(runes[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// streamErrorTranscript reports a lost chunk to the client
+func streamErrorTranscript(ref chunkRef, code pb.StreamErrorCode, message string) *pb.Transcript {
+	return &pb.Transcript{
+		Text:    fmt.Sprintf("error: %s", message),
+		Status:  pb.TranscriptStatus_TRANSCRIPT_STATUS_ERROR,
+		ChunkId: ref.id,
+		Error: &pb.StreamError{
+			Code:    code,
+			Message: message,
+			ChunkId: ref.id,
+			StartMs: ref.offset.Milliseconds(),
+			EndMs:   (ref.offset + ref.duration).Milliseconds(),
+		},
+	}
+}
+
+// resultErrorCode classifies a failed worker result
+func resultErrorCode(err error) pb.StreamErrorCode {
+	if errors.Is(err, pkg_audio.ErrAudioConversion) {
+		return pb.StreamErrorCode_STREAM_ERROR_CODE_AUDIO_CONVERSION
+	}
+	return pb.StreamErrorCode_STREAM_ERROR_CODE_TRANSCRIPTION
+}
+
+func segmentsToPb(segments []pkg_audio.Segment) []*pb.TranscriptSegment {
+	out := make([]*pb.TranscriptSegment, 0, len(segments))
+	for _, segment := range segments {
+		out = append(out, &pb.TranscriptSegment{
+			StartMs: segment.Start.Milliseconds(),
+			EndMs:   segment.End.Milliseconds(),
+			Text:    segment.Text,
+		})
+	}
+	return out
+}
+
+func matchesToPb(matches []pkg_keyword.Match) []*pb.KeywordMatch {
+	out := make([]*pb.KeywordMatch, 0, len(matches))
+	for _, match := range matches {
+		out = append(out, &pb.KeywordMatch{
+			Keyword:      match.Keyword,
+			Text:         match.Text,
+			Start:        int32(match.Start),
+			End:          int32(match.End),
+			Score:        float32(match.Score),
+			Category:     match.Category,
+			Severity:     severityToPb(match.Severity),
+			Action:       actionToPb(match.Action),
+			SuppressedBy: match.SuppressedBy,
+			Captures:     match.Captures,
+		})
+	}
+	return out
+}
+
+// piiMaskMatches turns masked pii findings into matches for the masking, category style shows the type
+func piiMaskMatches(findings []pkg_pii.Finding) []pkg_keyword.Match {
+	var matches []pkg_keyword.Match
+	for _, finding := range findings {
+		if !finding.Masked {
+			continue
+		}
+		matches = append(matches, pkg_keyword.Match{
+			Keyword:  string(finding.Type),
+			Text:     finding.Text,
+			Start:    finding.Start,
+			End:      finding.End,
+			Category: string(finding.Type),
+			Action:   pkg_keyword.ActionMask,
+		})
+	}
+	return matches
+}
+
+// piiToPb reports findings with offsets & text from the masked text, the canonical value stays on the server
+func piiToPb(findings []pkg_pii.Finding, masked pkg_keyword.MaskedText) []*pb.PiiMatch {
+	runes := []rune(masked.Text)
+	out := make([]*pb.PiiMatch, 0, len(findings))
+	for _, finding := range findings {
+		start, end := masked.Span(finding.Start, finding.End)
+		if start < 0 || start > end || end > len(runes) {
+			continue
+		}
+		out = append(out, &pb.PiiMatch{
+			Type:   piiTypeToPb(finding.Type),
+			Text:   string(runes[start:end]),
+			Start:  int32(start),
+			End:    int32(end),
+			Masked: finding.Masked,
+		})
+	}
+	return out
+}
+
+// piiTypes lists the types of the findings for the log, never the data
+func piiTypes(findings []pkg_pii.Finding) []pkg_pii.Type {
+	out := make([]pkg_pii.Type, 0, len(findings))
+	for _, finding := range findings {
+		out = append(out, finding.Type)
+	}
+	return out
+}
+
+func piiTypeToPb(t pkg_pii.Type) pb.PiiType {
+	switch t {
+	case pkg_pii.TypeEmail:
+		return pb.PiiType_PII_TYPE_EMAIL
+	case pkg_pii.TypePhone:
+		return pb.PiiType_PII_TYPE_PHONE
+	case pkg_pii.TypeIBAN:
+		return pb.PiiType_PII_TYPE_IBAN
+	case pkg_pii.TypeCard:
+		return pb.PiiType_PII_TYPE_CARD
+	default:
+		return pb.PiiType_PII_TYPE_UNSPECIFIED
+	}
+}
+
+// suppressedReasons formats suppressed matches for the log
+func suppressedReasons(matches []pkg_keyword.Match) []string {
+	reasons := make([]string, 0, len(matches))
+	for _, match := range matches {
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", match.Keyword, match.SuppressedBy))
+	}
+	return reasons
+}
+
+func severityToPb(severity pkg_keyword.Severity) pb.KeywordSeverity {
+	switch severity {
+	case pkg_keyword.SeverityLow:
+		return pb.KeywordSeverity_KEYWORD_SEVERITY_LOW
+	case pkg_keyword.SeverityMedium:
+		return pb.KeywordSeverity_KEYWORD_SEVERITY_MEDIUM
+	case pkg_keyword.SeverityHigh:
+		return pb.KeywordSeverity_KEYWORD_SEVERITY_HIGH
+	case pkg_keyword.SeverityCritical:
+		return pb.KeywordSeverity_KEYWORD_SEVERITY_CRITICAL
+	default:
+		return pb.KeywordSeverity_KEYWORD_SEVERITY_UNSPECIFIED
+	}
+}
+
+func actionToPb(action pkg_keyword.Action) pb.KeywordAction {
+	switch action {
+	case pkg_keyword.ActionWarn:
+		return pb.KeywordAction_KEYWORD_ACTION_WARN
+	case pkg_keyword.ActionMask:
+		return pb.KeywordAction_KEYWORD_ACTION_MASK
+	case pkg_keyword.ActionEscalate:
+		return pb.KeywordAction_KEYWORD_ACTION_ESCALATE
+	case pkg_keyword.ActionTerminate:
+		return pb.KeywordAction_KEYWORD_ACTION_TERMINATE
+	default:
+		return pb.KeywordAction_KEYWORD_ACTION_UNSPECIFIED
+	}
+}
@@ -3,17 +3,28 @@ package pkg_audio
 import (
 	"encoding/json"
 	"os"
+
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
 )
 
 type AudioConfig struct {
 	Keywords struct {
-		Forbidden struct {
-			En []string `json:"en"`
-		} `json:"forbidden"`
+		Forbidden map[string][]pkg_keyword.Keyword `json:"forbidden"` // iso 639-1 language -> "term" or {"term", "mode", "stem", "category", "severity", ...}
+		DefaultLanguage string `json:"default_language"` // list used when the detected language has none, default en
+		Exceptions map[string]pkg_keyword.Exceptions `json:"exceptions"` // iso 639-1 language -> {"allow", "context"}
+		Policies map[string]pkg_keyword.Policy `json:"policies"` // keyword policy id -> rules, "default" unless the stream picks one
 	} `json:"keywords"`
+	Pii pkg_pii.Config `json:"pii"` // {"enabled", "mask", "types"}: email, phone, iban, card
 	Whisper struct {
 		Model string `json:"model"`
+		Vocabulary []string `json:"vocabulary"` // names & domain words passed in the initial prompt of every session
+		PromptWords int `json:"prompt_words"` // recent transcript words in the initial prompt, default 32, negative disables
 	} `json:"whisper"`
+	Transcriber struct {
+		Backend string `json:"backend"` // whisper (default) or scripted
+		Script []string `json:"script"` // scripted backend lines, replayed in order
+	} `json:"transcriber"`
 	Processing struct {
 		SendingTicker int `json:"sending_ticker"` // in ms
 		SampleRate float64 `json:"sample_rate"`
@@ -21,6 +32,13 @@ type AudioConfig struct {
 		AudioChannels int `json:"audio_channels"`
 		AudioBufChannelSize int `json:"audio_buf_channel_size"`
 	} `json:"processing"`
+	Stream struct {
+		Language string `json:"language"` // language hint, empty or auto to detect
+		Translate bool `json:"translate"`
+		KeywordPolicy string `json:"keyword_policy"` // empty = default policy
+		Vocabulary []string `json:"vocabulary"` // names & domain words of this client, see whisper.vocabulary
+		File string `json:"file"` // wav file streamed instead of the microphone, empty = microphone
+	} `json:"stream"`
 }
 
 func AudioConfigLoad(fp string) (AudioConfig, error) {
@@ -2,6 +2,11 @@ package pkg_audio
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
 )
 
 // transcribeRequest represents a request to transcribe audio
@@ -10,12 +15,27 @@ type TranscribeRequest struct {
 	Resp      chan<- *TranscribeResult
 	Ctx       context.Context
 	SessionID string
+	Options   TranscribeOptions // per session, negotiated with StreamConfig
+	Offset    time.Duration     // audio start relative to the session start
 }
 
 // transcribeResult is the result of transcription
 type TranscribeResult struct {
 	Text     string
+	Language string // detected language, iso 639-1
 	Warning  bool
 	Keywords []string
+	Matches  []pkg_keyword.Match // keyword offsets in Text
+	// matches cleared by exceptions, informational
+	Suppressed []pkg_keyword.Match
+	Segments []Segment           // offsets relative to the session start
+	Action   pkg_keyword.Action  // strongest action of the matches, set by the session policy
+	PII      []pkg_pii.Finding   // personal data in Text, a separate detection from keywords
 	Err      error
 }
+
+// errors wrapped in TranscribeResult.Err, check with errors.Is
+var (
+	ErrAudioConversion = errors.New("audio conversion")
+	ErrTranscribe      = errors.New("transcribe")
+)
@@ -0,0 +1,43 @@
+package pkg_audio
+
+import (
+	"time"
+)
+
+// whisperSampleRate is the pcm sample rate every transcriber expects (mono)
+const WhisperSampleRate = 16000
+
+// transcribeOptions tunes a single transcription call
+type TranscribeOptions struct {
+	Language  string // language hint, "auto" lets the engine detect
+	Translate bool   // translate to english
+	Prompt    string // initial prompt, recent transcript & vocabulary of the session, empty clears it
+}
+
+// segment is a piece of transcribed text, offsets are relative to the start of the samples
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// transcription is the output of a single Transcribe call
+type Transcription struct {
+	Language string // detected (or hinted) language, iso 639-1, empty if unknown
+	Segments []Segment
+}
+
+// transcriber is an inference backend that turns 16 kHz mono pcm floats into segments
+// - implementations are not required to be thread safe
+// - the worker pool creates one transcriber per worker through NewTranscriberFunc
+type Transcriber interface {
+	Transcribe(samples []float32, opts TranscribeOptions) (Transcription, error)
+}
+
+// newTranscriberFunc creates a transcriber for a single worker
+type NewTranscriberFunc func() (Transcriber, error)
+
+// samplesDuration returns the playback duration of n samples at WhisperSampleRate
+func SamplesDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / WhisperSampleRate
+}
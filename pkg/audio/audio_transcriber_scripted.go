@@ -0,0 +1,55 @@
+package pkg_audio
+
+import (
+	"strings"
+	"sync"
+)
+
+// scriptedTranscriber is a deterministic fake backend for tests & ci
+// it replays the script line by line (one line per call) and loops when exhausted
+// a line may start with a language tag, i.e. "[id] transfer uangnya", otherwise the hint is reported
+// it's safe to share a single instance between workers, order is kept across them
+type ScriptedTranscriber struct {
+	mu     sync.Mutex
+	script []string
+	next   int
+}
+
+func NewScriptedTranscriber(script ...string) *ScriptedTranscriber {
+	return &ScriptedTranscriber{script: script}
+}
+
+func (s *ScriptedTranscriber) Transcribe(samples []float32, opts TranscribeOptions) (Transcription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transcription Transcription
+	if opts.Language != "auto" {
+		transcription.Language = opts.Language
+	}
+
+	if len(s.script) == 0 {
+		return transcription, nil
+	}
+
+	text := s.script[s.next%len(s.script)]
+	s.next++
+
+	if strings.HasPrefix(text, "[") {
+		if end := strings.Index(text, "] "); end > 0 {
+			transcription.Language = text[1:end]
+			text = text[end+2:]
+		}
+	}
+
+	if text == "" {
+		return transcription, nil
+	}
+
+	transcription.Segments = []Segment{{
+		Start: 0,
+		End:   SamplesDuration(len(samples)),
+		Text:  text,
+	}}
+	return transcription, nil
+}
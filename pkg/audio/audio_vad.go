@@ -0,0 +1,173 @@
+package pkg_audio
+
+import (
+	"math"
+	"time"
+)
+
+// vadConfig tunes the energy/zero-crossing voice activity detector
+// all durations are in ms, zero values fall back to defaults
+type VadConfig struct {
+	FrameMs               int     `json:"frame_ms"`                // analysis frame length
+	EnergyThreshold       float64 `json:"energy_threshold"`        // frame rms (0..1) considered voiced
+	ZeroCrossingThreshold float64 `json:"zero_crossing_threshold"` // zcr (0..1) for unvoiced speech (s, f, sh), 0 to disable
+	HangoverMs            int     `json:"hangover_ms"`             // trailing silence before an utterance is complete
+	MinUtteranceMs        int     `json:"min_utterance_ms"`        // shorter utterances are dropped as noise
+	MaxUtteranceMs        int     `json:"max_utterance_ms"`        // longer utterances are force flushed
+}
+
+func (c VadConfig) withDefaults() VadConfig {
+	if c.FrameMs <= 0 {
+		c.FrameMs = 30
+	}
+	if c.EnergyThreshold <= 0 {
+		c.EnergyThreshold = 0.01
+	}
+	if c.HangoverMs <= 0 {
+		c.HangoverMs = 500
+	}
+	if c.MinUtteranceMs <= 0 {
+		c.MinUtteranceMs = 300
+	}
+	if c.MaxUtteranceMs <= 0 {
+		c.MaxUtteranceMs = 10000
+	}
+	return c
+}
+
+// vadUtterance is a complete utterance, start is relative to the first byte written to the vad
+type VadUtterance struct {
+	Audio []byte
+	Start time.Duration
+}
+
+// vad splits a pcm16 (16 kHz mono, little endian) stream into utterances
+// not thread safe, use one per session
+type Vad struct {
+	cfg        VadConfig
+	frameBytes int
+	pending    []byte // partial frame waiting for more data
+	pos        int    // bytes consumed by frames so far
+	utterance  []byte
+	start      int // stream position of the utterance first byte
+	inSpeech   bool
+	voicedMs   int // utterance length up to the last speech frame
+	silenceMs  int // trailing silence inside the utterance
+}
+
+func NewVad(cfg VadConfig) *Vad {
+	cfg = cfg.withDefaults()
+	return &Vad{
+		cfg:        cfg,
+		frameBytes: WhisperSampleRate * cfg.FrameMs / 1000 * 2,
+	}
+}
+
+// write feeds pcm16 bytes and returns every utterance completed by them
+func (v *Vad) Write(data []byte) []VadUtterance {
+	var done []VadUtterance
+
+	v.pending = append(v.pending, data...)
+	for len(v.pending) >= v.frameBytes {
+		frame := v.pending[:v.frameBytes]
+		if utterance := v.frame(frame); utterance != nil {
+			done = append(done, *utterance)
+		}
+		v.pending = v.pending[v.frameBytes:]
+		v.pos += v.frameBytes
+	}
+
+	// keep the partial frame in its own small slice, don't pin the big one
+	v.pending = append([]byte(nil), v.pending...)
+
+	return done
+}
+
+// flush returns the in-progress utterance if it is long enough, e.g. on stream end
+func (v *Vad) Flush() *VadUtterance {
+	if !v.inSpeech {
+		return nil
+	}
+	return v.end()
+}
+
+// inSpeech reports whether an utterance is in progress
+func (v *Vad) InSpeech() bool {
+	return v.inSpeech
+}
+
+func (v *Vad) frame(frame []byte) *VadUtterance {
+	speech := v.isSpeech(frame)
+
+	if !v.inSpeech {
+		if !speech {
+			return nil
+		}
+		v.inSpeech = true
+		v.utterance = v.utterance[:0]
+		v.start = v.pos
+		v.voicedMs = 0
+		v.silenceMs = 0
+	}
+
+	v.utterance = append(v.utterance, frame...)
+	if speech {
+		v.voicedMs += v.silenceMs + v.cfg.FrameMs
+		v.silenceMs = 0
+	} else {
+		v.silenceMs += v.cfg.FrameMs
+	}
+
+	if v.silenceMs >= v.cfg.HangoverMs || v.voicedMs+v.silenceMs >= v.cfg.MaxUtteranceMs {
+		return v.end()
+	}
+	return nil
+}
+
+func (v *Vad) end() *VadUtterance {
+	v.inSpeech = false
+	if v.voicedMs < v.cfg.MinUtteranceMs {
+		return nil
+	}
+	audio := make([]byte, len(v.utterance))
+	copy(audio, v.utterance)
+	return &VadUtterance{
+		Audio: audio,
+		Start: SamplesDuration(v.start / 2),
+	}
+}
+
+// isSpeech classifies a frame:
+// - voiced speech carries energy
+// - unvoiced speech is quieter but crosses zero often
+func (v *Vad) isSpeech(frame []byte) bool {
+	n := len(frame) / 2
+	if n == 0 {
+		return false
+	}
+
+	var sumSquares float64
+	var crossings int
+	var prev int16
+	for i := 0; i < n; i++ {
+		sample := int16(frame[i*2]) | int16(frame[i*2+1])<<8
+		s := float64(sample) / 32768.0
+		sumSquares += s * s
+		if i > 0 && (sample >= 0) != (prev >= 0) {
+			crossings++
+		}
+		prev = sample
+	}
+
+	rms := math.Sqrt(sumSquares / float64(n))
+	if rms >= v.cfg.EnergyThreshold {
+		return true
+	}
+
+	if v.cfg.ZeroCrossingThreshold > 0 && n > 1 {
+		zcr := float64(crossings) / float64(n-1)
+		return rms >= v.cfg.EnergyThreshold/2 && zcr >= v.cfg.ZeroCrossingThreshold
+	}
+
+	return false
+}
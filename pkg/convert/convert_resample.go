@@ -0,0 +1,179 @@
+package pkg_convert
+
+import (
+	"fmt"
+	"math"
+)
+
+// resampling filter: windowed sinc, kaiser window
+const (
+	zeroCrossings = 16   // per side of the kernel, at the filter cutoff
+	rolloff       = 0.94 // cutoff below nyquist of the lower rate, leaves room for the transition band
+	kaiserBeta    = 8.6  // ~80 db stopband
+	maxPhases     = 640  // enough for every standard rate (11025 -> 16000 is 640/441), odd rates interpolate between phases
+)
+
+// resampler converts a mono stream between two rates with a polyphase windowed-sinc filter
+// the ratio is reduced to up/down (44100 -> 16000 is 160/441), one filter phase per output position
+// a prime rate would need up to 16000 phases, beyond maxPhases the taps are interpolated between the table phases
+// it keeps the filter history between Process calls, one resampler per stream, not thread safe
+type Resampler struct {
+	inRate, outRate int
+	up, down        int         // out/in = up/down
+	half            int         // taps on each side of the center
+	phases          [][]float32 // min(up, maxPhases)+1 phases of 2*half+1 taps, the last one is the next sample
+	taps            []float32   // interpolated phase, when up > maxPhases
+
+	buf   []float32 // input history, buf[next] is the sample at the next output position
+	next  int
+	phase int // fractional position of the next output, in 1/up input samples
+}
+
+func NewResampler(inRate, outRate int) (*Resampler, error) {
+	if inRate <= 0 || outRate <= 0 {
+		return nil, fmt.Errorf("invalid resampling %d -> %d hz", inRate, outRate)
+	}
+
+	g := gcd(inRate, outRate)
+	r := &Resampler{inRate: inRate, outRate: outRate, up: outRate / g, down: inRate / g}
+	if r.up == r.down {
+		return r, nil
+	}
+
+	// cutoff relative to the input rate, 1 = input nyquist
+	cutoff := rolloff * math.Min(1, float64(r.up)/float64(r.down))
+	width := float64(zeroCrossings) / cutoff
+	r.half = int(math.Ceil(width))
+
+	table := min(r.up, maxPhases)
+	r.phases = make([][]float32, table+1)
+	for p := range r.phases {
+		frac := float64(p) / float64(table)
+		taps := make([]float64, 2*r.half+1)
+		sum := 0.0
+		for k := range taps {
+			t := float64(k-r.half) - frac
+			taps[k] = cutoff * sinc(cutoff*t) * kaiser(t/width)
+			sum += taps[k]
+		}
+		// unity gain on dc for every phase
+		phase := make([]float32, len(taps))
+		for k := range taps {
+			phase[k] = float32(taps[k] / sum)
+		}
+		r.phases[p] = phase
+	}
+	if r.up > maxPhases {
+		r.taps = make([]float32, 2*r.half+1)
+	}
+
+	r.Reset()
+	return r, nil
+}
+
+// reset forgets the stream history
+func (r *Resampler) Reset() {
+	r.buf = make([]float32, r.half, r.half+4096)
+	r.next = r.half
+	r.phase = 0
+}
+
+// ratio returns output samples per input sample
+func (r *Resampler) Ratio() float64 {
+	return float64(r.outRate) / float64(r.inRate)
+}
+
+// process resamples the next samples of the stream
+// the output lags the input by half the kernel, Flush returns the rest at the end of the stream
+func (r *Resampler) Process(in []float32) []float32 {
+	if r.up == r.down {
+		return append([]float32(nil), in...)
+	}
+
+	r.buf = append(r.buf, in...)
+	out := make([]float32, 0, int(float64(len(in))*r.Ratio())+1)
+
+	for r.next+r.half < len(r.buf) {
+		window := r.buf[r.next-r.half : r.next+r.half+1]
+		taps := r.phaseTaps()
+		var acc float32
+		for k, tap := range taps {
+			acc += window[k] * tap
+		}
+		out = append(out, acc)
+
+		r.phase += r.down
+		r.next += r.phase / r.up
+		r.phase %= r.up
+	}
+
+	// keep the left context of the next output
+	if drop := r.next - r.half; drop > 0 {
+		if drop > len(r.buf) {
+			drop = len(r.buf)
+		}
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.next -= drop
+	}
+
+	return out
+}
+
+// phaseTaps returns the filter of the current phase
+func (r *Resampler) phaseTaps() []float32 {
+	if r.taps == nil {
+		return r.phases[r.phase]
+	}
+	pos := r.phase * maxPhases
+	i, frac := pos/r.up, float32(pos%r.up)/float32(r.up)
+	a, b := r.phases[i], r.phases[i+1]
+	for k := range r.taps {
+		r.taps[k] = a[k] + (b[k]-a[k])*frac
+	}
+	return r.taps
+}
+
+// flush pads the stream end with silence & returns the remaining output
+func (r *Resampler) Flush() []float32 {
+	if r.up == r.down {
+		return nil
+	}
+	out := r.Process(make([]float32, r.half))
+	r.Reset()
+	return out
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser window over [-1, 1]
+func kaiser(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return bessel0(kaiserBeta*math.Sqrt(1-x*x)) / bessel0(kaiserBeta)
+}
+
+// bessel0 is the zeroth order modified bessel function of the first kind
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
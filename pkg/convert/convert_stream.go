@@ -0,0 +1,82 @@
+package pkg_convert
+
+import (
+	"fmt"
+)
+
+// accepted input formats
+const (
+	MinSampleRate = 8000
+	MaxSampleRate = 192000
+	MaxChannels   = 8
+)
+
+// format is the layout of an interleaved stream of samples
+type Format struct {
+	SampleRate int
+	Channels   int
+}
+
+func (f Format) Validate() error {
+	if f.SampleRate < MinSampleRate || f.SampleRate > MaxSampleRate {
+		return fmt.Errorf("unsupported sample rate %d, expected %d to %d hz", f.SampleRate, MinSampleRate, MaxSampleRate)
+	}
+	if f.Channels < 1 || f.Channels > MaxChannels {
+		return fmt.Errorf("unsupported channels %d, expected 1 to %d", f.Channels, MaxChannels)
+	}
+	return nil
+}
+
+// downmix averages interleaved frames into mono, a partial trailing frame is dropped
+func Downmix(samples []float32, channels int) []float32 {
+	if channels <= 1 {
+		return samples
+	}
+	out := make([]float32, len(samples)/channels)
+	scale := 1 / float32(channels)
+	for i := range out {
+		var sum float32
+		for _, s := range samples[i*channels : (i+1)*channels] {
+			sum += s
+		}
+		out[i] = sum * scale
+	}
+	return out
+}
+
+// converter turns an interleaved stream of the declared format into mono at the target rate
+// it keeps the resampler state between chunks, one converter per stream, not thread safe
+type Converter struct {
+	in        Format
+	outRate   int
+	resampler *Resampler
+}
+
+func NewConverter(in Format, outRate int) (*Converter, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	resampler, err := NewResampler(in.SampleRate, outRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{in: in, outRate: outRate, resampler: resampler}, nil
+}
+
+// passthrough reports if the input is already mono at the target rate
+func (c *Converter) Passthrough() bool {
+	return c.in.Channels == 1 && c.in.SampleRate == c.outRate
+}
+
+// convert downmixes & resamples the next whole frames of the stream
+func (c *Converter) Convert(samples []float32) []float32 {
+	if c.Passthrough() {
+		return samples
+	}
+	return c.resampler.Process(Downmix(samples, c.in.Channels))
+}
+
+// flush returns the output still held by the resampler at the end of the stream
+func (c *Converter) Flush() []float32 {
+	return c.resampler.Flush()
+}
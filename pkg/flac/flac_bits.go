@@ -0,0 +1,73 @@
+package pkg_flac
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// errNeedMore means the buffer ends inside a header or frame, decoding resumes with more data
+var errNeedMore = errors.New("flac: need more data")
+
+// bitReader reads msb first bit fields out of a byte buffer
+type bitReader struct {
+	buf []byte
+	pos int // in bits
+}
+
+// bits reads an unsigned field of n <= 64 bits
+func (b *bitReader) bits(n uint) (uint64, error) {
+	if b.pos+int(n) > len(b.buf)*8 {
+		return 0, errNeedMore
+	}
+	var v uint64
+	for n > 0 {
+		avail := 8 - uint(b.pos&7)
+		take := min(avail, n)
+		chunk := uint64(b.buf[b.pos>>3]>>(avail-take)) & (1<<take - 1)
+		v = v<<take | chunk
+		n -= take
+		b.pos += int(take)
+	}
+	return v, nil
+}
+
+// signed reads a two's complement field of n bits
+func (b *bitReader) signed(n uint) (int64, error) {
+	v, err := b.bits(n)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	shift := 64 - n
+	return int64(v<<shift) >> shift, nil
+}
+
+// unary counts the zero bits before the next one bit
+func (b *bitReader) unary() (uint64, error) {
+	var n uint64
+	for {
+		if b.pos >= len(b.buf)*8 {
+			return 0, errNeedMore
+		}
+		off := b.pos & 7
+		rest := b.buf[b.pos>>3] << off
+		if rest == 0 {
+			n += uint64(8 - off)
+			b.pos += 8 - off
+			continue
+		}
+		zeros := bits.LeadingZeros8(rest)
+		n += uint64(zeros)
+		b.pos += zeros + 1
+		return n, nil
+	}
+}
+
+// align skips the padding up to the next byte
+func (b *bitReader) align() {
+	b.pos = (b.pos + 7) &^ 7
+}
+
+// offset returns the bytes read, the reader must be aligned
+func (b *bitReader) offset() int {
+	return b.pos >> 3
+}
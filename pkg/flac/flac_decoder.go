@@ -0,0 +1,186 @@
+package pkg_flac
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// frames are at most 8 channels * 65535 samples * 4 bytes, beyond that the buffer can't hold a frame start
+const maxFrameSize = 8*65535*4 + 64
+
+// decoder decodes a flac stream pushed in chunks of any size, i.e. the data of a grpc stream
+// chunks may split headers & frames, the incomplete part waits for the next chunk
+// one per stream, not thread safe
+type Decoder struct {
+	info    StreamInfo
+	hasInfo bool
+
+	buf     []byte
+	md5     hash.Hash
+	samples int64 // decoded per channel
+	corrupt int   // frames skipped, the md5 can't match anymore
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{md5: md5.New()}
+}
+
+// info returns the stream info, false until the header was decoded
+func (d *Decoder) Info() (StreamInfo, bool) {
+	return d.info, d.hasInfo
+}
+
+// decode returns the interleaved samples of the frames completed by p, in [-1, 1)
+// a corrupt frame is reported & skipped, decoding goes on from the next frame sync code
+func (d *Decoder) Decode(p []byte) ([]float32, error) {
+	d.buf = append(d.buf, p...)
+
+	if !d.hasInfo {
+		info, n, err := parseHeader(d.buf)
+		if err == errNeedMore {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.info, d.hasInfo = info, true
+		d.buf = d.buf[n:]
+	}
+
+	var out []float32
+	var errs []error
+	for len(d.buf) > 0 {
+		f, n, err := decodeFrame(d.buf, d.info)
+		if err == errNeedMore {
+			if len(d.buf) <= d.frameLimit() {
+				break
+			}
+			err = fmt.Errorf("flac frame larger than %d bytes", d.frameLimit())
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("flac frame after sample %d: %w", d.samples, err))
+			d.corrupt++
+			d.resync()
+			continue
+		}
+		out = d.appendFrame(out, f)
+		d.buf = d.buf[n:]
+	}
+
+	// drop the consumed prefix
+	d.buf = append([]byte(nil), d.buf...)
+	return out, errors.Join(errs...)
+}
+
+// frameLimit is the largest frame a buffer may still complete
+func (d *Decoder) frameLimit() int {
+	if d.info.MaxFrameSize > 0 {
+		return d.info.MaxFrameSize
+	}
+	return maxFrameSize
+}
+
+// resync drops bytes up to the next frame sync code (0xFFF8 fixed, 0xFFF9 variable blocking)
+func (d *Decoder) resync() {
+	for i := 1; i+1 < len(d.buf); i++ {
+		if d.buf[i] == 0xFF && d.buf[i+1]&0xFE == 0xF8 {
+			d.buf = d.buf[i:]
+			return
+		}
+	}
+	// keep a trailing 0xFF, it may start the next sync code
+	if n := len(d.buf); n > 1 && d.buf[n-1] == 0xFF {
+		d.buf = d.buf[n-1:]
+		return
+	}
+	d.buf = nil
+}
+
+// appendFrame interleaves the frame samples as floats & hashes them as the encoder did
+func (d *Decoder) appendFrame(out []float32, f frame) []float32 {
+	scale := 1 / float64(int64(1)<<(f.bitsPerSample-1))
+	sampleBytes := (f.bitsPerSample + 7) / 8
+	raw := make([]byte, 0, len(f.channels[0])*len(f.channels)*sampleBytes)
+
+	for i := range f.channels[0] {
+		for _, ch := range f.channels {
+			v := ch[i]
+			out = append(out, float32(float64(v)*scale))
+			for b := range sampleBytes {
+				raw = append(raw, byte(v>>(8*b)))
+			}
+		}
+	}
+	d.md5.Write(raw)
+	d.samples += int64(len(f.channels[0]))
+	return out
+}
+
+// close ends the stream, it verifies the sample count & the md5 signature of the stream info
+func (d *Decoder) Close() error {
+	if !d.hasInfo {
+		return fmt.Errorf("flac stream ended before its stream info")
+	}
+	if len(d.buf) > 0 {
+		return fmt.Errorf("flac stream truncated: %d bytes of an incomplete frame", len(d.buf))
+	}
+	if d.corrupt > 0 {
+		return fmt.Errorf("flac stream had %d corrupt frames, md5 not verified", d.corrupt)
+	}
+	if d.info.TotalSamples > 0 && d.samples != d.info.TotalSamples {
+		return fmt.Errorf("flac stream decoded %d of %d samples", d.samples, d.info.TotalSamples)
+	}
+	if d.info.MD5 == [16]byte{} {
+		return nil
+	}
+	if sum := d.md5.Sum(nil); !bytes.Equal(sum, d.info.MD5[:]) {
+		return fmt.Errorf("flac md5 mismatch: stream info %x, decoded %x", d.info.MD5, sum)
+	}
+	return nil
+}
+
+// decodeAll decodes a whole flac file, the md5 signature is verified
+func DecodeAll(r io.Reader) ([]float32, StreamInfo, error) {
+	d := NewDecoder()
+	var out []float32
+	chunk := make([]byte, 64<<10)
+	for {
+		n, err := r.Read(chunk)
+		samples, decodeErr := d.Decode(chunk[:n])
+		out = append(out, samples...)
+		if decodeErr != nil {
+			return out, d.info, decodeErr
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, d.info, err
+		}
+	}
+	return out, d.info, d.Close()
+}
+
+// readStreamInfo reads the header of a flac stream up to the first frame
+func ReadStreamInfo(r io.Reader) (StreamInfo, error) {
+	var buf []byte
+	chunk := make([]byte, 4096)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		info, _, parseErr := parseHeader(buf)
+		if parseErr != errNeedMore {
+			return info, parseErr
+		}
+		if err == io.EOF {
+			return info, fmt.Errorf("flac stream ended before its stream info: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return info, err
+		}
+	}
+}
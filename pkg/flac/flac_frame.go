@@ -0,0 +1,218 @@
+package pkg_flac
+
+import (
+	"fmt"
+)
+
+// frame sync code, 14 bits & the reserved zero bit
+const frameSync = 0x7FFC
+
+// channel assignments above the independent ones (0-7, channels - 1)
+const (
+	channelsLeftSide  = 8
+	channelsSideRight = 9
+	channelsMidSide   = 10
+)
+
+// frame sample rates by header code, 0 is the stream info rate, 12-14 are read after the header
+var frameSampleRates = [12]int{0, 88200, 176400, 192000, 8000, 16000, 22050, 24000, 32000, 44100, 48000, 96000}
+
+// frame sample sizes by header code, 0 is the stream info size, 3 is reserved
+var frameSampleSizes = [8]int{0, 8, 12, 0, 16, 20, 24, 32}
+
+// frame is one decoded block of every channel
+type frame struct {
+	bitsPerSample int
+	channels      [][]int64 // decorrelated samples per channel
+}
+
+// decodeFrame decodes the frame at the start of buf, n is its size in bytes
+// errNeedMore when buf ends inside the frame
+func decodeFrame(buf []byte, info StreamInfo) (f frame, n int, err error) {
+	br := &bitReader{buf: buf}
+
+	sync, err := br.bits(15)
+	if err != nil {
+		return f, 0, err
+	}
+	if sync != frameSync {
+		return f, 0, fmt.Errorf("flac frame sync code not found")
+	}
+	// fixed or variable blocking strategy, the coded number is a frame or sample number, not needed here
+	if _, err = br.bits(1); err != nil {
+		return f, 0, err
+	}
+	codes, err := br.bits(16)
+	if err != nil {
+		return f, 0, err
+	}
+	blockSizeCode := codes >> 12
+	rateCode := codes >> 8 & 0x0F
+	assignment := int(codes >> 4 & 0x0F)
+	sizeCode := codes >> 1 & 0x07
+	if codes&1 != 0 {
+		return f, 0, fmt.Errorf("flac frame header reserved bit set")
+	}
+	if err := skipCodedNumber(br); err != nil {
+		return f, 0, err
+	}
+
+	blockSize := 0
+	switch {
+	case blockSizeCode == 0:
+		return f, 0, fmt.Errorf("flac frame with reserved block size code 0")
+	case blockSizeCode == 1:
+		blockSize = 192
+	case blockSizeCode <= 5:
+		blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		v, err := br.bits(8)
+		if err != nil {
+			return f, 0, err
+		}
+		blockSize = int(v) + 1
+	case blockSizeCode == 7:
+		v, err := br.bits(16)
+		if err != nil {
+			return f, 0, err
+		}
+		blockSize = int(v) + 1
+	default:
+		blockSize = 256 << (blockSizeCode - 8)
+	}
+
+	// every frame must keep the stream rate, the samples are resampled with it
+	rate := 0
+	switch rateCode {
+	case 12, 13, 14:
+		// khz in 8 bits, hz or tens of hz in 16 bits
+		n, unit := uint(16), 1
+		switch rateCode {
+		case 12:
+			n, unit = 8, 1000
+		case 14:
+			unit = 10
+		}
+		v, err := br.bits(n)
+		if err != nil {
+			return f, 0, err
+		}
+		rate = int(v) * unit
+	case 15:
+		return f, 0, fmt.Errorf("flac frame with invalid sample rate code 15")
+	default:
+		rate = frameSampleRates[rateCode]
+	}
+	if rate != 0 && rate != info.SampleRate {
+		return f, 0, fmt.Errorf("flac frame at %d hz, stream info %d hz", rate, info.SampleRate)
+	}
+
+	headerEnd := br.offset()
+	crc, err := br.bits(8)
+	if err != nil {
+		return f, 0, err
+	}
+	if want := crc8(buf[:headerEnd]); uint8(crc) != want {
+		return f, 0, fmt.Errorf("flac frame header crc 0x%02X, computed 0x%02X", crc, want)
+	}
+
+	channels := assignment + 1
+	if assignment >= channelsLeftSide {
+		if assignment > channelsMidSide {
+			return f, 0, fmt.Errorf("flac frame with reserved channel assignment %d", assignment)
+		}
+		channels = 2
+	}
+	if channels != info.Channels {
+		return f, 0, fmt.Errorf("flac frame has %d channels, stream info %d", channels, info.Channels)
+	}
+
+	bps := frameSampleSizes[sizeCode]
+	switch {
+	case sizeCode == 0:
+		bps = info.BitsPerSample
+	case sizeCode == 3:
+		return f, 0, fmt.Errorf("flac frame with reserved sample size code 3")
+	case bps != info.BitsPerSample:
+		return f, 0, fmt.Errorf("flac frame has %d bits per sample, stream info %d", bps, info.BitsPerSample)
+	}
+
+	f = frame{bitsPerSample: bps, channels: make([][]int64, channels)}
+	for ch := range f.channels {
+		// the side channel needs one more bit
+		chBps := bps
+		if (assignment == channelsLeftSide || assignment == channelsMidSide) && ch == 1 ||
+			assignment == channelsSideRight && ch == 0 {
+			chBps++
+		}
+		if f.channels[ch], err = decodeSubframe(br, blockSize, uint(chBps)); err != nil {
+			if err == errNeedMore {
+				return f, 0, err
+			}
+			return f, 0, fmt.Errorf("flac subframe %d: %w", ch, err)
+		}
+	}
+
+	br.align()
+	frameEnd := br.offset()
+	footer, err := br.bits(16)
+	if err != nil {
+		return f, 0, err
+	}
+	if want := crc16(buf[:frameEnd]); uint16(footer) != want {
+		return f, 0, fmt.Errorf("flac frame crc 0x%04X, computed 0x%04X", footer, want)
+	}
+
+	decorrelate(assignment, f.channels)
+	return f, br.offset(), nil
+}
+
+// skipCodedNumber skips the utf-8 like coded frame or sample number, up to 7 bytes
+func skipCodedNumber(br *bitReader) error {
+	first, err := br.bits(8)
+	if err != nil {
+		return err
+	}
+	extra := 0
+	for mask := uint64(0x80); first&mask != 0; mask >>= 1 {
+		extra++
+	}
+	switch {
+	case extra == 1 || extra == 8:
+		return fmt.Errorf("flac frame with invalid coded number 0x%02X", first)
+	case extra > 1:
+		extra--
+	}
+	for range extra {
+		b, err := br.bits(8)
+		if err != nil {
+			return err
+		}
+		if b&0xC0 != 0x80 {
+			return fmt.Errorf("flac frame with invalid coded number continuation 0x%02X", b)
+		}
+	}
+	return nil
+}
+
+// decorrelate restores left & right from the stereo side channel
+func decorrelate(assignment int, channels [][]int64) {
+	switch assignment {
+	case channelsLeftSide:
+		left, side := channels[0], channels[1]
+		for i := range side {
+			side[i] = left[i] - side[i]
+		}
+	case channelsSideRight:
+		side, right := channels[0], channels[1]
+		for i := range side {
+			side[i] += right[i]
+		}
+	case channelsMidSide:
+		mid, side := channels[0], channels[1]
+		for i := range mid {
+			m := mid[i]<<1 | side[i]&1
+			mid[i], side[i] = (m+side[i])>>1, (m-side[i])>>1
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package pkg_flac
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// metadata block types
+const (
+	blockStreamInfo = 0
+	blockInvalid    = 127
+)
+
+const streamInfoSize = 34
+
+// the header is buffered until complete, a client could declare gigabytes of it
+// cover art fits, the block count bounds the parsing of every pushed chunk
+const (
+	maxID3Size        = 8 << 20
+	maxMetadataSize   = 8 << 20
+	maxMetadataBlocks = 256
+)
+
+// streamInfo is the STREAMINFO metadata block, the format of the whole stream
+type StreamInfo struct {
+	MinBlockSize, MaxBlockSize int // in samples per channel
+	MinFrameSize, MaxFrameSize int // in bytes, 0 when unknown
+	SampleRate                 int
+	Channels                   int
+	BitsPerSample              int
+	TotalSamples               int64    // per channel, 0 when unknown
+	MD5                        [16]byte // of the decoded samples, all zero when unknown
+}
+
+// duration returns the playback duration, 0 when unknown
+func (s StreamInfo) Duration() time.Duration {
+	return time.Duration(s.TotalSamples) * time.Second / time.Duration(s.SampleRate)
+}
+
+func parseStreamInfo(b []byte) (StreamInfo, error) {
+	info := StreamInfo{
+		MinBlockSize: int(binary.BigEndian.Uint16(b[0:])),
+		MaxBlockSize: int(binary.BigEndian.Uint16(b[2:])),
+		MinFrameSize: int(b[4])<<16 | int(b[5])<<8 | int(b[6]),
+		MaxFrameSize: int(b[7])<<16 | int(b[8])<<8 | int(b[9]),
+	}
+	// 20 bits rate, 3 bits channels - 1, 5 bits bits per sample - 1, 36 bits total samples
+	packed := binary.BigEndian.Uint64(b[10:])
+	info.SampleRate = int(packed >> 44)
+	info.Channels = int(packed>>41&0x07) + 1
+	info.BitsPerSample = int(packed>>36&0x1F) + 1
+	info.TotalSamples = int64(packed & (1<<36 - 1))
+	copy(info.MD5[:], b[18:34])
+
+	if info.SampleRate == 0 {
+		return info, fmt.Errorf("flac stream info declares a 0 hz sample rate")
+	}
+	if info.BitsPerSample < 4 {
+		return info, fmt.Errorf("flac stream info declares %d bits per sample, expected 4 to 32", info.BitsPerSample)
+	}
+	if info.MaxBlockSize < info.MinBlockSize {
+		return info, fmt.Errorf("flac stream info block sizes %d > %d", info.MinBlockSize, info.MaxBlockSize)
+	}
+	return info, nil
+}
+
+// parseHeader reads the stream marker & the metadata blocks, n is the offset of the first frame
+// an id3v2 tag before the marker is skipped, metadata blocks other than STREAMINFO are ignored
+func parseHeader(buf []byte) (info StreamInfo, n int, err error) {
+	if len(buf) >= 10 && string(buf[0:3]) == "ID3" {
+		// syncsafe size, 7 bits per byte, plus an optional footer
+		size := int(buf[6]&0x7F)<<21 | int(buf[7]&0x7F)<<14 | int(buf[8]&0x7F)<<7 | int(buf[9]&0x7F)
+		if size > maxID3Size {
+			return info, 0, fmt.Errorf("id3 tag of %d bytes before the flac stream, expected at most %d", size, maxID3Size)
+		}
+		n = 10 + size
+		if buf[5]&0x10 != 0 {
+			n += 10
+		}
+	}
+
+	if len(buf) < n+4 {
+		return info, 0, errNeedMore
+	}
+	if string(buf[n:n+4]) != "fLaC" {
+		return info, 0, fmt.Errorf("not a flac stream: expected fLaC, got %q", buf[n:n+4])
+	}
+	n += 4
+	metadata := n
+
+	for block := 0; ; block++ {
+		first := block == 0
+		if block == maxMetadataBlocks {
+			return info, 0, fmt.Errorf("flac stream of more than %d metadata blocks", maxMetadataBlocks)
+		}
+		if len(buf) < n+4 {
+			return info, 0, errNeedMore
+		}
+		last := buf[n]&0x80 != 0
+		kind := buf[n] & 0x7F
+		size := int(buf[n+1])<<16 | int(buf[n+2])<<8 | int(buf[n+3])
+		n += 4
+
+		switch {
+		case first && kind != blockStreamInfo:
+			return info, 0, fmt.Errorf("flac stream starts with metadata block %d, expected STREAMINFO", kind)
+		case kind == blockInvalid:
+			return info, 0, fmt.Errorf("invalid flac metadata block type %d", kind)
+		case kind == blockStreamInfo && size != streamInfoSize:
+			return info, 0, fmt.Errorf("flac STREAMINFO of %d bytes, expected %d", size, streamInfoSize)
+		case n+size-metadata > maxMetadataSize:
+			return info, 0, fmt.Errorf("flac metadata of more than %d bytes", maxMetadataSize)
+		}
+		if len(buf) < n+size {
+			return info, 0, errNeedMore
+		}
+		if first {
+			if info, err = parseStreamInfo(buf[n : n+size]); err != nil {
+				return info, 0, err
+			}
+		}
+		n += size
+
+		if last {
+			return info, n, nil
+		}
+	}
+}
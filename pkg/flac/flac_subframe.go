@@ -0,0 +1,208 @@
+package pkg_flac
+
+import (
+	"errors"
+	"fmt"
+)
+
+// subframe types, fixed & lpc carry the predictor order in the low bits
+const (
+	subframeConstant = 0x00
+	subframeVerbatim = 0x01
+	subframeFixed    = 0x08 // 0b001xxx, order 0 to 4
+	subframeLPC      = 0x20 // 0b1xxxxx, order 1 to 32
+)
+
+// decodeSubframe decodes the samples of one channel, bps includes the extra side channel bit
+func decodeSubframe(br *bitReader, blockSize int, bps uint) ([]int64, error) {
+	header, err := br.bits(8)
+	if err != nil {
+		return nil, err
+	}
+	if header&0x80 != 0 {
+		return nil, errors.New("subframe padding bit set")
+	}
+	kind := header >> 1 & 0x3F
+
+	// wasted bits: low zero bits shared by every sample, unary coded
+	wasted := uint(0)
+	if header&1 != 0 {
+		k, err := br.unary()
+		if err != nil {
+			return nil, err
+		}
+		wasted = uint(k) + 1
+		if wasted >= bps {
+			return nil, fmt.Errorf("%d wasted bits of %d bits per sample", wasted, bps)
+		}
+		bps -= wasted
+	}
+
+	samples := make([]int64, blockSize)
+	switch {
+	case kind == subframeConstant:
+		v, err := br.signed(bps)
+		if err != nil {
+			return nil, err
+		}
+		for i := range samples {
+			samples[i] = v
+		}
+
+	case kind == subframeVerbatim:
+		for i := range samples {
+			if samples[i], err = br.signed(bps); err != nil {
+				return nil, err
+			}
+		}
+
+	case kind&0x38 == subframeFixed && kind&0x07 <= 4:
+		order := int(kind & 0x07)
+		if err := decodeWarmup(br, samples, order, bps); err != nil {
+			return nil, err
+		}
+		if err := decodeResidual(br, samples, order); err != nil {
+			return nil, err
+		}
+		restoreFixed(samples, order)
+
+	case kind&subframeLPC != 0:
+		order := int(kind&0x1F) + 1
+		if err := decodeWarmup(br, samples, order, bps); err != nil {
+			return nil, err
+		}
+		precision, err := br.bits(4)
+		if err != nil {
+			return nil, err
+		}
+		if precision == 0x0F {
+			return nil, errors.New("invalid lpc coefficient precision")
+		}
+		shift, err := br.signed(5)
+		if err != nil {
+			return nil, err
+		}
+		if shift < 0 {
+			return nil, fmt.Errorf("negative lpc shift %d", shift)
+		}
+		coefs := make([]int64, order)
+		for i := range coefs {
+			if coefs[i], err = br.signed(uint(precision) + 1); err != nil {
+				return nil, err
+			}
+		}
+		if err := decodeResidual(br, samples, order); err != nil {
+			return nil, err
+		}
+		restoreLPC(samples, coefs, uint(shift))
+
+	default:
+		return nil, fmt.Errorf("reserved subframe type 0x%02X", kind)
+	}
+
+	if wasted > 0 {
+		for i := range samples {
+			samples[i] <<= wasted
+		}
+	}
+	return samples, nil
+}
+
+func decodeWarmup(br *bitReader, samples []int64, order int, bps uint) error {
+	if order > len(samples) {
+		return fmt.Errorf("predictor order %d above the block size %d", order, len(samples))
+	}
+	var err error
+	for i := range order {
+		if samples[i], err = br.signed(bps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeResidual reads the rice coded prediction errors after the warmup samples
+func decodeResidual(br *bitReader, samples []int64, order int) error {
+	method, err := br.bits(2)
+	if err != nil {
+		return err
+	}
+	if method > 1 {
+		return fmt.Errorf("reserved residual coding method %d", method)
+	}
+	paramBits := uint(4 + method)
+	escape := uint64(1)<<paramBits - 1
+
+	partitionOrder, err := br.bits(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partitionOrder
+	if len(samples)%partitions != 0 || len(samples)/partitions < order {
+		return fmt.Errorf("partition order %d doesn't fit a block of %d with order %d", partitionOrder, len(samples), order)
+	}
+
+	i := order
+	for p := range partitions {
+		end := (p + 1) * len(samples) / partitions
+		param, err := br.bits(paramBits)
+		if err != nil {
+			return err
+		}
+
+		if param == escape {
+			// unencoded partition, fixed size signed samples
+			n, err := br.bits(5)
+			if err != nil {
+				return err
+			}
+			for ; i < end; i++ {
+				if samples[i], err = br.signed(uint(n)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for ; i < end; i++ {
+			high, err := br.unary()
+			if err != nil {
+				return err
+			}
+			low, err := br.bits(uint(param))
+			if err != nil {
+				return err
+			}
+			u := high<<param | low
+			samples[i] = int64(u>>1) ^ -int64(u&1)
+		}
+	}
+	return nil
+}
+
+// restoreFixed adds the fixed polynomial predictions to the residual in place
+func restoreFixed(s []int64, order int) {
+	for i := order; i < len(s); i++ {
+		switch order {
+		case 1:
+			s[i] += s[i-1]
+		case 2:
+			s[i] += 2*s[i-1] - s[i-2]
+		case 3:
+			s[i] += 3*s[i-1] - 3*s[i-2] + s[i-3]
+		case 4:
+			s[i] += 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+		}
+	}
+}
+
+// restoreLPC adds the linear predictions to the residual in place, coefs[0] weights the previous sample
+func restoreLPC(s []int64, coefs []int64, shift uint) {
+	for i := len(coefs); i < len(s); i++ {
+		var sum int64
+		for j, c := range coefs {
+			sum += c * s[i-1-j]
+		}
+		s[i] += sum >> shift
+	}
+}
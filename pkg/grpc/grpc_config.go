@@ -3,6 +3,8 @@ package pkg_grpc
 import (
 	"encoding/json"
 	"os"
+
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
 )
 
 type GrpcConfig struct {
@@ -13,6 +15,12 @@ type GrpcConfig struct {
 	Processing struct {
 		AudioProcessing int `json:"audio_processing"` // in ms
 		TranscribeStreamChunkSize int `json:"transcribe_stream_chunk_size"`
+		Segmentation string `json:"segmentation"` // vad, sliding or ticker (fallback, default)
+		SlidingOverlap int `json:"sliding_overlap"` // in ms, trailing audio re-transcribed by the next window
+		TranscriptTailWords int `json:"transcript_tail_words"` // words of the previous transcript matched with the next one, default 16, negative disables
+		Vad pkg_audio.VadConfig `json:"vad"`
+		FileChunk int `json:"file_chunk"` // in ms, recordings of Transcribe are split in chunks of at most this, default 25000
+		MaxFileMB int `json:"max_file_mb"` // largest Transcribe request, default 64
 	} `json:"processing"`
 }
 
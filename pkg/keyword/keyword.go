@@ -0,0 +1,121 @@
+package pkg_keyword
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// matching modes
+const (
+	ModeWord      = "word"      // whole word or whole phrase on token boundaries (default)
+	ModeSubstring = "substring" // legacy, case-insensitive strings.Contains
+	ModeFuzzy     = "fuzzy"     // word mode tolerating misrecognitions, edit distance & optional phonetic
+	ModeRegex     = "regex"     // term is an RE2 regex, case-insensitive, named groups are reported as captures
+	ModeTemplate  = "template"  // term is a word template with placeholders, e.g. "send {amount:number} dollars"
+)
+
+// keyword is a forbidden term with its matching options
+// in json it's either a plain string (word mode) or an object
+type Keyword struct {
+	Term string `json:"term"`
+	Name string `json:"name"` // reported instead of the term if set, e.g. to label a regex
+	Mode string `json:"mode"` // word (default), substring, fuzzy, regex or template
+	Stem bool   `json:"stem"` // also match plural & inflected forms, word mode only
+	// fuzzy mode only
+	Distance int  `json:"distance"` // max edit distance over the whole term, 0 scales with the term length
+	Phonetic bool `json:"phonetic"` // also match words that sound the same (double metaphone)
+	// policy inputs
+	Category string   `json:"category"` // free form, e.g. scam, finance, profanity
+	Severity Severity `json:"severity"` // low, medium (default), high or critical
+}
+
+func (k *Keyword) UnmarshalJSON(data []byte) error {
+	var term string
+	if err := json.Unmarshal(data, &term); err == nil {
+		*k = Keyword{Term: term, Mode: ModeWord}
+		return nil
+	}
+
+	// alias drops the method, prevent recursion
+	type keyword Keyword
+	var kw keyword
+	if err := json.Unmarshal(data, &kw); err != nil {
+		return fmt.Errorf("keyword: expected string or object: %w", err)
+	}
+	*k = Keyword(kw)
+	if k.Mode == "" {
+		k.Mode = ModeWord
+	}
+	// fail the config load on a broken keyword, not the first transcript
+	return k.validate()
+}
+
+func (k Keyword) validate() error {
+	if k.Term == "" {
+		return fmt.Errorf("keyword: empty term")
+	}
+	if k.Distance < 0 {
+		return fmt.Errorf("keyword %q: negative distance", k.Term)
+	}
+	if (k.Distance > 0 || k.Phonetic) && k.Mode != ModeFuzzy {
+		return fmt.Errorf("keyword %q: distance & phonetic need fuzzy mode", k.Term)
+	}
+	if err := k.Severity.validate(); err != nil {
+		return fmt.Errorf("keyword %q: %w", k.Term, err)
+	}
+	switch k.Mode {
+	case "", ModeWord, ModeSubstring, ModeFuzzy:
+		return nil
+	case ModeRegex, ModeTemplate:
+		_, err := newPatternRule(k)
+		return err
+	default:
+		return fmt.Errorf("keyword %q: unknown mode %q", k.Term, k.Mode)
+	}
+}
+
+// words converts plain terms to word mode keywords
+func Words(terms ...string) []Keyword {
+	keywords := make([]Keyword, 0, len(terms))
+	for _, term := range terms {
+		keywords = append(keywords, Keyword{Term: term, Mode: ModeWord})
+	}
+	return keywords
+}
+
+// match is a keyword occurrence, offsets are unicode code points in the text (end exclusive)
+type Match struct {
+	Keyword string // canonical keyword term
+	Text    string // matched text as it appears in the transcript
+	Start   int
+	End     int
+	Score   float64 // 1 for exact hits, lower for approximate (fuzzy) ones
+	// copied from the keyword, action is set by the session policy
+	Category string
+	Severity Severity
+	Action   Action
+	// exception that cleared the match, informational matches only
+	SuppressedBy string
+	// named captures of regex & template keywords
+	Captures map[string]string
+}
+
+func (k Keyword) newMatch(text string, start, end int, score float64) Match {
+	severity := k.Severity
+	if severity == "" {
+		severity = SeverityMedium
+	}
+	term := k.Term
+	if k.Name != "" {
+		term = k.Name
+	}
+	return Match{
+		Keyword:  term,
+		Text:     text,
+		Start:    start,
+		End:      end,
+		Score:    score,
+		Category: k.Category,
+		Severity: severity,
+	}
+}
@@ -0,0 +1,93 @@
+package pkg_keyword
+
+// automaton is an aho-corasick multi-pattern matcher over any symbol type
+// words are matched over token sequences, substrings over runes
+// build once, then it's read-only and safe for concurrent use
+type automaton[T comparable] struct {
+	next    []map[T]int // goto function, node -> symbol -> node
+	fail    []int
+	outputs [][]int // pattern ids ending at node, including the ones reached by fail links
+	lengths []int   // pattern length in symbols, by pattern id
+}
+
+func newAutomaton[T comparable]() *automaton[T] {
+	return &automaton[T]{
+		next:    []map[T]int{{}},
+		fail:    []int{0},
+		outputs: [][]int{nil},
+	}
+}
+
+// add inserts a pattern & returns its id, call build after the last add
+func (a *automaton[T]) add(pattern []T) int {
+	node := 0
+	for _, symbol := range pattern {
+		child, ok := a.next[node][symbol]
+		if !ok {
+			child = len(a.next)
+			a.next = append(a.next, map[T]int{})
+			a.fail = append(a.fail, 0)
+			a.outputs = append(a.outputs, nil)
+			a.next[node][symbol] = child
+		}
+		node = child
+	}
+
+	id := len(a.lengths)
+	a.lengths = append(a.lengths, len(pattern))
+	a.outputs[node] = append(a.outputs[node], id)
+	return id
+}
+
+// build computes the fail links breadth first
+func (a *automaton[T]) build() {
+	queue := make([]int, 0, len(a.next))
+	for _, child := range a.next[0] {
+		a.fail[child] = 0
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for symbol, child := range a.next[node] {
+			queue = append(queue, child)
+
+			fail := a.fail[node]
+			for {
+				if target, ok := a.next[fail][symbol]; ok && target != child {
+					a.fail[child] = target
+					break
+				}
+				if fail == 0 {
+					a.fail[child] = 0
+					break
+				}
+				fail = a.fail[fail]
+			}
+			a.outputs[child] = append(a.outputs[child], a.outputs[a.fail[child]]...)
+		}
+	}
+}
+
+// search calls found for every pattern occurrence, end is the exclusive symbol index
+// time is linear in len(text) + number of occurrences, independent of the pattern count
+func (a *automaton[T]) search(text []T, found func(id, start, end int)) {
+	node := 0
+	for i, symbol := range text {
+		for {
+			if child, ok := a.next[node][symbol]; ok {
+				node = child
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = a.fail[node]
+		}
+		for _, id := range a.outputs[node] {
+			found(id, i+1-a.lengths[id], i+1)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// lists is the source of an engine snapshot, maps are keyed by iso 639-1 language code
+type Lists struct {
+	Forbidden       map[string][]Keyword
+	Exceptions      map[string]Exceptions // apply to the list of the same language
+	DefaultLanguage string                // list used when the detected language has none
+}
+
+// matcherSet is an immutable snapshot of the compiled keyword lists per language
+type MatcherSet struct {
+	byLanguage      map[string]*Matcher
+	exceptions      map[string]*ExceptionSet
+	defaultLanguage string
+	version         uint64
+}
+
+// matcher returns the list for the language, or the default list when there's none
+// the second value is the language of the returned list, empty when nothing matched
+func (s *MatcherSet) Matcher(language string) (*Matcher, string) {
+	if s == nil {
+		return nil, ""
+	}
+	language = strings.ToLower(language)
+	if m, ok := s.byLanguage[language]; ok {
+		return m, language
+	}
+	if m, ok := s.byLanguage[s.defaultLanguage]; ok {
+		return m, s.defaultLanguage
+	}
+	return nil, ""
+}
+
+// exceptions returns the exceptions of the language list, nil if none
+// pass the list language returned by Matcher
+func (s *MatcherSet) Exceptions(language string) *ExceptionSet {
+	if s == nil {
+		return nil
+	}
+	return s.exceptions[strings.ToLower(language)]
+}
+
+// match finds the keywords of the language list in text & clears the ones covered by its exceptions
+func (s *MatcherSet) Match(language, text string) (matches, suppressed []Match) {
+	matcher, listLanguage := s.Matcher(language)
+	return s.Exceptions(listLanguage).Filter(text, matcher.Match(text))
+}
+
+// languages returns the number of compiled lists
+func (s *MatcherSet) Languages() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.byLanguage)
+}
+
+// len returns the number of compiled keywords over all languages
+func (s *MatcherSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	n := 0
+	for _, m := range s.byLanguage {
+		n += m.Len()
+	}
+	return n
+}
+
+// version increments on every successful Engine.Update
+func (s *MatcherSet) Version() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.version
+}
+
+// engine holds the active keyword lists shared by all workers
+// Update compiles the new lists first & swaps them atomically:
+// in-flight matches finish on the snapshot they loaded, a failed compile keeps the old one
+type Engine struct {
+	snapshot atomic.Pointer[MatcherSet]
+}
+
+// newEngine compiles the lists
+func NewEngine(lists Lists) (*Engine, error) {
+	e := &Engine{}
+	if err := e.Update(lists); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// snapshot returns the current lists, use the same one for a whole transcript
+// a nil engine returns a nil set, which matches nothing
+func (e *Engine) Snapshot() *MatcherSet {
+	if e == nil {
+		return nil
+	}
+	return e.snapshot.Load()
+}
+
+// matcher is a shortcut for Snapshot().Matcher(language)
+func (e *Engine) Matcher(language string) (*Matcher, string) {
+	return e.Snapshot().Matcher(language)
+}
+
+func (e *Engine) Update(lists Lists) error {
+	set := &MatcherSet{
+		byLanguage:      map[string]*Matcher{},
+		exceptions:      map[string]*ExceptionSet{},
+		defaultLanguage: strings.ToLower(lists.DefaultLanguage),
+	}
+	for language, keywords := range lists.Forbidden {
+		m, err := NewMatcher(keywords)
+		if err != nil {
+			return fmt.Errorf("language %q: %w", language, err)
+		}
+		set.byLanguage[strings.ToLower(language)] = m
+	}
+	if len(set.byLanguage) > 0 {
+		if _, ok := set.byLanguage[set.defaultLanguage]; !ok {
+			return fmt.Errorf("default language %q has no keyword list", lists.DefaultLanguage)
+		}
+	}
+	for language, exceptions := range lists.Exceptions {
+		es, err := NewExceptionSet(exceptions)
+		if err != nil {
+			return fmt.Errorf("language %q exceptions: %w", language, err)
+		}
+		set.exceptions[strings.ToLower(language)] = es
+	}
+
+	for {
+		old := e.snapshot.Load()
+		set.version = old.Version() + 1
+		if e.snapshot.CompareAndSwap(old, set) {
+			return nil
+		}
+	}
+}
+
+// version of the active snapshot
+func (e *Engine) Version() uint64 {
+	return e.Snapshot().Version()
+}
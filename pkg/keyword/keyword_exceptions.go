@@ -0,0 +1,194 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exceptions clear legitimate uses of forbidden keywords, evaluated after matching
+// e.g. allow "train station", ignore "money" right before "back guarantee"
+type Exceptions struct {
+	Allow   []string      `json:"allow"`   // phrases, keywords matched inside them are not flagged
+	Context []ContextRule `json:"context"` // keyword ignored next to given words
+}
+
+// contextRule ignores a keyword right after one of the before phrases or right before one of the after phrases
+type ContextRule struct {
+	Keyword string   `json:"keyword"` // canonical term, as in the forbidden list
+	Before  []string `json:"before"`
+	After   []string `json:"after"`
+}
+
+// exceptionSet is the compiled form of Exceptions, immutable & safe to share
+// a nil set clears nothing
+type ExceptionSet struct {
+	allow        *automaton[string]
+	allowPhrases []string
+	context      map[string][]contextPhrases // by lowercased keyword term
+}
+
+type contextPhrases struct {
+	before [][]string
+	after  [][]string
+	rule   ContextRule
+}
+
+func NewExceptionSet(exceptions Exceptions) (*ExceptionSet, error) {
+	set := &ExceptionSet{
+		allow:   newAutomaton[string](),
+		context: map[string][]contextPhrases{},
+	}
+
+	for _, phrase := range exceptions.Allow {
+		words, err := phraseWords(phrase)
+		if err != nil {
+			return nil, fmt.Errorf("allow: %w", err)
+		}
+		set.allow.add(words)
+		set.allowPhrases = append(set.allowPhrases, phrase)
+	}
+	set.allow.build()
+
+	for _, rule := range exceptions.Context {
+		if strings.TrimSpace(rule.Keyword) == "" {
+			return nil, fmt.Errorf("context rule: empty keyword")
+		}
+		if len(rule.Before) == 0 && len(rule.After) == 0 {
+			return nil, fmt.Errorf("context rule %q: needs before or after phrases", rule.Keyword)
+		}
+		compiled := contextPhrases{rule: rule}
+		for _, phrase := range rule.Before {
+			words, err := phraseWords(phrase)
+			if err != nil {
+				return nil, fmt.Errorf("context rule %q: %w", rule.Keyword, err)
+			}
+			compiled.before = append(compiled.before, words)
+		}
+		for _, phrase := range rule.After {
+			words, err := phraseWords(phrase)
+			if err != nil {
+				return nil, fmt.Errorf("context rule %q: %w", rule.Keyword, err)
+			}
+			compiled.after = append(compiled.after, words)
+		}
+		key := strings.ToLower(rule.Keyword)
+		set.context[key] = append(set.context[key], compiled)
+	}
+
+	return set, nil
+}
+
+func phraseWords(phrase string) ([]string, error) {
+	tokens := Tokenize(phrase)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("phrase %q has no words", phrase)
+	}
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Text
+	}
+	return words, nil
+}
+
+// filter splits the matches of text into kept & suppressed
+// suppressed matches carry the exception that cleared them in SuppressedBy
+func (s *ExceptionSet) Filter(text string, matches []Match) (kept, suppressed []Match) {
+	kept = []Match{}
+	suppressed = []Match{}
+	if s == nil || len(matches) == 0 {
+		return append(kept, matches...), suppressed
+	}
+
+	tokens := Tokenize(text)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Text
+	}
+
+	// allow phrase spans, in runes
+	type span struct {
+		start, end int
+		phrase     string
+	}
+	var allowed []span
+	if len(s.allowPhrases) > 0 {
+		s.allow.search(words, func(id, start, end int) {
+			allowed = append(allowed, span{tokens[start].Start, tokens[end-1].End, s.allowPhrases[id]})
+		})
+	}
+
+	for _, match := range matches {
+		reason := ""
+		for _, a := range allowed {
+			if match.Start >= a.start && match.End <= a.end {
+				reason = fmt.Sprintf("allow %q", a.phrase)
+				break
+			}
+		}
+		if reason == "" {
+			reason = s.contextReason(tokens, words, match)
+		}
+
+		if reason == "" {
+			kept = append(kept, match)
+			continue
+		}
+		match.SuppressedBy = reason
+		suppressed = append(suppressed, match)
+	}
+
+	return kept, suppressed
+}
+
+func (s *ExceptionSet) contextReason(tokens []Token, words []string, match Match) string {
+	rules := s.context[strings.ToLower(match.Keyword)]
+	if len(rules) == 0 {
+		return ""
+	}
+
+	// tokens before & after the match, substring matches may cut a token
+	first, last := len(tokens), -1
+	for i, token := range tokens {
+		if token.End > match.Start && token.Start < match.End {
+			first = min(first, i)
+			last = i
+		}
+	}
+	if last < 0 {
+		return ""
+	}
+	before, after := words[:first], words[last+1:]
+
+	for _, rule := range rules {
+		for _, phrase := range rule.before {
+			if hasSuffixWords(before, phrase) {
+				return fmt.Sprintf("context %q before %q", strings.Join(phrase, " "), rule.rule.Keyword)
+			}
+		}
+		for _, phrase := range rule.after {
+			if hasPrefixWords(after, phrase) {
+				return fmt.Sprintf("context %q after %q", strings.Join(phrase, " "), rule.rule.Keyword)
+			}
+		}
+	}
+	return ""
+}
+
+func hasPrefixWords(words, prefix []string) bool {
+	if len(prefix) > len(words) {
+		return false
+	}
+	for i := range prefix {
+		if words[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSuffixWords(words, suffix []string) bool {
+	if len(suffix) > len(words) {
+		return false
+	}
+	return hasPrefixWords(words[len(words)-len(suffix):], suffix)
+}
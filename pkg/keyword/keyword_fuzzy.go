@@ -0,0 +1,174 @@
+package pkg_keyword
+
+// fuzzy keywords are compared against every window of tokens, the cost grows with the list size
+// meant for a short list of frequently misrecognized terms, keep the bulk in word mode
+
+type fuzzyKeyword struct {
+	keyword   Keyword
+	letters   []rune // tokens joined without spaces, "trans fur" must hit "transfer"
+	words     int
+	distance  int
+	primary   string // phonetic codes, empty when phonetic is off
+	alternate string
+}
+
+func newFuzzyKeyword(kw Keyword, tokens []Token) fuzzyKeyword {
+	fk := fuzzyKeyword{
+		keyword:  kw,
+		letters:  joinTokens(tokens),
+		words:    len(tokens),
+		distance: kw.Distance,
+	}
+	if fk.distance == 0 {
+		fk.distance = defaultDistance(len(fk.letters))
+	}
+	if kw.Phonetic {
+		fk.primary, fk.alternate = DoubleMetaphone(string(fk.letters))
+	}
+	return fk
+}
+
+// defaultDistance scales the tolerance with the keyword length
+// short words are left exact, one typo turns "gun" into "fun"
+func defaultDistance(length int) int {
+	switch {
+	case length <= 3:
+		return 0
+	case length <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func joinTokens(tokens []Token) []rune {
+	var letters []rune
+	for _, token := range tokens {
+		letters = append(letters, []rune(token.Text)...)
+	}
+	return letters
+}
+
+// match scans the tokens left to right, at each position the best scoring window
+// of words-1..words+1 tokens is a candidate, windows starting inside it compete with it
+// the highest score wins, then the shortest span, and the scan continues after it
+func (fk fuzzyKeyword) match(tokens []Token, runes []rune, found func(Match)) {
+	for i := 0; i < len(tokens); {
+		end, score := fk.bestWindow(tokens, i)
+		if end == 0 {
+			i++
+			continue
+		}
+
+		// "a money" is within tolerance too, the exact "money" must win
+		start := i
+		for j := i + 1; j < end; j++ {
+			if e, s := fk.bestWindow(tokens, j); e != 0 && (s > score || s == score && e-j < end-start) {
+				start, end, score = j, e, s
+			}
+		}
+
+		from, to := tokens[start].Start, tokens[end-1].End
+		found(fk.keyword.newMatch(string(runes[from:to]), from, to, score))
+		i = end
+	}
+}
+
+// bestWindow returns the end & score of the best window starting at i, the shortest on a tie, end 0 if none is a hit
+func (fk fuzzyKeyword) bestWindow(tokens []Token, i int) (int, float64) {
+	bestScore, bestEnd := 0.0, 0
+	for n := max(1, fk.words-1); n <= fk.words+1 && i+n <= len(tokens); n++ {
+		if score, ok := fk.score(joinTokens(tokens[i : i+n])); ok && score > bestScore {
+			bestScore, bestEnd = score, i+n
+		}
+	}
+	return bestEnd, bestScore
+}
+
+// phonetic hits are at most one edit beyond the tolerance & score at least this
+const minPhoneticScore = 0.6
+
+// score is 1 for an exact hit and drops with the edit distance
+// a window within the tolerance or sounding the same (phonetic on) is a hit
+func (fk fuzzyKeyword) score(window []rune) (float64, bool) {
+	longest := max(len(fk.letters), len(window))
+
+	limit := fk.distance
+	if fk.primary != "" {
+		limit++
+	}
+
+	distance, ok := editDistance(fk.letters, window, limit)
+	if !ok {
+		return 0, false
+	}
+	score := 1 - float64(distance)/float64(longest)
+
+	if distance <= fk.distance {
+		return score, true
+	}
+
+	// metaphone codes drop the vowels, "many" & "mean" code like "money", the vowels must match too
+	if fk.primary != "" && score >= minPhoneticScore && string(vowels(window)) == string(vowels(fk.letters)) {
+		primary, alternate := DoubleMetaphone(string(window))
+		if primary != "" && (primary == fk.primary || primary == fk.alternate || alternate == fk.primary) {
+			return score, true
+		}
+	}
+
+	return 0, false
+}
+
+func vowels(letters []rune) []rune {
+	var out []rune
+	for _, r := range letters {
+		switch r {
+		case 'a', 'e', 'i', 'o', 'u', 'y':
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// editDistance is the levenshtein distance of a and b
+// gives up (false) as soon as it's certain to exceed limit
+func editDistance(a, b []rune, limit int) (int, bool) {
+	if abs(len(a)-len(b)) > limit {
+		return 0, false
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return 0, false
+		}
+		prev, curr = curr, prev
+	}
+
+	if prev[len(b)] > limit {
+		return 0, false
+	}
+	return prev[len(b)], true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
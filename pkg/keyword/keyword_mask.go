@@ -0,0 +1,191 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"sort"
+)
+
+// how masked keywords appear in the outgoing text
+type MaskStyle string
+
+const (
+	MaskAsterisks   MaskStyle = "asterisks"   // letters & digits replaced by '*', default
+	MaskCategory    MaskStyle = "category"    // whole match replaced by its category tag, [violence]
+	MaskReplacement MaskStyle = "replacement" // whole match replaced by a fixed string
+)
+
+// tag of category masking for keywords without category
+const uncategorizedTag = "[redacted]"
+
+// masking selects which matches are masked & how
+// the zero value masks matches with the mask action (or stronger) by asterisks
+type Masking struct {
+	Style       MaskStyle
+	Replacement string // text of MaskReplacement
+	All         bool   // mask every match, not only the ones the policy masks
+}
+
+func (m Masking) Validate() error {
+	switch m.Style {
+	case "", MaskAsterisks, MaskCategory:
+		return nil
+	case MaskReplacement:
+		if m.Replacement == "" {
+			return fmt.Errorf("mask style %q needs a replacement text", m.Style)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown mask style %q, expected asterisks, category or replacement", m.Style)
+	}
+}
+
+// masks reports if the match is hidden in the outgoing text
+func (m Masking) masks(match Match) bool {
+	return m.All || match.Action.AtLeast(ActionMask)
+}
+
+// replace returns what stands in for text of the match
+func (m Masking) replace(match Match, text string) string {
+	switch m.Style {
+	case MaskCategory:
+		if match.Category == "" {
+			return uncategorizedTag
+		}
+		return "[" + match.Category + "]"
+	case MaskReplacement:
+		return m.Replacement
+	default:
+		return Mask(text)
+	}
+}
+
+// label returns how the keyword of the match may be shown, masked if the match is
+func (m Masking) Label(match Match) string {
+	if !m.masks(match) {
+		return match.Keyword
+	}
+	return m.replace(match, match.Keyword)
+}
+
+// maskedText is text with the masked matches replaced
+// offsets of the original text are moved with Span, replacements may change the length
+type MaskedText struct {
+	Text    string
+	masking Masking
+	spans   []maskedSpan // ordered, never overlapping
+}
+
+type maskedSpan struct {
+	start, end       int // in the original text, runes
+	newStart, newEnd int // in the masked text
+}
+
+// apply masks the matches of text selected by the masking, overlapping matches are masked as one
+func (m Masking) Apply(text string, matches []Match) MaskedText {
+	runes := []rune(text)
+
+	var selected []Match
+	for _, match := range matches {
+		if m.masks(match) && match.Start >= 0 && match.Start < match.End && match.End <= len(runes) {
+			selected = append(selected, match)
+		}
+	}
+	if len(selected) == 0 {
+		return MaskedText{Text: text, masking: m}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Start < selected[j].Start })
+
+	masked := MaskedText{masking: m}
+	out := make([]rune, 0, len(runes))
+	pos := 0
+	for i := 0; i < len(selected); {
+		// first match of the group names the category
+		first := selected[i]
+		start, end := first.Start, first.End
+		for i++; i < len(selected) && selected[i].Start < end; i++ {
+			end = max(end, selected[i].End)
+		}
+
+		out = append(out, runes[pos:start]...)
+		newStart := len(out)
+		out = append(out, []rune(m.replace(first, string(runes[start:end])))...)
+		masked.spans = append(masked.spans, maskedSpan{start, end, newStart, len(out)})
+		pos = end
+	}
+	out = append(out, runes[pos:]...)
+
+	masked.Text = string(out)
+	return masked
+}
+
+// span maps a rune range of the original text into the masked text
+// a bound inside a replaced span moves to its edge
+func (t MaskedText) Span(start, end int) (int, int) {
+	return t.offset(start, false), t.offset(end, true)
+}
+
+func (t MaskedText) offset(pos int, end bool) int {
+	shift := 0
+	for _, s := range t.spans {
+		if pos <= s.start {
+			break
+		}
+		if pos < s.end {
+			// asterisks keep the length, offsets inside stay exact
+			if s.newEnd-s.newStart == s.end-s.start {
+				return pos + s.newStart - s.start
+			}
+			if end {
+				return s.newEnd
+			}
+			return s.newStart
+		}
+		shift = s.newEnd - s.end
+	}
+	return pos + shift
+}
+
+// matches moves matches into the masked text
+// Text, Keyword & captures of masked matches are masked too, the keyword as Label shows it
+func (t MaskedText) Matches(matches []Match) []Match {
+	runes := []rune(t.Text)
+	out := make([]Match, 0, len(matches))
+	for _, match := range matches {
+		start, end := t.Span(match.Start, match.End)
+		if start >= 0 && start <= end && end <= len(runes) {
+			match.Start, match.End = start, end
+			match.Text = string(runes[start:end])
+		}
+		if t.masking.masks(match) && len(match.Captures) > 0 {
+			captures := make(map[string]string, len(match.Captures))
+			for name, value := range match.Captures {
+				captures[name] = t.masking.replace(match, value)
+			}
+			match.Captures = captures
+		}
+		match.Keyword = t.masking.Label(match)
+		out = append(out, match)
+	}
+	return out
+}
+
+// maskText replaces the letters of every match with mask action or stronger by '*'
+// spaces & punctuation inside a phrase are kept so the text stays readable
+func MaskText(text string, matches []Match) string {
+	return Masking{}.Apply(text, matches).Text
+}
+
+// mask replaces every letter & digit of the text by '*'
+func Mask(text string) string {
+	runes := []rune(text)
+	maskRunes(runes)
+	return string(runes)
+}
+
+func maskRunes(runes []rune) {
+	for i, r := range runes {
+		if isWordRune(r) {
+			runes[i] = '*'
+		}
+	}
+}
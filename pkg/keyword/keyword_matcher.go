@@ -0,0 +1,191 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+)
+
+// matcher finds keywords in transcript text
+// all keywords are compiled into automata once, matching cost doesn't grow with the list size
+// it's immutable after NewMatcher, safe to share between workers
+// text & word keywords go through pkg.Normalize, "$500" & "five hundred bucks" both match "500 dollars"
+// a nil matcher matches nothing
+type Matcher struct {
+	size       int
+	words      *automaton[string] // word mode, exact tokens
+	stems      *automaton[string] // word mode with stem
+	substrings *automaton[rune]   // substring mode, lowercased runes
+	fuzzy      []fuzzyKeyword     // fuzzy mode, scanned one by one
+	patterns   []patternRule      // regex & template modes, scanned one by one
+	// keyword by pattern id, per automaton
+	wordKeywords      []Keyword
+	stemKeywords      []Keyword
+	substringKeywords []Keyword
+}
+
+func NewMatcher(keywords []Keyword) (*Matcher, error) {
+	m := &Matcher{
+		size:       len(keywords),
+		words:      newAutomaton[string](),
+		stems:      newAutomaton[string](),
+		substrings: newAutomaton[rune](),
+	}
+
+	for _, kw := range keywords {
+		if err := kw.validate(); err != nil {
+			return nil, err
+		}
+
+		if kw.Mode == ModeRegex || kw.Mode == ModeTemplate {
+			rule, err := newPatternRule(kw)
+			if err != nil {
+				return nil, err
+			}
+			m.patterns = append(m.patterns, rule)
+			continue
+		}
+
+		term := pkg.Normalize(kw.Term).Text
+		if kw.Mode == ModeSubstring {
+			m.substrings.add(lowerRunes(term))
+			m.substringKeywords = append(m.substringKeywords, kw)
+			continue
+		}
+
+		tokens := Tokenize(term)
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("keyword %q: no words to match", kw.Term)
+		}
+		pattern := make([]string, len(tokens))
+		for i, token := range tokens {
+			pattern[i] = token.Text
+			if kw.Stem {
+				pattern[i] = Stem(token.Text)
+			}
+		}
+		if kw.Mode == ModeFuzzy {
+			m.fuzzy = append(m.fuzzy, newFuzzyKeyword(kw, tokens))
+			continue
+		}
+		if kw.Stem {
+			m.stems.add(pattern)
+			m.stemKeywords = append(m.stemKeywords, kw)
+		} else {
+			m.words.add(pattern)
+			m.wordKeywords = append(m.wordKeywords, kw)
+		}
+	}
+
+	m.words.build()
+	m.stems.build()
+	m.substrings.build()
+
+	return m, nil
+}
+
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// len returns the number of compiled keywords
+func (m *Matcher) Len() int {
+	if m == nil {
+		return 0
+	}
+	return m.size
+}
+
+// match returns every keyword occurrence ordered by position
+// matching runs on the normalized text, offsets & Text point back into the original text
+// captures keep the normalized form (1,500 -> 1500)
+func (m *Matcher) Match(text string) []Match {
+	matches := []Match{}
+	if m == nil {
+		return matches
+	}
+
+	normalized := pkg.Normalize(text)
+	text = normalized.Text
+	runes := []rune(text)
+	tokens := Tokenize(text)
+
+	wordMatch := func(keywords []Keyword) func(id, start, end int) {
+		return func(id, start, end int) {
+			from, to := tokens[start].Start, tokens[end-1].End
+			matches = append(matches, keywords[id].newMatch(string(runes[from:to]), from, to, 1))
+		}
+	}
+
+	if len(m.wordKeywords) > 0 {
+		words := make([]string, len(tokens))
+		for i, token := range tokens {
+			words[i] = token.Text
+		}
+		m.words.search(words, wordMatch(m.wordKeywords))
+	}
+
+	if len(m.stemKeywords) > 0 {
+		stems := make([]string, len(tokens))
+		for i, token := range tokens {
+			stems[i] = Stem(token.Text)
+		}
+		m.stems.search(stems, wordMatch(m.stemKeywords))
+	}
+
+	if len(m.substringKeywords) > 0 {
+		m.substrings.search(lowerRunes(text), func(id, start, end int) {
+			matches = append(matches, m.substringKeywords[id].newMatch(string(runes[start:end]), start, end, 1))
+		})
+	}
+
+	for _, fk := range m.fuzzy {
+		fk.match(tokens, runes, func(match Match) {
+			matches = append(matches, match)
+		})
+	}
+
+	if len(m.patterns) > 0 {
+		runeIndex := runeIndexOf(text)
+		for _, rule := range m.patterns {
+			rule.match(text, runeIndex, func(match Match) {
+				matches = append(matches, match)
+			})
+		}
+	}
+
+	original := []rune(normalized.Original)
+	for i := range matches {
+		start, end := normalized.OriginalSpan(matches[i].Start, matches[i].End)
+		matches[i].Start, matches[i].End = start, end
+		matches[i].Text = string(original[start:end])
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End < matches[j].End
+	})
+
+	return matches
+}
+
+// matchedTerms returns the distinct keywords of the matches in order of appearance
+func MatchedTerms(matches []Match) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, match := range matches {
+		if !seen[match.Keyword] {
+			seen[match.Keyword] = true
+			terms = append(terms, match.Keyword)
+		}
+	}
+	return terms
+}
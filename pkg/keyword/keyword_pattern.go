@@ -0,0 +1,157 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// separator between the words of a template, anything but letters & digits
+const templateSeparator = `[^\p{L}\p{N}]+`
+
+// placeholder types of templates, {name:type}
+var templateTypes = map[string]string{
+	"number": `\d+(?:[.,]\d+)*`,                                  // 500, 1,000.50
+	"digits": `\d(?:[^\p{L}\p{N}]*\d)*`,                          // spoken digit sequences: 4 1 1 1-2 2
+	"word":   `[\p{L}\p{N}]+(?:'[\p{L}\p{N}]+)*`,                 // one word
+	"words":  `[\p{L}\p{N}']+(?:[^\p{L}\p{N}]+[\p{L}\p{N}']+)*?`, // one or more words, as few as possible
+}
+
+var templateNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// patternRule is a compiled regex or template keyword
+type patternRule struct {
+	keyword Keyword
+	re      *regexp.Regexp
+	names   []string // capture group names by index
+	bounded bool     // templates start & end on word boundaries
+}
+
+func newPatternRule(kw Keyword) (patternRule, error) {
+	source := kw.Term
+	if kw.Mode == ModeTemplate {
+		var err error
+		if source, err = templateToRegex(kw.Term); err != nil {
+			return patternRule{}, fmt.Errorf("keyword %q: invalid template: %w", kw.Term, err)
+		}
+	}
+
+	// transcripts have unpredictable casing
+	re, err := regexp.Compile("(?i)" + source)
+	if err != nil {
+		return patternRule{}, fmt.Errorf("keyword %q: invalid regex: %w", kw.Term, err)
+	}
+	if re.MatchString("") {
+		return patternRule{}, fmt.Errorf("keyword %q: pattern matches empty text", kw.Term)
+	}
+
+	return patternRule{
+		keyword: kw,
+		re:      re,
+		names:   re.SubexpNames(),
+		bounded: kw.Mode == ModeTemplate,
+	}, nil
+}
+
+// templateToRegex turns "send {amount:number} dollars" into a regex
+// literal words match case-insensitively with any punctuation in between, symbols are ignored
+// placeholders are {name} (one word) or {name:type} with type number, digits, word or words
+func templateToRegex(template string) (string, error) {
+	var parts []string
+	seen := map[string]bool{}
+
+	for rest := template; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		literal := rest
+		if open >= 0 {
+			literal = rest[:open]
+		}
+		for _, token := range Tokenize(literal) {
+			parts = append(parts, regexp.QuoteMeta(token.Text))
+		}
+		if open < 0 {
+			break
+		}
+
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed placeholder at %q", rest[open:])
+		}
+		name, kind, _ := strings.Cut(rest[open+1:open+end], ":")
+		name, kind = strings.TrimSpace(name), strings.TrimSpace(kind)
+		if kind == "" {
+			kind = "word"
+		}
+		if !templateNameRe.MatchString(name) {
+			return "", fmt.Errorf("invalid placeholder name %q", name)
+		}
+		if seen[name] {
+			return "", fmt.Errorf("duplicate placeholder %q", name)
+		}
+		seen[name] = true
+		expr, ok := templateTypes[kind]
+		if !ok {
+			return "", fmt.Errorf("placeholder %q: unknown type %q, expected number, digits, word or words", name, kind)
+		}
+		parts = append(parts, fmt.Sprintf("(?P<%s>%s)", name, expr))
+
+		rest = rest[open+end+1:]
+	}
+
+	if len(parts) == 0 {
+		return "", fmt.Errorf("no words or placeholders")
+	}
+	return strings.Join(parts, templateSeparator), nil
+}
+
+// match reports every non-overlapping occurrence in text
+// runeIndex maps byte offsets of text to rune offsets
+func (p patternRule) match(text string, runeIndex []int, found func(Match)) {
+	for _, loc := range p.re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if p.bounded && !onWordBoundary(text, start, end) {
+			continue
+		}
+
+		match := p.keyword.newMatch(text[start:end], runeIndex[start], runeIndex[end], 1)
+		for i := 1; i < len(p.names); i++ {
+			if p.names[i] == "" || loc[2*i] < 0 {
+				continue
+			}
+			if match.Captures == nil {
+				match.Captures = map[string]string{}
+			}
+			match.Captures[p.names[i]] = text[loc[2*i]:loc[2*i+1]]
+		}
+		found(match)
+	}
+}
+
+func onWordBoundary(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// runeIndexOf maps every byte offset of text (and len(text)) to its rune offset
+func runeIndexOf(text string) []int {
+	index := make([]int, len(text)+1)
+	n := 0
+	for i := range text {
+		index[i] = n
+		n++
+	}
+	index[len(text)] = n
+	// continuation bytes point to the next rune, never used as match bounds
+	for i := len(text) - 1; i >= 0; i-- {
+		if !utf8.RuneStart(text[i]) {
+			index[i] = index[i+1]
+		}
+	}
+	return index
+}
@@ -0,0 +1,612 @@
+package pkg_keyword
+
+import (
+	"strings"
+	"unicode"
+)
+
+// doubleMetaphone encodes an english word by how it sounds (lawrence philips' double metaphone)
+// primary is the common pronunciation, alternate covers foreign/ambiguous ones (equal if none)
+// codes are not truncated, phrases are encoded as one word
+// letters outside a-z (after folding accents) are ignored
+func DoubleMetaphone(word string) (primary, alternate string) {
+	e := newMetaphoneEncoder(word)
+	if len(e.word) == 0 {
+		return "", ""
+	}
+	e.encode()
+	return e.primary.String(), e.alternate.String()
+}
+
+type metaphoneEncoder struct {
+	word      []rune // uppercased letters only
+	last      int
+	slavo     bool // slavo germanic spelling (w, k, cz, witz)
+	primary   strings.Builder
+	alternate strings.Builder
+}
+
+func newMetaphoneEncoder(word string) *metaphoneEncoder {
+	e := &metaphoneEncoder{}
+	for _, r := range strings.ToUpper(word) {
+		switch r {
+		case 'À', 'Á', 'Â', 'Ã', 'Ä', 'Å':
+			r = 'A'
+		case 'È', 'É', 'Ê', 'Ë':
+			r = 'E'
+		case 'Ì', 'Í', 'Î', 'Ï':
+			r = 'I'
+		case 'Ò', 'Ó', 'Ô', 'Õ', 'Ö':
+			r = 'O'
+		case 'Ù', 'Ú', 'Û', 'Ü':
+			r = 'U'
+		case 'Ç', 'Ñ':
+			// kept, they have their own rules
+		default:
+			if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+				continue
+			}
+		}
+		e.word = append(e.word, r)
+	}
+	e.last = len(e.word) - 1
+
+	s := string(e.word)
+	e.slavo = strings.ContainsAny(s, "WK") || strings.Contains(s, "CZ") || strings.Contains(s, "WITZ")
+	return e
+}
+
+func (e *metaphoneEncoder) add(primary, alternate string) {
+	e.primary.WriteString(primary)
+	e.alternate.WriteString(alternate)
+}
+
+func (e *metaphoneEncoder) both(code string) {
+	e.add(code, code)
+}
+
+func (e *metaphoneEncoder) char(i int) rune {
+	if i < 0 || i > e.last {
+		return 0
+	}
+	return e.word[i]
+}
+
+// at reports whether one of the strings starts at position i
+func (e *metaphoneEncoder) at(i int, options ...string) bool {
+	if i < 0 {
+		return false
+	}
+	for _, option := range options {
+		if i+len(option) > len(e.word) {
+			continue
+		}
+		if string(e.word[i:i+len(option)]) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *metaphoneEncoder) vowel(i int) bool {
+	return strings.ContainsRune("AEIOUY", e.char(i))
+}
+
+func (e *metaphoneEncoder) encode() {
+	current := 0
+
+	// silent first letter
+	if e.at(0, "GN", "KN", "PN", "WR", "PS") {
+		current = 1
+	}
+	// xavier
+	if e.char(0) == 'X' {
+		e.both("S")
+		current = 1
+	}
+
+	for current <= e.last {
+		current += e.letter(current)
+	}
+}
+
+// letter encodes the letter at current and returns how many letters were consumed
+func (e *metaphoneEncoder) letter(current int) int {
+	next := e.char(current + 1)
+
+	switch e.char(current) {
+	case 'A', 'E', 'I', 'O', 'U', 'Y':
+		if current == 0 {
+			e.both("A")
+		}
+		return 1
+
+	case 'B':
+		e.both("P")
+		return skipDouble(next, 'B')
+
+	case 'Ç':
+		e.both("S")
+		return 1
+
+	case 'C':
+		return e.letterC(current)
+
+	case 'D':
+		if e.at(current, "DG") {
+			if e.at(current+2, "I", "E", "Y") {
+				// edge
+				e.both("J")
+				return 3
+			}
+			// edgar
+			e.both("TK")
+			return 2
+		}
+		e.both("T")
+		if e.at(current, "DT", "DD") {
+			return 2
+		}
+		return 1
+
+	case 'F':
+		e.both("F")
+		return skipDouble(next, 'F')
+
+	case 'G':
+		return e.letterG(current)
+
+	case 'H':
+		// only keep h between vowels or at the start before a vowel
+		if (current == 0 || e.vowel(current-1)) && e.vowel(current+1) {
+			e.both("H")
+			return 2
+		}
+		return 1
+
+	case 'J':
+		return e.letterJ(current)
+
+	case 'K':
+		e.both("K")
+		return skipDouble(next, 'K')
+
+	case 'L':
+		if next == 'L' {
+			// cabrillo, gallegos
+			if (current == e.last-2 && e.at(current-1, "ILLO", "ILLA", "ALLE")) ||
+				((e.at(e.last-1, "AS", "OS") || e.at(e.last, "A", "O")) && e.at(current-1, "ALLE")) {
+				e.add("L", "")
+				return 2
+			}
+			e.both("L")
+			return 2
+		}
+		e.both("L")
+		return 1
+
+	case 'M':
+		e.both("M")
+		// dumb, thumb
+		if (e.at(current-1, "UMB") && (current+1 == e.last || e.at(current+2, "ER"))) || next == 'M' {
+			return 2
+		}
+		return 1
+
+	case 'N':
+		e.both("N")
+		return skipDouble(next, 'N')
+
+	case 'Ñ':
+		e.both("N")
+		return 1
+
+	case 'P':
+		if next == 'H' {
+			e.both("F")
+			return 2
+		}
+		e.both("P")
+		if next == 'P' || next == 'B' {
+			return 2
+		}
+		return 1
+
+	case 'Q':
+		e.both("K")
+		return skipDouble(next, 'Q')
+
+	case 'R':
+		// french rogier
+		if current == e.last && !e.slavo && e.at(current-2, "IE") && !e.at(current-4, "ME", "MA") {
+			e.add("", "R")
+		} else {
+			e.both("R")
+		}
+		return skipDouble(next, 'R')
+
+	case 'S':
+		return e.letterS(current)
+
+	case 'T':
+		if e.at(current, "TION") {
+			e.both("X")
+			return 3
+		}
+		if e.at(current, "TIA", "TCH") {
+			e.both("X")
+			return 3
+		}
+		if e.at(current, "TH", "TTH") {
+			// thomas, thames
+			if e.at(current+2, "OM", "AM") || e.at(0, "VAN", "VON", "SCH") {
+				e.both("T")
+			} else {
+				e.add("0", "T")
+			}
+			return 2
+		}
+		e.both("T")
+		if next == 'T' || next == 'D' {
+			return 2
+		}
+		return 1
+
+	case 'V':
+		e.both("F")
+		return skipDouble(next, 'V')
+
+	case 'W':
+		return e.letterW(current)
+
+	case 'X':
+		// french breaux
+		if !(current == e.last && (e.at(current-3, "IAU", "EAU") || e.at(current-2, "AU", "OU"))) {
+			e.both("KS")
+		}
+		if next == 'C' || next == 'X' {
+			return 2
+		}
+		return 1
+
+	case 'Z':
+		if next == 'H' {
+			// chinese zhao
+			e.both("J")
+			return 2
+		}
+		if e.at(current+1, "ZO", "ZI", "ZA") || (e.slavo && current > 0 && e.char(current-1) != 'T') {
+			e.add("S", "TS")
+		} else {
+			e.both("S")
+		}
+		return skipDouble(next, 'Z')
+	}
+
+	return 1
+}
+
+func skipDouble(next, letter rune) int {
+	if next == letter {
+		return 2
+	}
+	return 1
+}
+
+func (e *metaphoneEncoder) letterC(current int) int {
+	// germanic: bacher, macher
+	if current > 1 && !e.vowel(current-2) && e.at(current-1, "ACH") && e.char(current+2) != 'I' &&
+		(e.char(current+2) != 'E' || e.at(current-2, "BACHER", "MACHER")) {
+		e.both("K")
+		return 2
+	}
+
+	if current == 0 && e.at(current, "CAESAR") {
+		e.both("S")
+		return 2
+	}
+
+	// italian chianti
+	if e.at(current, "CHIA") {
+		e.both("K")
+		return 2
+	}
+
+	if e.at(current, "CH") {
+		// michael
+		if current > 0 && e.at(current, "CHAE") {
+			e.add("K", "X")
+			return 2
+		}
+		// greek roots: chemistry, chorus
+		if current == 0 && (e.at(current+1, "HARAC", "HARIS") || e.at(current+1, "HOR", "HYM", "HIA", "HEM")) && !e.at(0, "CHORE") {
+			e.both("K")
+			return 2
+		}
+		// germanic, greek or otherwise 'ch' for 'kh' sound
+		if e.at(0, "VAN", "VON", "SCH") || e.at(current-2, "ORCHES", "ARCHIT", "ORCHID") || e.at(current+2, "T", "S") ||
+			((e.at(current-1, "A", "O", "U", "E") || current == 0) && (current+2 > e.last || e.at(current+2, "L", "R", "N", "M", "B", "H", "F", "V", "W"))) {
+			e.both("K")
+		} else if current > 0 {
+			if e.at(0, "MC") {
+				e.both("K")
+			} else {
+				e.add("X", "K")
+			}
+		} else {
+			e.both("X")
+		}
+		return 2
+	}
+
+	// polish czerny
+	if e.at(current, "CZ") && !e.at(current-2, "WICZ") {
+		e.add("S", "X")
+		return 2
+	}
+
+	// italian focaccia
+	if e.at(current+1, "CIA") {
+		e.both("X")
+		return 3
+	}
+
+	// double c but not mcclellan
+	if e.at(current, "CC") && !(current == 1 && e.char(0) == 'M') {
+		// bellocchio but not bacchus
+		if e.at(current+2, "I", "E", "H") && !e.at(current+2, "HU") {
+			// accident, accede, succeed
+			if (current == 1 && e.char(current-1) == 'A') || e.at(current-1, "UCCEE", "UCCES") {
+				e.both("KS")
+			} else {
+				// bacci, bertucci
+				e.both("X")
+			}
+			return 3
+		}
+		// pierce's rule
+		e.both("K")
+		return 2
+	}
+
+	if e.at(current, "CK", "CG", "CQ") {
+		e.both("K")
+		return 2
+	}
+
+	if e.at(current, "CI", "CE", "CY") {
+		// italian vs english
+		if e.at(current, "CIO", "CIE", "CIA") {
+			e.add("S", "X")
+		} else {
+			e.both("S")
+		}
+		return 2
+	}
+
+	e.both("K")
+	if e.at(current+1, "C", "K", "Q") && !e.at(current+1, "CE", "CI") {
+		return 2
+	}
+	return 1
+}
+
+func (e *metaphoneEncoder) letterG(current int) int {
+	next := e.char(current + 1)
+
+	if next == 'H' {
+		if current > 0 && !e.vowel(current-1) {
+			e.both("K")
+			return 2
+		}
+		// ghislane, ghiradelli
+		if current == 0 {
+			if e.char(current+2) == 'I' {
+				e.both("J")
+			} else {
+				e.both("K")
+			}
+			return 2
+		}
+		// hugh, bough, broughton: silent
+		if (current > 1 && e.at(current-2, "B", "H", "D")) ||
+			(current > 2 && e.at(current-3, "B", "H", "D")) ||
+			(current > 3 && e.at(current-4, "B", "H")) {
+			return 2
+		}
+		// laugh, cough, tough
+		if current > 2 && e.char(current-1) == 'U' && e.at(current-3, "C", "G", "L", "R", "T") {
+			e.both("F")
+			return 2
+		}
+		if current > 0 && e.char(current-1) != 'I' {
+			e.both("K")
+		}
+		return 2
+	}
+
+	if next == 'N' {
+		if current == 1 && e.vowel(0) && !e.slavo {
+			e.add("KN", "N")
+		} else if !e.at(current+2, "EY") && e.char(current+1) != 'Y' && !e.slavo {
+			// not cagney
+			e.add("N", "KN")
+		} else {
+			e.both("KN")
+		}
+		return 2
+	}
+
+	// tagliaro
+	if e.at(current+1, "LI") && !e.slavo {
+		e.add("KL", "L")
+		return 2
+	}
+
+	// -ges-, -gep-, -gel-, -gie- at beginning
+	if current == 0 && (next == 'Y' || e.at(current+1, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")) {
+		e.add("K", "J")
+		return 2
+	}
+
+	// -ger-, -gy-
+	if (e.at(current+1, "ER") || next == 'Y') && !e.at(0, "DANGER", "RANGER", "MANGER") &&
+		!e.at(current-1, "E", "I") && !e.at(current-1, "RGY", "OGY") {
+		e.add("K", "J")
+		return 2
+	}
+
+	// italian biaggi
+	if e.at(current+1, "E", "I", "Y") || e.at(current-1, "AGGI", "OGGI") {
+		if e.at(0, "VAN", "VON", "SCH") || e.at(current+1, "ET") {
+			// obvious germanic
+			e.both("K")
+		} else if e.at(current+1, "IER") && current+3 == e.last {
+			e.both("J")
+		} else {
+			e.add("J", "K")
+		}
+		return 2
+	}
+
+	e.both("K")
+	return skipDouble(next, 'G')
+}
+
+func (e *metaphoneEncoder) letterJ(current int) int {
+	next := e.char(current + 1)
+
+	// spanish jose, san jacinto
+	if e.at(current, "JOSE") || e.at(0, "SAN") {
+		if (current == 0 && current+4 > e.last) || e.at(0, "SAN") {
+			e.both("H")
+		} else {
+			e.add("J", "H")
+		}
+		return 1
+	}
+
+	switch {
+	case current == 0:
+		// yankelovich, jankelowicz
+		e.add("J", "A")
+	case e.vowel(current-1) && !e.slavo && (next == 'A' || next == 'O'):
+		// spanish pronunciation of bajador
+		e.add("J", "H")
+	case current == e.last:
+		e.add("J", "")
+	case !e.at(current+1, "L", "T", "K", "S", "N", "M", "B", "Z") && !e.at(current-1, "S", "K", "L"):
+		e.both("J")
+	}
+
+	return skipDouble(next, 'J')
+}
+
+func (e *metaphoneEncoder) letterS(current int) int {
+	next := e.char(current + 1)
+
+	// island, isle, carlysle: silent
+	if e.at(current-1, "ISL", "YSL") {
+		return 1
+	}
+
+	// sugar
+	if current == 0 && e.at(current, "SUGAR") {
+		e.add("X", "S")
+		return 1
+	}
+
+	if e.at(current, "SH") {
+		// germanic
+		if e.at(current+1, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			e.both("S")
+		} else {
+			e.both("X")
+		}
+		return 2
+	}
+
+	// italian & armenian
+	if e.at(current, "SIO", "SIA") {
+		if !e.slavo {
+			e.add("S", "X")
+		} else {
+			e.both("S")
+		}
+		return 3
+	}
+
+	// german & anglicisations: smith vs schmidt, snider vs schneider
+	if (current == 0 && e.at(current+1, "M", "N", "L", "W")) || next == 'Z' {
+		e.add("S", "X")
+		return skipDouble(next, 'Z')
+	}
+
+	if e.at(current, "SC") {
+		if e.char(current+2) == 'H' {
+			// dutch origin: school, schooner
+			if e.at(current+3, "OO", "ER", "EN", "UY", "ED", "EM") {
+				if e.at(current+3, "ER", "EN") {
+					// schlesinger's rule
+					e.add("X", "SK")
+				} else {
+					e.both("SK")
+				}
+				return 3
+			}
+			if current == 0 && !e.vowel(3) && e.char(3) != 'W' {
+				e.add("X", "S")
+			} else {
+				e.both("X")
+			}
+			return 3
+		}
+		if e.at(current+2, "I", "E", "Y") {
+			e.both("S")
+			return 3
+		}
+		e.both("SK")
+		return 3
+	}
+
+	// french resnais, artois
+	if current == e.last && e.at(current-2, "AI", "OI") {
+		e.add("", "S")
+	} else {
+		e.both("S")
+	}
+	if next == 'S' || next == 'Z' {
+		return 2
+	}
+	return 1
+}
+
+func (e *metaphoneEncoder) letterW(current int) int {
+	if e.at(current, "WR") {
+		e.both("R")
+		return 2
+	}
+
+	if current == 0 && (e.vowel(current+1) || e.at(current, "WH")) {
+		// wasserman should match vasserman
+		if e.vowel(current + 1) {
+			e.add("A", "F")
+		} else {
+			e.both("A")
+		}
+	}
+
+	// arnow should match arnoff
+	if (current == e.last && e.vowel(current-1)) || e.at(current-1, "EWSKI", "EWSKY", "OWSKI", "OWSKY") || e.at(0, "SCH") {
+		e.add("", "F")
+		return 1
+	}
+
+	// polish filipowicz
+	if e.at(current, "WICZ", "WITZ") {
+		e.add("TS", "FX")
+		return 4
+	}
+
+	return 1
+}
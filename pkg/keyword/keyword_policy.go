@@ -0,0 +1,156 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severities, from mildly sensitive to hard violation
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium" // default
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case "", SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+func (s Severity) validate() error {
+	if s.rank() == 0 {
+		return fmt.Errorf("unknown severity %q, expected low, medium, high or critical", s)
+	}
+	return nil
+}
+
+// enforcement actions, ordered by strength
+type Action string
+
+const (
+	ActionWarn      Action = "warn"      // report only
+	ActionMask      Action = "mask"      // also mask the matched text in the outgoing transcript
+	ActionEscalate  Action = "escalate"  // also flag the session for moderators
+	ActionTerminate Action = "terminate" // end the stream
+)
+
+func (a Action) rank() int {
+	switch a {
+	case ActionWarn:
+		return 1
+	case ActionMask:
+		return 2
+	case ActionEscalate:
+		return 3
+	case ActionTerminate:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// atLeast reports whether a is as strong as other
+func (a Action) AtLeast(other Action) bool {
+	return a.rank() >= other.rank()
+}
+
+// policyRule maps a category & minimum severity to an action
+// empty category or severity matches any
+type PolicyRule struct {
+	Category    string   `json:"category"`
+	MinSeverity Severity `json:"min_severity"`
+	Action      Action   `json:"action"`
+}
+
+func (r PolicyRule) applies(match Match) bool {
+	if r.Category != "" && r.Category != match.Category {
+		return false
+	}
+	return r.MinSeverity == "" || match.Severity.rank() >= r.MinSeverity.rank()
+}
+
+// policy decides what to do with the matches of a transcript
+// the first rule that applies to a match gives its action, default action otherwise (warn if empty)
+type Policy struct {
+	Rules         []PolicyRule `json:"rules"`
+	DefaultAction Action       `json:"default_action"`
+	Strikes       StrikePolicy `json:"strikes"` // per session escalation
+	// by iso 639-1 language code, on top of the exceptions of the keyword lists
+	Exceptions map[string]Exceptions `json:"exceptions"`
+}
+
+func (p Policy) Validate() error {
+	if p.DefaultAction != "" && p.DefaultAction.rank() == 0 {
+		return fmt.Errorf("policy: unknown default action %q", p.DefaultAction)
+	}
+	if err := p.Strikes.Validate(); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+	for language, exceptions := range p.Exceptions {
+		if _, err := NewExceptionSet(exceptions); err != nil {
+			return fmt.Errorf("policy exceptions %q: %w", language, err)
+		}
+	}
+	for i, rule := range p.Rules {
+		if rule.Action.rank() == 0 {
+			return fmt.Errorf("policy rule #%d: unknown action %q, expected warn, mask, escalate or terminate", i, rule.Action)
+		}
+		if rule.MinSeverity != "" {
+			if err := rule.MinSeverity.validate(); err != nil {
+				return fmt.Errorf("policy rule #%d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// suppress clears the matches covered by the policy exceptions of the language
+// policies are small, the exceptions are compiled per call
+func (p Policy) Suppress(language, text string, matches []Match) (kept, suppressed []Match) {
+	var set *ExceptionSet
+	if exceptions, ok := p.Exceptions[strings.ToLower(language)]; ok {
+		// errors are rejected by Validate, a nil set clears nothing
+		set, _ = NewExceptionSet(exceptions)
+	}
+	return set.Filter(text, matches)
+}
+
+func (p Policy) actionFor(match Match) Action {
+	for _, rule := range p.Rules {
+		if rule.applies(match) {
+			return rule.Action
+		}
+	}
+	if p.DefaultAction != "" {
+		return p.DefaultAction
+	}
+	return ActionWarn
+}
+
+// apply sets the action of each match & returns the strongest one, empty without matches
+// matches are copied, the input is shared with other sessions
+func (p Policy) Apply(matches []Match) ([]Match, Action) {
+	var strongest Action
+	out := make([]Match, len(matches))
+	for i, match := range matches {
+		match.Action = p.actionFor(match)
+		if match.Action.rank() > strongest.rank() {
+			strongest = match.Action
+		}
+		out[i] = match
+	}
+	return out, strongest
+}
@@ -0,0 +1,137 @@
+package pkg_keyword
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// strikePolicy escalates sessions that keep tripping keywords
+// every transcript with a policy action is a strike, levels are reached by the strikes in the sliding window
+// e.g. 3 strikes in 5 minutes -> final_warning, 5 -> terminate
+type StrikePolicy struct {
+	WindowSec int           `json:"window_sec"` // sliding window, default 300
+	Levels    []StrikeLevel `json:"levels"`     // ordered by strikes
+}
+
+type StrikeLevel struct {
+	Name       string `json:"name"`        // reported in the escalation event, e.g. final_warning
+	Strikes    int    `json:"strikes"`     // strikes within the window to reach the level
+	Terminate  bool   `json:"terminate"`   // end the stream once reached
+	StatusCode string `json:"status_code"` // grpc status code name of the termination, default PERMISSION_DENIED
+}
+
+func (p StrikePolicy) Window() time.Duration {
+	if p.WindowSec <= 0 {
+		return 300 * time.Second
+	}
+	return time.Duration(p.WindowSec) * time.Second
+}
+
+func (p StrikePolicy) Validate() error {
+	if p.WindowSec < 0 {
+		return fmt.Errorf("strikes: negative window")
+	}
+	prev := 0
+	for i, level := range p.Levels {
+		if level.Name == "" {
+			return fmt.Errorf("strike level #%d: empty name", i)
+		}
+		if level.Strikes <= prev {
+			return fmt.Errorf("strike level %q: strikes must be positive & increasing", level.Name)
+		}
+		prev = level.Strikes
+	}
+	return nil
+}
+
+// strikeState is a snapshot of a session tracker
+type StrikeState struct {
+	Strikes    int    // within the window
+	Total      int    // since the session start
+	Level      string // active level, empty below the first one
+	Window     time.Duration
+	LastStrike time.Time // zero without strikes
+}
+
+// strikeTracker counts the strikes of one session
+// safe for concurrent use, results are delivered from several goroutines & state is read by queries
+type StrikeTracker struct {
+	mu      sync.Mutex
+	policy  StrikePolicy
+	window  time.Duration
+	strikes []time.Time // within the window, oldest first
+	total   int
+	level   int // active level index + 1, 0 for none
+}
+
+func NewStrikeTracker(policy StrikePolicy) *StrikeTracker {
+	return &StrikeTracker{
+		policy: policy,
+		window: policy.Window(),
+	}
+}
+
+// strike records a strike at now, the level is returned only when this strike raised the active level
+func (t *StrikeTracker) Strike(now time.Time) (StrikeState, *StrikeLevel) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(now)
+	t.strikes = append(t.strikes, now)
+	t.total++
+
+	prev := t.level
+	t.level = t.activeLevel()
+
+	var reached *StrikeLevel
+	if t.level > prev {
+		level := t.policy.Levels[t.level-1]
+		reached = &level
+	}
+	return t.state(), reached
+}
+
+// state returns the tracker state at now, strikes older than the window are dropped
+func (t *StrikeTracker) State(now time.Time) StrikeState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(now)
+	t.level = t.activeLevel()
+	return t.state()
+}
+
+func (t *StrikeTracker) prune(now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(t.strikes) && !t.strikes[i].After(cutoff) {
+		i++
+	}
+	t.strikes = t.strikes[i:]
+}
+
+func (t *StrikeTracker) activeLevel() int {
+	active := 0
+	for i, level := range t.policy.Levels {
+		if len(t.strikes) >= level.Strikes {
+			active = i + 1
+		}
+	}
+	return active
+}
+
+func (t *StrikeTracker) state() StrikeState {
+	state := StrikeState{
+		Strikes: len(t.strikes),
+		Total:   t.total,
+		Window:  t.window,
+	}
+	if t.level > 0 {
+		state.Level = t.policy.Levels[t.level-1].Name
+	}
+	if n := len(t.strikes); n > 0 {
+		state.LastStrike = t.strikes[n-1]
+	}
+	return state
+}
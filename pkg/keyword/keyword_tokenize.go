@@ -0,0 +1,97 @@
+package pkg_keyword
+
+import (
+	"strings"
+	"unicode"
+)
+
+// token is a word of the text, offsets are unicode code points (end exclusive)
+type Token struct {
+	Text  string // lowercased, punctuation stripped
+	Start int
+	End   int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// tokenize splits text into lowercased words, punctuation & symbols are separators
+// apostrophes inside a word are kept (don't, it's)
+func Tokenize(text string) []Token {
+	runes := []rune(text)
+	tokens := []Token{}
+
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) {
+			if isWordRune(runes[i]) {
+				i++
+				continue
+			}
+			if isApostrophe(runes[i]) && i+1 < len(runes) && isWordRune(runes[i+1]) {
+				i++
+				continue
+			}
+			break
+		}
+
+		word := strings.ReplaceAll(string(runes[start:i]), "’", "'")
+		tokens = append(tokens, Token{
+			Text:  strings.ToLower(word),
+			Start: start,
+			End:   i,
+		})
+	}
+
+	return tokens
+}
+
+// stem is a light english suffix stripper for plural & common inflections
+// it only needs to map related forms to the same key, not to produce real words
+func Stem(word string) string {
+	if len([]rune(word)) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "'s"):
+		word = strings.TrimSuffix(word, "'s")
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		word = strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = strings.TrimSuffix(word, "s")
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		base := strings.TrimSuffix(word, suffix)
+		if base == word || len([]rune(base)) < 3 {
+			continue
+		}
+		// transferr(ed) -> transfer, stopp(ing) -> stop
+		if n := len(base); n >= 2 && base[n-1] == base[n-2] && !strings.ContainsRune("lsz", rune(base[n-1])) {
+			base = base[:n-1]
+		}
+		word = base
+		break
+	}
+
+	// wire, wired, wiring -> wir
+	if len([]rune(word)) > 3 {
+		word = strings.TrimSuffix(word, "e")
+	}
+
+	return word
+}
@@ -0,0 +1,52 @@
+package pkg_pii
+
+// luhnValid checks the luhn (mod 10) checksum of a digit string
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return len(digits) > 0 && sum%10 == 0
+}
+
+// ibanValid checks the iso 13616 layout & mod 97 checksum of an uppercase iban without spaces
+func ibanValid(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	for i := 0; i < 4; i++ {
+		c := iban[i]
+		if i < 2 && (c < 'A' || c > 'Z') {
+			return false
+		}
+		if i >= 2 && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	// country & check digits move to the end, letters count as 10..35
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A'+10)) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
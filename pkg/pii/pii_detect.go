@@ -0,0 +1,343 @@
+package pkg_pii
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	pkg "showcase-backend-audio_transcriber-go/pkg"
+)
+
+// personal data types, ordered by priority when findings overlap
+type Type string
+
+const (
+	TypeIBAN  Type = "iban"
+	TypeCard  Type = "card"  // luhn validated, 13 to 19 digits
+	TypePhone Type = "phone" // 10 to 15 digits, 8 with a leading + or "plus"
+	TypeEmail Type = "email" // written or spoken, john dot doe at example dot com
+)
+
+var types = []Type{TypeIBAN, TypeCard, TypePhone, TypeEmail}
+
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	Mask    bool   `json:"mask"`  // mask findings in the outgoing text
+	Types   []Type `json:"types"` // empty = all
+}
+
+func (c Config) Validate() error {
+	for _, t := range c.Types {
+		if !t.valid() {
+			return fmt.Errorf("unknown pii type %q, expected email, phone, iban or card", t)
+		}
+	}
+	return nil
+}
+
+func (t Type) valid() bool {
+	for _, known := range types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// finding is personal data in a transcript, offsets are runes in the original text
+type Finding struct {
+	Type   Type
+	Text   string // as it appears in the text
+	Value  string // canonical form, digits only or the written email, never leave the server with it
+	Start  int
+	End    int
+	Masked bool // set by the caller when the finding is masked in outgoing text
+}
+
+// detect finds personal data of the given types (all when empty) ordered by position
+// detection runs on the normalized text, "four one one one ..." is a card number too
+func Detect(text string, only []Type) []Finding {
+	findings := []Finding{}
+	if strings.TrimSpace(text) == "" {
+		return findings
+	}
+
+	enabled := map[Type]bool{}
+	for _, t := range only {
+		enabled[t] = true
+	}
+	if len(enabled) == 0 {
+		for _, t := range types {
+			enabled[t] = true
+		}
+	}
+
+	normalized := pkg.Normalize(text)
+	runes := []rune(normalized.Text)
+	tokens := tokenize(runes)
+
+	var candidates []Finding
+	if enabled[TypeIBAN] {
+		candidates = append(candidates, detectIBAN(runes, tokens)...)
+	}
+	if enabled[TypeCard] || enabled[TypePhone] {
+		for _, finding := range detectNumbers(runes, tokens) {
+			if enabled[finding.Type] {
+				candidates = append(candidates, finding)
+			}
+		}
+	}
+	if enabled[TypeEmail] {
+		candidates = append(candidates, detectEmail(normalized.Text)...)
+	}
+
+	// higher priority types win overlaps
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return typeRank(candidates[i].Type) < typeRank(candidates[j].Type)
+	})
+	original := []rune(text)
+	for _, candidate := range candidates {
+		overlaps := false
+		for _, kept := range findings {
+			if candidate.Start < kept.End && kept.Start < candidate.End {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		findings = append(findings, candidate)
+	}
+
+	for i := range findings {
+		start, end := normalized.OriginalSpan(findings[i].Start, findings[i].End)
+		findings[i].Start, findings[i].End = start, end
+		findings[i].Text = string(original[start:end])
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+
+	return findings
+}
+
+func typeRank(t Type) int {
+	for i, known := range types {
+		if t == known {
+			return i
+		}
+	}
+	return len(types)
+}
+
+// token is a run of letters & digits, offsets in runes
+type token struct {
+	text       string
+	start, end int
+	digits     bool
+}
+
+func tokenize(runes []rune) []token {
+	var tokens []token
+	for i := 0; i < len(runes); {
+		if !isAlnum(runes[i]) {
+			i++
+			continue
+		}
+		start, digits := i, true
+		for i < len(runes) && isAlnum(runes[i]) {
+			digits = digits && runes[i] >= '0' && runes[i] <= '9'
+			i++
+		}
+		tokens = append(tokens, token{string(runes[start:i]), start, i, digits})
+	}
+	return tokens
+}
+
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// numberSeparator tells if the gap between two digit groups keeps them in one number, 4111-1111, (555) 123
+func numberSeparator(gap []rune) bool {
+	if len(gap) == 0 || len(gap) > 3 {
+		return false
+	}
+	for _, r := range gap {
+		if !strings.ContainsRune(" -.()/", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectNumbers finds cards & phones in runs of digit groups
+func detectNumbers(runes []rune, tokens []token) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(tokens); {
+		if !tokens[i].digits {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(tokens) && tokens[j].digits && numberSeparator(runes[tokens[j-1].end:tokens[j].start]) {
+			j++
+		}
+		findings = append(findings, numberRun(runes, tokens, i, j)...)
+		i = j
+	}
+
+	return findings
+}
+
+// numberRun checks the digit groups tokens[from:to], cards first, what's left may be a phone
+func numberRun(runes []rune, tokens []token, from, to int) []Finding {
+	var findings []Finding
+	joined := func(i, j int) string {
+		var b strings.Builder
+		for _, t := range tokens[i:j] {
+			b.WriteString(t.text)
+		}
+		return b.String()
+	}
+
+	leftoverStart := from
+	phone := func(i, j int) {
+		if i >= j {
+			return
+		}
+		digits := joined(i, j)
+		start := tokens[i].start
+		plus := false
+		switch {
+		case start > 0 && runes[start-1] == '+':
+			plus, start = true, start-1
+		case i > 0 && tokens[i-1].text == "plus" && tokens[i-1].end+1 == tokens[i].start:
+			plus, start = true, tokens[i-1].start
+		}
+		minDigits := 10
+		if plus {
+			minDigits = 8
+		}
+		if len(digits) >= minDigits && len(digits) <= 15 {
+			value := digits
+			if plus {
+				value = "+" + digits
+			}
+			findings = append(findings, Finding{Type: TypePhone, Value: value, Start: start, End: tokens[j-1].end})
+		}
+	}
+
+	for i := from; i < to; i++ {
+		for j := to; j > i; j-- {
+			digits := joined(i, j)
+			if len(digits) < 13 || len(digits) > 19 || !luhnValid(digits) {
+				continue
+			}
+			phone(leftoverStart, i)
+			findings = append(findings, Finding{Type: TypeCard, Value: digits, Start: tokens[i].start, End: tokens[j-1].end})
+			leftoverStart = j
+			i = j - 1
+			break
+		}
+	}
+	phone(leftoverStart, to)
+
+	return findings
+}
+
+// detectIBAN finds country code, check digits & up to 30 letters or digits, spaced groups allowed
+// the mod 97 checksum must hold
+func detectIBAN(runes []rune, tokens []token) []Finding {
+	var findings []Finding
+
+	type char struct {
+		r        rune
+		pos      int
+		tokenEnd bool // last rune of its token
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		var chars []char
+		for j := i; j < len(tokens) && len(chars) < 34; j++ {
+			if j > i && tokens[j].start-tokens[j-1].end > 1 {
+				break
+			}
+			if j > i && !strings.ContainsRune(" -", runes[tokens[j-1].end]) {
+				break
+			}
+			for k := tokens[j].start; k < tokens[j].end && len(chars) < 34; k++ {
+				chars = append(chars, char{runes[k], k, k == tokens[j].end-1})
+			}
+		}
+
+		for size := len(chars); size >= 15; size-- {
+			if !chars[size-1].tokenEnd {
+				continue
+			}
+			var b strings.Builder
+			for _, c := range chars[:size] {
+				b.WriteRune(unicode.ToUpper(c.r))
+			}
+			value := b.String()
+			if !ibanValid(value) {
+				continue
+			}
+			findings = append(findings, Finding{Type: TypeIBAN, Value: value, Start: chars[0].pos, End: chars[size-1].pos + 1})
+			// skip the tokens of the iban
+			for i+1 < len(tokens) && tokens[i+1].start < chars[size-1].pos+1 {
+				i++
+			}
+			break
+		}
+	}
+
+	return findings
+}
+
+// written & spoken emails, "at" & "dot" spelled out
+var emailRe = regexp.MustCompile(`[\p{L}\p{N}_%+-]+(?:(?:\.|\s+dot\s+)[\p{L}\p{N}_%+-]+)*(?:\s*@\s*|\s+at\s+)[\p{L}\p{N}-]+(?:(?:\.|\s+dot\s+)[\p{L}\p{N}-]+)*(?:\.|\s+dot\s+)\p{L}{2,24}`)
+
+var (
+	spokenAt  = regexp.MustCompile(`\s*@\s*|\s+at\s+`)
+	spokenDot = regexp.MustCompile(`\s+dot\s+`)
+)
+
+func detectEmail(text string) []Finding {
+	var findings []Finding
+	runeIndex := runeOffsets(text)
+
+	for _, loc := range emailRe.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && isAlnum(lastRune(text[:start])) {
+			continue
+		}
+		if end < len(text) && isAlnum([]rune(text[end:])[0]) {
+			continue
+		}
+		value := spokenDot.ReplaceAllString(text[start:end], ".")
+		value = spokenAt.ReplaceAllString(value, "@")
+		findings = append(findings, Finding{Type: TypeEmail, Value: value, Start: runeIndex[start], End: runeIndex[end]})
+	}
+
+	return findings
+}
+
+func lastRune(s string) rune {
+	runes := []rune(s)
+	return runes[len(runes)-1]
+}
+
+// runeOffsets maps byte offsets of rune starts (and len(text)) to rune offsets
+func runeOffsets(text string) map[int]int {
+	index := map[int]int{}
+	n := 0
+	for i := range text {
+		index[i] = n
+		n++
+	}
+	index[len(text)] = n
+	return index
+}
@@ -0,0 +1,163 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// decoder turns raw little endian samples of one encoding into floats in [-1, 1]
+type Decoder struct {
+	Name       string                 // lowercase encoding name, i.e. pcm16, mulaw
+	SampleSize int                    // bytes per sample
+	Sample     func(b []byte) float32 // decodes one sample, len(b) == SampleSize
+}
+
+// decode converts every sample of data, the length must be a multiple of the sample size
+func (d Decoder) Decode(data []byte) ([]float32, error) {
+	if len(data)%d.SampleSize != 0 {
+		return nil, fmt.Errorf("data length %d is not a multiple of %d bytes for %s audio", len(data), d.SampleSize, d.Name)
+	}
+	floats := make([]float32, len(data)/d.SampleSize)
+	for i := range floats {
+		floats[i] = d.Sample(data[i*d.SampleSize : (i+1)*d.SampleSize])
+	}
+	return floats, nil
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{}
+)
+
+// registerDecoder adds a decoder or replaces the one with the same name
+func RegisterDecoder(d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[d.Name] = d
+}
+
+// lookupDecoder returns the decoder of an encoding name
+func LookupDecoder(name string) (Decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[name]
+	return d, ok
+}
+
+// decoderNames lists the registered encodings, sorted
+func DecoderNames() []string {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	names := make([]string, 0, len(decoders))
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterDecoder(Decoder{Name: "pcm16", SampleSize: 2, Sample: func(b []byte) float32 {
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+	}})
+	RegisterDecoder(Decoder{Name: "pcm24", SampleSize: 3, Sample: func(b []byte) float32 {
+		// sign extend through the top byte of an int32
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / 8388608.0
+	}})
+	RegisterDecoder(Decoder{Name: "pcm32", SampleSize: 4, Sample: func(b []byte) float32 {
+		return float32(float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648.0)
+	}})
+	RegisterDecoder(Decoder{Name: "float32", SampleSize: 4, Sample: func(b []byte) float32 {
+		f := math.Float32frombits(binary.LittleEndian.Uint32(b))
+		// browsers may overshoot, nan & inf would poison the resampler
+		if math.IsNaN(float64(f)) {
+			return 0
+		}
+		return max(min(f, 1), -1)
+	}})
+	RegisterDecoder(Decoder{Name: "mulaw", SampleSize: 1, Sample: func(b []byte) float32 {
+		return float32(MulawDecode(b[0])) / 32768.0
+	}})
+	RegisterDecoder(Decoder{Name: "alaw", SampleSize: 1, Sample: func(b []byte) float32 {
+		return float32(AlawDecode(b[0])) / 32768.0
+	}})
+}
+
+// g.711 segment ends of the biased magnitude
+var g711SegmentEnds = [8]int{0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF, 0x1FFF, 0x3FFF, 0x7FFF}
+
+const (
+	mulawBias = 0x84
+	mulawClip = 32635
+)
+
+func g711Segment(v int) int {
+	for seg, end := range g711SegmentEnds {
+		if v <= end {
+			return seg
+		}
+	}
+	return len(g711SegmentEnds)
+}
+
+// mulawDecode expands a g.711 mu-law code to 16-bit linear pcm
+func MulawDecode(code byte) int16 {
+	u := ^code
+	t := (int(u&0x0F)<<3 + mulawBias) << ((u & 0x70) >> 4)
+	if u&0x80 != 0 {
+		return int16(mulawBias - t)
+	}
+	return int16(t - mulawBias)
+}
+
+// mulawEncode compresses 16-bit linear pcm to a g.711 mu-law code
+func MulawEncode(sample int16) byte {
+	v, mask := int(sample), byte(0xFF)
+	if v < 0 {
+		v, mask = -v, 0x7F
+	}
+	v = min(v, mulawClip) + mulawBias
+	seg := g711Segment(v)
+	return byte(seg<<4|(v>>(seg+3))&0x0F) ^ mask
+}
+
+// alawDecode expands a g.711 a-law code to 16-bit linear pcm
+func AlawDecode(code byte) int16 {
+	a := code ^ 0x55
+	t := int(a&0x0F) << 4
+	switch seg := int(a&0x70) >> 4; seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t = (t + 0x108) << (seg - 1)
+	}
+	if a&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
+
+// alawEncode compresses 16-bit linear pcm to a g.711 a-law code
+func AlawEncode(sample int16) byte {
+	v, mask := int(sample), byte(0xD5)
+	if v < 0 {
+		v, mask = -v-1, 0x55
+	}
+	seg := g711Segment(v)
+	if seg >= len(g711SegmentEnds) {
+		return 0x7F ^ mask
+	}
+	code := byte(seg << 4)
+	if seg < 2 {
+		code |= byte(v>>4) & 0x0F
+	} else {
+		code |= byte(v>>(seg+3)) & 0x0F
+	}
+	return code ^ mask
+}
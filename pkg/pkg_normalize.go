@@ -0,0 +1,449 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizedText is a transcript prepared for keyword & pattern matching:
+// - unicode nfkc & case folding
+// - common english contractions expanded (don't -> do not)
+// - spoken & written numbers as digits (five hundred -> 500, 1,500 -> 1500), ordinals (twenty first -> 21st)
+// - currency as number + word ($500, 5 hundred bucks -> 500 dollars)
+// every rune of Text remembers the original runes it came from, see OriginalSpan
+type NormalizedText struct {
+	Original string
+	Text     string
+	starts   []int // per rune of Text, first original rune (offsets are runes)
+	ends     []int // per rune of Text, original rune end (exclusive)
+}
+
+// normalize runs the whole pipeline on text
+func Normalize(text string) NormalizedText {
+	folded := foldText(text)
+	out := expandWords(folded)
+	return NormalizedText{
+		Original: text,
+		Text:     string(out.runes),
+		starts:   out.starts,
+		ends:     out.ends,
+	}
+}
+
+// originalSpan maps a rune range of Text to the rune range of Original it was produced from
+// a range touching a rewritten part (e.g. "500 dollars" from "$500") covers the whole original part
+func (n NormalizedText) OriginalSpan(start, end int) (int, int) {
+	if len(n.starts) == 0 || start >= end {
+		return start, end
+	}
+	start = min(max(start, 0), len(n.starts)-1)
+	end = min(max(end, start+1), len(n.ends))
+	return n.starts[start], n.ends[end-1]
+}
+
+// alignedText is text under construction with the original span of every rune
+type alignedText struct {
+	runes  []rune
+	starts []int
+	ends   []int
+}
+
+// copy appends runes kept as they are, rune by rune mapping
+func (a *alignedText) copy(in *alignedText, from, to int) {
+	a.runes = append(a.runes, in.runes[from:to]...)
+	a.starts = append(a.starts, in.starts[from:to]...)
+	a.ends = append(a.ends, in.ends[from:to]...)
+}
+
+// replace appends s as the rewrite of in[from:to], every rune maps to the whole span
+func (a *alignedText) replace(s string, in *alignedText, from, to int) {
+	start, end := in.starts[from], in.ends[to-1]
+	for _, r := range s {
+		a.runes = append(a.runes, r)
+		a.starts = append(a.starts, start)
+		a.ends = append(a.ends, end)
+	}
+}
+
+// foldText applies nfkc & case folding per normalization segment, unchanged segments keep a rune mapping
+func foldText(text string) *alignedText {
+	out := &alignedText{}
+	fold := cases.Fold()
+
+	pos := 0 // rune offset of the segment in text
+	for rest := text; rest != ""; {
+		size := norm.NFKC.NextBoundaryInString(rest, true)
+		if size <= 0 {
+			size = len(rest)
+		}
+		segment := rest[:size]
+		rest = rest[size:]
+
+		segmentRunes := []rune(segment)
+		converted := fold.String(norm.NFKC.String(segment))
+		if converted == segment {
+			for i, r := range segmentRunes {
+				out.runes = append(out.runes, r)
+				out.starts = append(out.starts, pos+i)
+				out.ends = append(out.ends, pos+i+1)
+			}
+		} else {
+			for _, r := range converted {
+				out.runes = append(out.runes, r)
+				out.starts = append(out.starts, pos)
+				out.ends = append(out.ends, pos+len(segmentRunes))
+			}
+		}
+		pos += len(segmentRunes)
+	}
+
+	return out
+}
+
+// word is a run of letters & digits (inner apostrophes kept) in an alignedText
+type word struct {
+	text       string // apostrophes as '
+	start, end int
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func splitWords(in *alignedText) []word {
+	var words []word
+	runes := in.runes
+	for i := 0; i < len(runes); {
+		if !isWordChar(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && (isWordChar(runes[i]) ||
+			((runes[i] == '\'' || runes[i] == '’') && i+1 < len(runes) && isWordChar(runes[i+1]) && i > start)) {
+			i++
+		}
+		words = append(words, word{
+			text:  strings.ReplaceAll(string(runes[start:i]), "’", "'"),
+			start: start,
+			end:   i,
+		})
+	}
+	return words
+}
+
+// expandWords rewrites contractions, numbers & currencies, everything else is copied
+func expandWords(in *alignedText) *alignedText {
+	out := &alignedText{}
+	words := splitWords(in)
+
+	pos := 0 // next rune of in to copy
+	for i := 0; i < len(words); {
+		w := words[i]
+
+		if number, next, ok := parseNumber(in, words, i); ok {
+			from, to := w.start, words[next-1].end
+			value := number.value
+
+			// currency symbol right before ($500) or currency word right after (500 bucks)
+			currency := ""
+			if w.start > pos {
+				if name, ok := currencySymbols[in.runes[w.start-1]]; ok {
+					currency, from = name, w.start-1
+				}
+			}
+			if next < len(words) && !number.ordinal {
+				if name, ok := currencyWords[words[next].text]; ok && onlySpaces(in, words[next-1].end, words[next].start) {
+					currency, to = name, words[next].end
+					next++
+				}
+			}
+			if currency != "" {
+				if value == "1" {
+					currency = strings.TrimSuffix(currency, "s")
+				}
+				value += " " + currency
+			}
+
+			out.copy(in, pos, from)
+			if value == string(in.runes[from:to]) {
+				out.copy(in, from, to)
+			} else {
+				out.replace(value, in, from, to)
+			}
+			pos, i = to, next
+			continue
+		}
+
+		if expanded, ok := expandContraction(w.text); ok {
+			out.copy(in, pos, w.start)
+			out.replace(expanded, in, w.start, w.end)
+			pos, i = w.end, i+1
+			continue
+		}
+
+		if name, ok := slangCurrency[w.text]; ok {
+			out.copy(in, pos, w.start)
+			out.replace(name, in, w.start, w.end)
+			pos, i = w.end, i+1
+			continue
+		}
+
+		i++
+	}
+	out.copy(in, pos, len(in.runes))
+
+	return out
+}
+
+func onlySpaces(in *alignedText, from, to int) bool {
+	for _, r := range in.runes[from:to] {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+var currencySymbols = map[rune]string{
+	'$': "dollars",
+	'€': "euros",
+	'£': "pounds",
+	'¥': "yen",
+}
+
+// currency words after a number, singular or plural
+var currencyWords = map[string]string{
+	"dollar": "dollars", "dollars": "dollars", "buck": "dollars", "bucks": "dollars", "usd": "dollars",
+	"euro": "euros", "euros": "euros", "eur": "euros",
+	"pound": "pounds", "pounds": "pounds", "quid": "pounds", "gbp": "pounds",
+	"yen": "yen", "jpy": "yen",
+	"rupiah": "rupiah", "idr": "rupiah",
+}
+
+// currency slang anywhere in the text
+var slangCurrency = map[string]string{
+	"buck":  "dollar",
+	"bucks": "dollars",
+	"quid":  "pounds",
+}
+
+var contractions = map[string]string{
+	"won't": "will not", "can't": "can not", "shan't": "shall not", "ain't": "is not", "let's": "let us",
+	"it's": "it is", "that's": "that is", "what's": "what is", "there's": "there is", "here's": "here is",
+	"he's": "he is", "she's": "she is", "who's": "who is", "where's": "where is", "how's": "how is",
+}
+
+// suffixes safe to expand on any word, 's is left alone (possessive)
+var contractionSuffixes = []struct{ suffix, expanded string }{
+	{"n't", " not"},
+	{"'re", " are"},
+	{"'ve", " have"},
+	{"'ll", " will"},
+	{"'d", " would"},
+	{"'m", " am"},
+}
+
+func expandContraction(w string) (string, bool) {
+	if expanded, ok := contractions[w]; ok {
+		return expanded, true
+	}
+	for _, c := range contractionSuffixes {
+		if base, ok := strings.CutSuffix(w, c.suffix); ok && base != "" {
+			return base + c.expanded, true
+		}
+	}
+	return "", false
+}
+
+type numberKind int
+
+const (
+	kindNone numberKind = iota
+	kindDigits
+	kindUnit
+	kindTeen
+	kindTens
+	kindHundred
+	kindScale
+)
+
+var numberWords = map[string]struct {
+	value float64
+	kind  numberKind
+}{
+	"zero": {0, kindUnit}, "one": {1, kindUnit}, "two": {2, kindUnit}, "three": {3, kindUnit}, "four": {4, kindUnit},
+	"five": {5, kindUnit}, "six": {6, kindUnit}, "seven": {7, kindUnit}, "eight": {8, kindUnit}, "nine": {9, kindUnit},
+	"ten": {10, kindTeen}, "eleven": {11, kindTeen}, "twelve": {12, kindTeen}, "thirteen": {13, kindTeen},
+	"fourteen": {14, kindTeen}, "fifteen": {15, kindTeen}, "sixteen": {16, kindTeen}, "seventeen": {17, kindTeen},
+	"eighteen": {18, kindTeen}, "nineteen": {19, kindTeen},
+	"twenty": {20, kindTens}, "thirty": {30, kindTens}, "forty": {40, kindTens}, "fifty": {50, kindTens},
+	"sixty": {60, kindTens}, "seventy": {70, kindTens}, "eighty": {80, kindTens}, "ninety": {90, kindTens},
+	"hundred":  {100, kindHundred},
+	"thousand": {1e3, kindScale}, "million": {1e6, kindScale}, "billion": {1e9, kindScale},
+}
+
+var ordinalWords = map[string]string{
+	"first": "one", "second": "two", "third": "three", "fourth": "four", "fifth": "five",
+	"sixth": "six", "seventh": "seven", "eighth": "eight", "ninth": "nine", "tenth": "ten",
+	"eleventh": "eleven", "twelfth": "twelve", "thirteenth": "thirteen", "fourteenth": "fourteen",
+	"fifteenth": "fifteen", "sixteenth": "sixteen", "seventeenth": "seventeen", "eighteenth": "eighteen",
+	"nineteenth": "nineteen", "twentieth": "twenty", "thirtieth": "thirty", "fortieth": "forty",
+	"fiftieth": "fifty", "sixtieth": "sixty", "seventieth": "seventy", "eightieth": "eighty",
+	"ninetieth": "ninety", "hundredth": "hundred", "thousandth": "thousand", "millionth": "million",
+}
+
+type parsedNumber struct {
+	value   string // digits, with the ordinal suffix if any
+	ordinal bool
+}
+
+// parseNumber reads the number starting at words[i] & returns the index after it
+// digits with group separators (1,500) or a decimal point (1.5) are joined
+func parseNumber(in *alignedText, words []word, i int) (parsedNumber, int, bool) {
+	var total, current float64
+	last := kindNone
+	ordinal := false
+	written := "" // digits as written, kept when no number word follows (0044 stays 0044)
+	j := i
+
+	for j < len(words) && !ordinal {
+		w := words[j].text
+
+		// "a hundred", "a thousand"
+		if w == "a" && j == i && j+1 < len(words) {
+			if next, ok := numberWords[words[j+1].text]; ok && (next.kind == kindHundred || next.kind == kindScale) {
+				current, last = 1, kindUnit
+				j++
+				continue
+			}
+			break
+		}
+
+		if w == "and" && (last == kindHundred || last == kindScale) && j+1 < len(words) {
+			if next, ok := numberWords[words[j+1].text]; ok && next.kind >= kindUnit && next.kind <= kindTens {
+				j++
+				continue
+			}
+			break
+		}
+
+		if j == i && isDigits(w) {
+			text, next := joinDigits(in, words, j)
+			current, _ = strconv.ParseFloat(text, 64)
+			written, last = text, kindDigits
+			j = next
+			continue
+		}
+
+		spoken := w
+		if base, ok := ordinalWords[w]; ok {
+			// "wait a second" isn't an ordinal
+			if w == "second" && j == i && (i == 0 || words[i-1].text != "the") {
+				break
+			}
+			spoken, ordinal = base, true
+		}
+		number, ok := numberWords[spoken]
+		if !ok {
+			break
+		}
+
+		switch number.kind {
+		case kindUnit:
+			if last != kindNone && last != kindTens && last != kindHundred && last != kindScale {
+				ok = false
+				break
+			}
+			current += number.value
+		case kindTeen, kindTens:
+			if last != kindNone && last != kindHundred && last != kindScale {
+				ok = false
+				break
+			}
+			current += number.value
+		case kindHundred:
+			if last != kindUnit && last != kindTeen && last != kindDigits && last != kindTens {
+				ok = false
+				break
+			}
+			current *= 100
+		case kindScale:
+			if last == kindNone || last == kindScale {
+				ok = false
+				break
+			}
+			total += current * number.value
+			current = 0
+		}
+		if !ok {
+			ordinal = false
+			break
+		}
+		last = number.kind
+		j++
+	}
+
+	if j == i || last == kindNone {
+		return parsedNumber{}, i, false
+	}
+
+	if last == kindDigits {
+		return parsedNumber{value: written}, j, true
+	}
+
+	value := total + current
+	digits := strconv.FormatFloat(value, 'f', -1, 64)
+	if ordinal {
+		digits += ordinalSuffix(int64(value))
+	}
+	return parsedNumber{value: digits, ordinal: ordinal}, j, true
+}
+
+func isDigits(w string) bool {
+	for _, r := range w {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return w != ""
+}
+
+// joinDigits reads 1,500,000 (as 1500000) or 1.5 from words[i], the separators must sit right between the digits
+func joinDigits(in *alignedText, words []word, i int) (string, int) {
+	text := words[i].text
+	j := i + 1
+	for j < len(words) && isDigits(words[j].text) && words[j].start == words[j-1].end+1 {
+		sep := in.runes[words[j-1].end]
+		switch {
+		case sep == ',' && len(words[j].text) == 3 && !strings.Contains(text, "."):
+			text += words[j].text
+		case sep == '.' && !strings.Contains(text, "."):
+			text += "." + words[j].text
+		default:
+			return text, j
+		}
+		j++
+	}
+	return text, j
+}
+
+func ordinalSuffix(n int64) string {
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	default:
+		return "th"
+	}
+}
@@ -2,7 +2,9 @@ package pkg
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"unicode"
 )
 
 func BytesToFloat32(data []byte) ([]float32, error) {
@@ -36,3 +38,50 @@ func Int16SliceToBytes(data []int16) []byte {
     }
     return bytes
 }
+
+// float32ToBytes is the inverse of BytesToFloat32, rounded to the nearest pcm16 value, samples out of [-1, 1) are clipped
+func Float32ToBytes(samples []float32) []byte {
+	bytes := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		v := int16(max(min(math.Round(float64(s)*32768), 32767), -32768))
+		bytes[i*2] = byte(v)
+		bytes[i*2+1] = byte(v >> 8)
+	}
+	return bytes
+}
+
+// normalizeWord lowercases a word and trims surrounding punctuation for comparison
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}))
+}
+
+func wordsEqual(a, b []string) bool {
+	for i := range a {
+		if normalizeWord(a[i]) != normalizeWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeOverlappingText returns the part of next that isn't already the tail of prev
+// used by the sliding window, where next starts with a re-transcription of prev's last words
+// the last word of prev may be cut at the window boundary, it's ignored if nothing else matches
+func MergeOverlappingText(prev, next string) string {
+	prevWords := strings.Fields(prev)
+	nextWords := strings.Fields(next)
+
+	for cut := 0; cut <= 1 && cut < len(prevWords); cut++ {
+		tail := prevWords[:len(prevWords)-cut]
+		maxOverlap := min(len(tail), len(nextWords))
+		for k := maxOverlap; k > 0; k-- {
+			if wordsEqual(tail[len(tail)-k:], nextWords[:k]) {
+				return strings.Join(nextWords[k:], " ")
+			}
+		}
+	}
+
+	return strings.Join(nextWords, " ")
+}
@@ -0,0 +1,150 @@
+package pkg_wav
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// wave format tags, see mmreg.h
+const (
+	formatPCM        = 0x0001
+	formatFloat      = 0x0003
+	formatALaw       = 0x0006
+	formatMuLaw      = 0x0007
+	formatExtensible = 0xFFFE
+)
+
+// extensible sub formats are guids starting with the format tag, the rest is fixed
+var subFormatSuffix = []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+// encoding is a sample layout a wav file can carry, named after the pkg decoders
+type encoding struct {
+	name string
+	tag  uint16
+	bits int
+}
+
+var encodings = []encoding{
+	{"pcm16", formatPCM, 16},
+	{"pcm24", formatPCM, 24},
+	{"pcm32", formatPCM, 32},
+	{"float32", formatFloat, 32},
+	{"alaw", formatALaw, 8},
+	{"mulaw", formatMuLaw, 8},
+}
+
+// format is the stream layout of a wav file
+// encoding is a decoder name of pkg (pcm16, float32, mulaw, ...), so the stream config can be filled in directly
+type Format struct {
+	Encoding   string
+	SampleRate int
+	Channels   int
+}
+
+func (f Format) encoding() (encoding, error) {
+	for _, e := range encodings {
+		if e.name == f.Encoding {
+			return e, nil
+		}
+	}
+	return encoding{}, fmt.Errorf("unsupported wav encoding %q", f.Encoding)
+}
+
+func (f Format) Validate() error {
+	if _, err := f.encoding(); err != nil {
+		return err
+	}
+	if f.SampleRate <= 0 {
+		return fmt.Errorf("invalid wav sample rate %d", f.SampleRate)
+	}
+	if f.Channels < 1 || f.Channels > 0xFFFF {
+		return fmt.Errorf("invalid wav channels %d", f.Channels)
+	}
+	return nil
+}
+
+// bitsPerSample returns the sample size in bits, 0 for an unknown encoding
+func (f Format) BitsPerSample() int {
+	e, _ := f.encoding()
+	return e.bits
+}
+
+// blockAlign returns the bytes of one frame, a sample of every channel
+func (f Format) BlockAlign() int {
+	return f.BitsPerSample() / 8 * f.Channels
+}
+
+// parseFormat reads the body of a fmt chunk
+func parseFormat(body []byte) (Format, error) {
+	if len(body) < 16 {
+		return Format{}, fmt.Errorf("wav fmt chunk too short: %d bytes, expected at least 16", len(body))
+	}
+	tag := binary.LittleEndian.Uint16(body[0:])
+	channels := int(binary.LittleEndian.Uint16(body[2:]))
+	rate := int(binary.LittleEndian.Uint32(body[4:]))
+	blockAlign := int(binary.LittleEndian.Uint16(body[12:]))
+	bits := int(binary.LittleEndian.Uint16(body[14:]))
+
+	if tag == formatExtensible {
+		// cbSize, valid bits, channel mask, sub format guid
+		if len(body) < 40 {
+			return Format{}, fmt.Errorf("wav extensible fmt chunk too short: %d bytes, expected 40", len(body))
+		}
+		if cbSize := binary.LittleEndian.Uint16(body[16:]); cbSize < 22 {
+			return Format{}, fmt.Errorf("wav extensible fmt chunk has %d extension bytes, expected 22", cbSize)
+		}
+		guid := body[24:40]
+		if string(guid[2:]) != string(subFormatSuffix) {
+			return Format{}, fmt.Errorf("unknown wav extensible sub format % X", guid)
+		}
+		tag = binary.LittleEndian.Uint16(guid)
+	}
+
+	if channels == 0 {
+		return Format{}, fmt.Errorf("wav fmt chunk declares 0 channels")
+	}
+	if rate == 0 {
+		return Format{}, fmt.Errorf("wav fmt chunk declares a 0 hz sample rate")
+	}
+
+	for _, e := range encodings {
+		if e.tag != tag || e.bits != bits {
+			continue
+		}
+		if blockAlign != e.bits/8*channels {
+			return Format{}, fmt.Errorf("wav block align %d doesn't match %d channels of %d bits", blockAlign, channels, bits)
+		}
+		return Format{Encoding: e.name, SampleRate: rate, Channels: channels}, nil
+	}
+	return Format{}, fmt.Errorf("unsupported wav format 0x%04X with %d bits per sample", tag, bits)
+}
+
+// appendFormat appends a fmt chunk body, extensible when the plain header is ambiguous:
+// more than 2 channels or pcm wider than 16 bits
+func appendFormat(b []byte, f Format) []byte {
+	e, _ := f.encoding()
+	extensible := f.Channels > 2 || (e.tag == formatPCM && e.bits > 16)
+
+	tag := e.tag
+	if extensible {
+		tag = formatExtensible
+	}
+	b = binary.LittleEndian.AppendUint16(b, tag)
+	b = binary.LittleEndian.AppendUint16(b, uint16(f.Channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(f.SampleRate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(f.SampleRate*f.BlockAlign()))
+	b = binary.LittleEndian.AppendUint16(b, uint16(f.BlockAlign()))
+	b = binary.LittleEndian.AppendUint16(b, uint16(e.bits))
+
+	switch {
+	case extensible:
+		b = binary.LittleEndian.AppendUint16(b, 22)
+		b = binary.LittleEndian.AppendUint16(b, uint16(e.bits)) // valid bits
+		b = binary.LittleEndian.AppendUint32(b, 0)              // channel mask, unspecified
+		b = binary.LittleEndian.AppendUint16(b, e.tag)
+		b = append(b, subFormatSuffix...)
+	case e.tag != formatPCM:
+		b = binary.LittleEndian.AppendUint16(b, 0) // cbSize
+	}
+	return b
+}
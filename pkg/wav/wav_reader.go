@@ -0,0 +1,152 @@
+package pkg_wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// chunks other than data are read in memory, bigger ones are skipped
+const maxChunkSize = 1 << 20
+
+// unknownSize is the data size of wav files written by streaming tools that never patch the header
+const unknownSize = 0xFFFFFFFF
+
+// reader parses the header of a wav file & reads the samples of its data chunk
+// chunks after data (i.e. a trailing LIST) are not read
+type Reader struct {
+	Format   Format
+	Info     map[string]string // LIST INFO tags before the data chunk, i.e. INAM -> title
+	DataSize int64             // declared data bytes, -1 when unknown
+
+	r         io.Reader
+	remaining int64 // -1 reads until eof
+	truncated bool
+}
+
+// newReader parses the header up to the data chunk, malformed headers are described in the error
+func NewReader(r io.Reader) (*Reader, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("truncated wav riff header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" {
+		return nil, fmt.Errorf("not a wav file: expected RIFF, got %q", riff[0:4])
+	}
+	if string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a wav file: riff form %q, expected WAVE", riff[8:12])
+	}
+
+	reader := &Reader{Info: map[string]string{}, r: r}
+	hasFormat := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if !hasFormat {
+				return nil, fmt.Errorf("wav file has no fmt chunk: %w", err)
+			}
+			return nil, fmt.Errorf("wav file has no data chunk: %w", err)
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+
+		switch id {
+		case "data":
+			if !hasFormat {
+				return nil, errors.New("wav data chunk before the fmt chunk")
+			}
+			reader.DataSize, reader.remaining = size, size
+			if size == unknownSize {
+				reader.DataSize, reader.remaining = -1, -1
+			}
+			return reader, nil
+
+		case "fmt ":
+			body, err := readChunk(r, id, size)
+			if err != nil {
+				return nil, err
+			}
+			if reader.Format, err = parseFormat(body); err != nil {
+				return nil, err
+			}
+			hasFormat = true
+
+		case "LIST":
+			body, err := readChunk(r, id, size)
+			if err != nil {
+				return nil, err
+			}
+			parseInfo(body, reader.Info)
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil && !(errors.Is(err, io.EOF) && size%2 == 1) {
+				return nil, fmt.Errorf("truncated wav %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// readChunk reads a chunk body & its padding byte
+func readChunk(r io.Reader, id string, size int64) ([]byte, error) {
+	if size > maxChunkSize {
+		return nil, fmt.Errorf("wav %q chunk too large: %d bytes", id, size)
+	}
+	body := make([]byte, size+size%2)
+	n, err := io.ReadFull(r, body)
+	if err != nil && int64(n) < size {
+		return nil, fmt.Errorf("truncated wav %q chunk: %d of %d bytes: %w", id, n, size, err)
+	}
+	return body[:size], nil
+}
+
+// parseInfo collects the tags of a LIST INFO chunk, other lists (adtl, ...) are ignored
+func parseInfo(body []byte, info map[string]string) {
+	if len(body) < 4 || string(body[0:4]) != "INFO" {
+		return
+	}
+	for b := body[4:]; len(b) >= 8; {
+		id := string(b[0:4])
+		size := int(binary.LittleEndian.Uint32(b[4:]))
+		b = b[8:]
+		if size > len(b) {
+			return
+		}
+		info[id] = strings.TrimRight(string(b[:size]), "\x00")
+		b = b[min(size+size%2, len(b)):]
+	}
+}
+
+// read reads the samples of the data chunk, a file shorter than declared ends with io.EOF & Truncated
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if r.remaining > 0 && int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.r.Read(p)
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	if errors.Is(err, io.EOF) && r.remaining > 0 {
+		r.truncated = true
+	}
+	return n, err
+}
+
+// truncated reports if the data chunk ended before its declared size
+func (r *Reader) Truncated() bool {
+	return r.truncated
+}
+
+// duration returns the playback duration of the declared data, 0 when unknown
+func (r *Reader) Duration() time.Duration {
+	if r.DataSize < 0 {
+		return 0
+	}
+	frames := r.DataSize / int64(r.Format.BlockAlign())
+	return time.Duration(frames) * time.Second / time.Duration(r.Format.SampleRate)
+}
@@ -0,0 +1,69 @@
+package pkg_wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// write encodes a complete wav file: fmt, fact for non pcm, LIST INFO when info is set & data
+// odd chunks are padded, data must hold whole frames
+func Write(w io.Writer, f Format, info map[string]string, data []byte) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if len(data)%f.BlockAlign() != 0 {
+		return fmt.Errorf("wav data of %d bytes is not a multiple of %d byte frames", len(data), f.BlockAlign())
+	}
+
+	for id := range info {
+		if len(id) != 4 {
+			return fmt.Errorf("wav info id %q must be 4 characters", id)
+		}
+	}
+
+	body := []byte("WAVE")
+	body = appendChunk(body, "fmt ", appendFormat(nil, f))
+	if e, _ := f.encoding(); e.tag != formatPCM {
+		body = appendChunk(body, "fact", binary.LittleEndian.AppendUint32(nil, uint32(len(data)/f.BlockAlign())))
+	}
+	if len(info) > 0 {
+		body = appendChunk(body, "LIST", appendInfo([]byte("INFO"), info))
+	}
+
+	// riff & data headers, the data itself isn't copied
+	header := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)+8+len(data)+len(data)%2))
+	dataHeader := binary.LittleEndian.AppendUint32([]byte("data"), uint32(len(data)))
+	padding := make([]byte, len(data)%2)
+
+	for _, b := range [][]byte{header, body, dataHeader, data, padding} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendChunk(b []byte, id string, body []byte) []byte {
+	b = append(b, id...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(body)))
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// appendInfo appends the tags sorted by id, values are nul terminated
+func appendInfo(b []byte, info map[string]string) []byte {
+	ids := make([]string, 0, len(info))
+	for id := range info {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		b = appendChunk(b, id, append([]byte(info[id]), 0))
+	}
+	return b
+}
@@ -0,0 +1,81 @@
+//go:build !nowhisper
+
+package pkg_whisper
+
+import (
+	"fmt"
+
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// whisperBackend holds the loaded ggml model shared by every worker transcriber
+type WhisperBackend struct {
+	model whisper.Model
+}
+
+// whisperBackendLoad loads the ggml model once, call Close when done
+func WhisperBackendLoad(modelPath string) (*WhisperBackend, error) {
+	model, err := whisper.New(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	return &WhisperBackend{model: model}, nil
+}
+
+// newTranscriber creates a transcriber with its own whisper context
+func (b *WhisperBackend) NewTranscriber() (pkg_audio.Transcriber, error) {
+	ctx, err := b.model.NewContext()
+	if err != nil {
+		return nil, fmt.Errorf("whisper context: %w", err)
+	}
+	return &WhisperTranscriber{ctx: ctx}, nil
+}
+
+func (b *WhisperBackend) Close() error {
+	return b.model.Close()
+}
+
+// whisperTranscriber is the whisper.cpp implementation of pkg_audio.Transcriber
+// a context is not thread safe, use one per worker
+type WhisperTranscriber struct {
+	ctx whisper.Context
+}
+
+func (w *WhisperTranscriber) Transcribe(samples []float32, opts pkg_audio.TranscribeOptions) (pkg_audio.Transcription, error) {
+	var transcription pkg_audio.Transcription
+
+	// english only models (*.en) reject any language setting
+	multilingual := w.ctx.IsMultilingual()
+	if multilingual {
+		language := opts.Language
+		if language == "" {
+			language = "auto"
+		}
+		if err := w.ctx.SetLanguage(language); err != nil {
+			return transcription, fmt.Errorf("whisper language %q: %w", language, err)
+		}
+	}
+	w.ctx.SetTranslate(opts.Translate)
+	// always set, the context is shared by the sessions of the worker
+	w.ctx.SetInitialPrompt(opts.Prompt)
+
+	segmentCallback := func(segment whisper.Segment) {
+		transcription.Segments = append(transcription.Segments, pkg_audio.Segment{
+			Start: segment.Start,
+			End:   segment.End,
+			Text:  segment.Text,
+		})
+	}
+
+	if err := w.ctx.Process(samples, nil, segmentCallback, nil); err != nil {
+		return transcription, err
+	}
+
+	transcription.Language = "en"
+	if multilingual {
+		transcription.Language = w.ctx.DetectedLanguage()
+	}
+
+	return transcription, nil
+}
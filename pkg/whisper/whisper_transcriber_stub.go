@@ -0,0 +1,27 @@
+//go:build nowhisper
+
+package pkg_whisper
+
+import (
+	"errors"
+
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+)
+
+// built with `-tags nowhisper`, no cgo & no ggml model required
+// use the scripted transcriber backend instead
+var errNoWhisper = errors.New("whisper backend not available: built with nowhisper tag")
+
+type WhisperBackend struct{}
+
+func WhisperBackendLoad(modelPath string) (*WhisperBackend, error) {
+	return nil, errNoWhisper
+}
+
+func (b *WhisperBackend) NewTranscriber() (pkg_audio.Transcriber, error) {
+	return nil, errNoWhisper
+}
+
+func (b *WhisperBackend) Close() error {
+	return nil
+}
@@ -8,25 +8,24 @@ import (
 
 	pkg "showcase-backend-audio_transcriber-go/pkg"
 	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
-	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
 )
 
 // whisperWorkerPool initializes a pool of workers to process requests concurrently
+// each worker owns one transcriber created by newTranscriber (whisper, scripted, etc.)
+// the keyword engine is shared, every transcript is matched against the list of its detected language
 // we pass a *sync.Mutex to ensure only one inference runs at a time, prevent external lib SIGSEGV
-func WhisperWorkerPool(model whisper.Model, reqChan <-chan *pkg_audio.TranscribeRequest, numWorkers int, inferenceMu *sync.Mutex, fbdkwrds []string) {
+func WhisperWorkerPool(newTranscriber pkg_audio.NewTranscriberFunc, reqChan <-chan *pkg_audio.TranscribeRequest, numWorkers int, inferenceMu *sync.Mutex, fbdkwrds *pkg_keyword.Engine) {
 	for i := 0; i < numWorkers; i++ {
 		go func(workerID int) {
 			log.Printf("worker #%d started", workerID)
 
-			// context per worker
-			ctx, err := model.NewContext()
+			// transcriber per worker
+			transcriber, err := newTranscriber()
 			if err != nil {
-				log.Fatalf("worker #%d failed to create whisper context: %v", workerID, err)
+				log.Fatalf("worker #%d failed to create transcriber: %v", workerID, err)
 			}
 
-			ctx.SetLanguage("auto")
-			ctx.SetTranslate(false)
-
 			for req := range reqChan {
 				select {
 				case <-req.Ctx.Done():
@@ -42,49 +41,66 @@ func WhisperWorkerPool(model whisper.Model, reqChan <-chan *pkg_audio.Transcribe
 				audioFloats, err := pkg.BytesToFloat32(req.Audio)
 				if err != nil {
 					select {
-					case req.Resp <- &pkg_audio.TranscribeResult{Err: fmt.Errorf("audio conversion: %w", err)}:
+					case req.Resp <- &pkg_audio.TranscribeResult{Err: fmt.Errorf("%w: %w", pkg_audio.ErrAudioConversion, err)}:
 					default:
 						log.Printf("[worker #%d] resp chan full for session %s", workerID, req.SessionID)
 					}
 					continue
 				}
 
-				var result strings.Builder
-				segmentCallback := func(segment whisper.Segment) {
-					result.WriteString(segment.Text)
+				opts := req.Options
+				if opts.Language == "" {
+					opts.Language = "auto"
 				}
 
 				// cgo bound: inference (critical)
 				// - gglm whisper is not thread safe
 				// - concurrent process calls on the same backend state
 				inferenceMu.Lock()
-				err = ctx.Process(audioFloats, nil, segmentCallback, nil)
+				transcription, err := transcriber.Transcribe(audioFloats, opts)
 				inferenceMu.Unlock()
 
 				if err != nil {
 					select {
-					case req.Resp <- &pkg_audio.TranscribeResult{Err: fmt.Errorf("whisper process: %w", err)}:
+					case req.Resp <- &pkg_audio.TranscribeResult{Err: fmt.Errorf("%w: %w", pkg_audio.ErrTranscribe, err)}:
 					default:
 						log.Printf("[worker #%d] resp chan full for session %s", workerID, req.SessionID)
 					}
 					continue
 				}
 
+				// shift segment offsets from the request audio to the session
+				var result strings.Builder
+				sessionSegments := make([]pkg_audio.Segment, 0, len(transcription.Segments))
+				for _, segment := range transcription.Segments {
+					result.WriteString(segment.Text)
+					sessionSegments = append(sessionSegments, pkg_audio.Segment{
+						Start: req.Offset + segment.Start,
+						End:   req.Offset + segment.End,
+						Text:  strings.TrimSpace(segment.Text),
+					})
+				}
+
 				text := strings.TrimSpace(result.String())
-				
+
 				if text == "" || text == "BLANK_AUDIO" || len(text) < 2 {
 					select {
-					case req.Resp <- &pkg_audio.TranscribeResult{}:
+					case req.Resp <- &pkg_audio.TranscribeResult{Language: transcription.Language}:
 					default:
 					}
 					continue
 				}
 
-				hasKeywords, found := pkg.ContainsKeywords(text, fbdkwrds)
+				// keyword list & exceptions of the detected language, or the default list
+				matches, suppressed := fbdkwrds.Snapshot().Match(transcription.Language, text)
 				res := &pkg_audio.TranscribeResult{
-					Text:     text,
-					Warning:  hasKeywords,
-					Keywords: found,
+					Text:       text,
+					Language:   transcription.Language,
+					Warning:    len(matches) > 0,
+					Keywords:   pkg_keyword.MatchedTerms(matches),
+					Matches:    matches,
+					Suppressed: suppressed,
+					Segments:   sessionSegments,
 				}
 
 				// send result, if fail just log
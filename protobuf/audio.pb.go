@@ -21,29 +21,1185 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type AudioEncoding int32
+
+const (
+	AudioEncoding_AUDIO_ENCODING_UNSPECIFIED AudioEncoding = 0 // treated as pcm16
+	AudioEncoding_AUDIO_ENCODING_PCM16       AudioEncoding = 1 // signed 16-bit little endian
+	AudioEncoding_AUDIO_ENCODING_PCM24       AudioEncoding = 2 // signed 24-bit little endian, packed in 3 bytes
+	AudioEncoding_AUDIO_ENCODING_PCM32       AudioEncoding = 3 // signed 32-bit little endian
+	AudioEncoding_AUDIO_ENCODING_FLOAT32     AudioEncoding = 4 // ieee 754 32-bit little endian, [-1, 1]
+	AudioEncoding_AUDIO_ENCODING_MULAW       AudioEncoding = 5 // g.711 mu-law, 8-bit
+	AudioEncoding_AUDIO_ENCODING_ALAW        AudioEncoding = 6 // g.711 a-law, 8-bit
+	AudioEncoding_AUDIO_ENCODING_FLAC        AudioEncoding = 7 // flac stream from its fLaC marker, sample rate & channels come from its STREAMINFO
+)
+
+// Enum value maps for AudioEncoding.
+var (
+	AudioEncoding_name = map[int32]string{
+		0: "AUDIO_ENCODING_UNSPECIFIED",
+		1: "AUDIO_ENCODING_PCM16",
+		2: "AUDIO_ENCODING_PCM24",
+		3: "AUDIO_ENCODING_PCM32",
+		4: "AUDIO_ENCODING_FLOAT32",
+		5: "AUDIO_ENCODING_MULAW",
+		6: "AUDIO_ENCODING_ALAW",
+		7: "AUDIO_ENCODING_FLAC",
+	}
+	AudioEncoding_value = map[string]int32{
+		"AUDIO_ENCODING_UNSPECIFIED": 0,
+		"AUDIO_ENCODING_PCM16":       1,
+		"AUDIO_ENCODING_PCM24":       2,
+		"AUDIO_ENCODING_PCM32":       3,
+		"AUDIO_ENCODING_FLOAT32":     4,
+		"AUDIO_ENCODING_MULAW":       5,
+		"AUDIO_ENCODING_ALAW":        6,
+		"AUDIO_ENCODING_FLAC":        7,
+	}
+)
+
+func (x AudioEncoding) Enum() *AudioEncoding {
+	p := new(AudioEncoding)
+	*p = x
+	return p
+}
+
+func (x AudioEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AudioEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_audio_proto_enumTypes[0].Descriptor()
+}
+
+func (AudioEncoding) Type() protoreflect.EnumType {
+	return &file_audio_proto_enumTypes[0]
+}
+
+func (x AudioEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AudioEncoding.Descriptor instead.
+func (AudioEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{0}
+}
+
+type TranscriptStatus int32
+
+const (
+	TranscriptStatus_TRANSCRIPT_STATUS_UNSPECIFIED TranscriptStatus = 0
+	TranscriptStatus_TRANSCRIPT_STATUS_OK          TranscriptStatus = 1 // transcribed, nothing detected
+	TranscriptStatus_TRANSCRIPT_STATUS_WARNING     TranscriptStatus = 2 // forbidden keywords detected
+	TranscriptStatus_TRANSCRIPT_STATUS_ERROR       TranscriptStatus = 3 // transcription failed
+	TranscriptStatus_TRANSCRIPT_STATUS_EMPTY       TranscriptStatus = 4 // no speech in the audio
+)
+
+// Enum value maps for TranscriptStatus.
+var (
+	TranscriptStatus_name = map[int32]string{
+		0: "TRANSCRIPT_STATUS_UNSPECIFIED",
+		1: "TRANSCRIPT_STATUS_OK",
+		2: "TRANSCRIPT_STATUS_WARNING",
+		3: "TRANSCRIPT_STATUS_ERROR",
+		4: "TRANSCRIPT_STATUS_EMPTY",
+	}
+	TranscriptStatus_value = map[string]int32{
+		"TRANSCRIPT_STATUS_UNSPECIFIED": 0,
+		"TRANSCRIPT_STATUS_OK":          1,
+		"TRANSCRIPT_STATUS_WARNING":     2,
+		"TRANSCRIPT_STATUS_ERROR":       3,
+		"TRANSCRIPT_STATUS_EMPTY":       4,
+	}
+)
+
+func (x TranscriptStatus) Enum() *TranscriptStatus {
+	p := new(TranscriptStatus)
+	*p = x
+	return p
+}
+
+func (x TranscriptStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TranscriptStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_audio_proto_enumTypes[1].Descriptor()
+}
+
+func (TranscriptStatus) Type() protoreflect.EnumType {
+	return &file_audio_proto_enumTypes[1]
+}
+
+func (x TranscriptStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TranscriptStatus.Descriptor instead.
+func (TranscriptStatus) EnumDescriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{1}
+}
+
+type KeywordSeverity int32
+
+const (
+	KeywordSeverity_KEYWORD_SEVERITY_UNSPECIFIED KeywordSeverity = 0
+	KeywordSeverity_KEYWORD_SEVERITY_LOW         KeywordSeverity = 1
+	KeywordSeverity_KEYWORD_SEVERITY_MEDIUM      KeywordSeverity = 2
+	KeywordSeverity_KEYWORD_SEVERITY_HIGH        KeywordSeverity = 3
+	KeywordSeverity_KEYWORD_SEVERITY_CRITICAL    KeywordSeverity = 4
+)
+
+// Enum value maps for KeywordSeverity.
+var (
+	KeywordSeverity_name = map[int32]string{
+		0: "KEYWORD_SEVERITY_UNSPECIFIED",
+		1: "KEYWORD_SEVERITY_LOW",
+		2: "KEYWORD_SEVERITY_MEDIUM",
+		3: "KEYWORD_SEVERITY_HIGH",
+		4: "KEYWORD_SEVERITY_CRITICAL",
+	}
+	KeywordSeverity_value = map[string]int32{
+		"KEYWORD_SEVERITY_UNSPECIFIED": 0,
+		"KEYWORD_SEVERITY_LOW":         1,
+		"KEYWORD_SEVERITY_MEDIUM":      2,
+		"KEYWORD_SEVERITY_HIGH":        3,
+		"KEYWORD_SEVERITY_CRITICAL":    4,
+	}
+)
+
+func (x KeywordSeverity) Enum() *KeywordSeverity {
+	p := new(KeywordSeverity)
+	*p = x
+	return p
+}
+
+func (x KeywordSeverity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KeywordSeverity) Descriptor() protoreflect.EnumDescriptor {
+	return file_audio_proto_enumTypes[2].Descriptor()
+}
+
+func (KeywordSeverity) Type() protoreflect.EnumType {
+	return &file_audio_proto_enumTypes[2]
+}
+
+func (x KeywordSeverity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KeywordSeverity.Descriptor instead.
+func (KeywordSeverity) EnumDescriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{2}
+}
+
+// enforcement action of the session keyword policy, ordered by strength
+type KeywordAction int32
+
+const (
+	KeywordAction_KEYWORD_ACTION_UNSPECIFIED KeywordAction = 0 // nothing detected
+	KeywordAction_KEYWORD_ACTION_WARN        KeywordAction = 1
+	KeywordAction_KEYWORD_ACTION_MASK        KeywordAction = 2 // matched text is masked in text
+	KeywordAction_KEYWORD_ACTION_ESCALATE    KeywordAction = 3 // session flagged for moderators
+	KeywordAction_KEYWORD_ACTION_TERMINATE   KeywordAction = 4 // last message, the stream ends with PERMISSION_DENIED
+)
+
+// Enum value maps for KeywordAction.
+var (
+	KeywordAction_name = map[int32]string{
+		0: "KEYWORD_ACTION_UNSPECIFIED",
+		1: "KEYWORD_ACTION_WARN",
+		2: "KEYWORD_ACTION_MASK",
+		3: "KEYWORD_ACTION_ESCALATE",
+		4: "KEYWORD_ACTION_TERMINATE",
+	}
+	KeywordAction_value = map[string]int32{
+		"KEYWORD_ACTION_UNSPECIFIED": 0,
+		"KEYWORD_ACTION_WARN":        1,
+		"KEYWORD_ACTION_MASK":        2,
+		"KEYWORD_ACTION_ESCALATE":    3,
+		"KEYWORD_ACTION_TERMINATE":   4,
+	}
+)
+
+func (x KeywordAction) Enum() *KeywordAction {
+	p := new(KeywordAction)
+	*p = x
+	return p
+}
+
+func (x KeywordAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KeywordAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_audio_proto_enumTypes[3].Descriptor()
+}
+
+func (KeywordAction) Type() protoreflect.EnumType {
+	return &file_audio_proto_enumTypes[3]
+}
+
+func (x KeywordAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KeywordAction.Descriptor instead.
+func (KeywordAction) EnumDescriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{3}
+}
+
+type StreamErrorCode int32
+
+const (
+	StreamErrorCode_STREAM_ERROR_CODE_UNSPECIFIED      StreamErrorCode = 0
+	StreamErrorCode_STREAM_ERROR_CODE_AUDIO_CONVERSION StreamErrorCode = 1 // audio bytes couldn't be decoded
+	StreamErrorCode_STREAM_ERROR_CODE_TRANSCRIPTION    StreamErrorCode = 2 // inference failed
+	StreamErrorCode_STREAM_ERROR_CODE_TIMEOUT          StreamErrorCode = 3 // no result in time
+	StreamErrorCode_STREAM_ERROR_CODE_WORKERS_BUSY     StreamErrorCode = 4 // chunk dropped before processing
+)
+
+// Enum value maps for StreamErrorCode.
+var (
+	StreamErrorCode_name = map[int32]string{
+		0: "STREAM_ERROR_CODE_UNSPECIFIED",
+		1: "STREAM_ERROR_CODE_AUDIO_CONVERSION",
+		2: "STREAM_ERROR_CODE_TRANSCRIPTION",
+		3: "STREAM_ERROR_CODE_TIMEOUT",
+		4: "STREAM_ERROR_CODE_WORKERS_BUSY",
+	}
+	StreamErrorCode_value = map[string]int32{
+		"STREAM_ERROR_CODE_UNSPECIFIED":      0,
+		"STREAM_ERROR_CODE_AUDIO_CONVERSION": 1,
+		"STREAM_ERROR_CODE_TRANSCRIPTION":    2,
+		"STREAM_ERROR_CODE_TIMEOUT":          3,
+		"STREAM_ERROR_CODE_WORKERS_BUSY":     4,
+	}
+)
+
+func (x StreamErrorCode) Enum() *StreamErrorCode {
+	p := new(StreamErrorCode)
+	*p = x
+	return p
+}
+
+func (x StreamErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_audio_proto_enumTypes[4].Descriptor()
+}
+
+func (StreamErrorCode) Type() protoreflect.EnumType {
+	return &file_audio_proto_enumTypes[4]
+}
+
+func (x StreamErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamErrorCode.Descriptor instead.
+func (StreamErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{4}
+}
+
+type PiiType int32
+
+const (
+	PiiType_PII_TYPE_UNSPECIFIED PiiType = 0
+	PiiType_PII_TYPE_EMAIL       PiiType = 1
+	PiiType_PII_TYPE_PHONE       PiiType = 2
+	PiiType_PII_TYPE_IBAN        PiiType = 3 // mod 97 checksum validated
+	PiiType_PII_TYPE_CARD        PiiType = 4 // luhn validated
+)
+
+// Enum value maps for PiiType.
+var (
+	PiiType_name = map[int32]string{
+		0: "PII_TYPE_UNSPECIFIED",
+		1: "PII_TYPE_EMAIL",
+		2: "PII_TYPE_PHONE",
+		3: "PII_TYPE_IBAN",
+		4: "PII_TYPE_CARD",
+	}
+	PiiType_value = map[string]int32{
+		"PII_TYPE_UNSPECIFIED": 0,
+		"PII_TYPE_EMAIL":       1,
+		"PII_TYPE_PHONE":       2,
+		"PII_TYPE_IBAN":        3,
+		"PII_TYPE_CARD":        4,
+	}
+)
+
+func (x PiiType) Enum() *PiiType {
+	p := new(PiiType)
+	*p = x
+	return p
+}
+
+func (x PiiType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PiiType) Descriptor() protoreflect.EnumDescriptor {
+	return file_audio_proto_enumTypes[5].Descriptor()
+}
+
+func (PiiType) Type() protoreflect.EnumType {
+	return &file_audio_proto_enumTypes[5]
+}
+
+func (x PiiType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PiiType.Descriptor instead.
+func (PiiType) EnumDescriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{5}
+}
+
+// first message of the stream, before any audio
+type StreamConfig struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SampleRate      uint32                 `protobuf:"varint,1,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"` // in hz, 0 = 16000, 8000 to 192000 resampled to 16000
+	Channels        uint32                 `protobuf:"varint,2,opt,name=channels,proto3" json:"channels,omitempty"`                       // 0 = mono, up to 8 interleaved, downmixed to mono
+	Encoding        AudioEncoding          `protobuf:"varint,3,opt,name=encoding,proto3,enum=audio.AudioEncoding" json:"encoding,omitempty"`
+	Language        string                 `protobuf:"bytes,4,opt,name=language,proto3" json:"language,omitempty"`                                                                           // language hint, i.e. "en" or "haw", empty or "auto" to detect
+	Translate       bool                   `protobuf:"varint,5,opt,name=translate,proto3" json:"translate,omitempty"`                                                                        // translate to english
+	KeywordPolicyId string                 `protobuf:"bytes,6,opt,name=keyword_policy_id,json=keywordPolicyId,proto3" json:"keyword_policy_id,omitempty"`                                    // empty = default policy
+	Metadata        map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // client metadata, i.e. app version, device
+	Vocabulary      []string               `protobuf:"bytes,8,rep,name=vocabulary,proto3" json:"vocabulary,omitempty"`                                                                       // names & domain words of the session, added to the server vocabulary in the whisper prompt
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamConfig) Reset() {
+	*x = StreamConfig{}
+	mi := &file_audio_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamConfig) ProtoMessage() {}
+
+func (x *StreamConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamConfig.ProtoReflect.Descriptor instead.
+func (*StreamConfig) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamConfig) GetSampleRate() uint32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *StreamConfig) GetChannels() uint32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+func (x *StreamConfig) GetEncoding() AudioEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return AudioEncoding_AUDIO_ENCODING_UNSPECIFIED
+}
+
+func (x *StreamConfig) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *StreamConfig) GetTranslate() bool {
+	if x != nil {
+		return x.Translate
+	}
+	return false
+}
+
+func (x *StreamConfig) GetKeywordPolicyId() string {
+	if x != nil {
+		return x.KeywordPolicyId
+	}
+	return ""
+}
+
+func (x *StreamConfig) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *StreamConfig) GetVocabulary() []string {
+	if x != nil {
+		return x.Vocabulary
+	}
+	return nil
+}
+
 type AudioChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*AudioChunk_Data
+	//	*AudioChunk_Config
+	Payload       isAudioChunk_Payload `protobuf_oneof:"payload"`
+	SessionId     string               `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // uuid v7 for user session
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioChunk) Reset() {
+	*x = AudioChunk{}
+	mi := &file_audio_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioChunk) ProtoMessage() {}
+
+func (x *AudioChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioChunk.ProtoReflect.Descriptor instead.
+func (*AudioChunk) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AudioChunk) GetPayload() isAudioChunk_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AudioChunk) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*AudioChunk_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *AudioChunk) GetConfig() *StreamConfig {
+	if x != nil {
+		if x, ok := x.Payload.(*AudioChunk_Config); ok {
+			return x.Config
+		}
+	}
+	return nil
+}
+
+func (x *AudioChunk) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type isAudioChunk_Payload interface {
+	isAudioChunk_Payload()
+}
+
+type AudioChunk_Data struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3,oneof"`
+}
+
+type AudioChunk_Config struct {
+	Config *StreamConfig `protobuf:"bytes,3,opt,name=config,proto3,oneof"`
+}
+
+func (*AudioChunk_Data) isAudioChunk_Payload() {}
+
+func (*AudioChunk_Config) isAudioChunk_Payload() {}
+
+// server reply to StreamConfig
+type StreamConfigAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // why the config was rejected
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamConfigAck) Reset() {
+	*x = StreamConfigAck{}
+	mi := &file_audio_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamConfigAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamConfigAck) ProtoMessage() {}
+
+func (x *StreamConfigAck) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamConfigAck.ProtoReflect.Descriptor instead.
+func (*StreamConfigAck) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamConfigAck) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *StreamConfigAck) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// offsets are relative to the session start
+type TranscriptSegment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartMs       int64                  `protobuf:"varint,1,opt,name=start_ms,json=startMs,proto3" json:"start_ms,omitempty"`
+	EndMs         int64                  `protobuf:"varint,2,opt,name=end_ms,json=endMs,proto3" json:"end_ms,omitempty"`
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TranscriptSegment) Reset() {
+	*x = TranscriptSegment{}
+	mi := &file_audio_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TranscriptSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranscriptSegment) ProtoMessage() {}
+
+func (x *TranscriptSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranscriptSegment.ProtoReflect.Descriptor instead.
+func (*TranscriptSegment) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TranscriptSegment) GetStartMs() int64 {
+	if x != nil {
+		return x.StartMs
+	}
+	return 0
+}
+
+func (x *TranscriptSegment) GetEndMs() int64 {
+	if x != nil {
+		return x.EndMs
+	}
+	return 0
+}
+
+func (x *TranscriptSegment) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// offsets are unicode code points in Transcript.raw_text, end exclusive
+type KeywordMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keyword       string                 `protobuf:"bytes,1,opt,name=keyword,proto3" json:"keyword,omitempty"` // canonical keyword from the config, masked like text when the match is masked
+	Start         int32                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	Text          string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`     // matched text as it appears in raw_text (masked too)
+	Score         float32                `protobuf:"fixed32,5,opt,name=score,proto3" json:"score,omitempty"` // 1 for exact hits, lower for approximate (fuzzy) ones
+	Category      string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
+	Severity      KeywordSeverity        `protobuf:"varint,7,opt,name=severity,proto3,enum=audio.KeywordSeverity" json:"severity,omitempty"`
+	Action        KeywordAction          `protobuf:"varint,8,opt,name=action,proto3,enum=audio.KeywordAction" json:"action,omitempty"`
+	SuppressedBy  string                 `protobuf:"bytes,9,opt,name=suppressed_by,json=suppressedBy,proto3" json:"suppressed_by,omitempty"`                                                // exception that cleared the match, only in suppressed_matches
+	Captures      map[string]string      `protobuf:"bytes,10,rep,name=captures,proto3" json:"captures,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // named captures of regex & template rules
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeywordMatch) Reset() {
+	*x = KeywordMatch{}
+	mi := &file_audio_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeywordMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeywordMatch) ProtoMessage() {}
+
+func (x *KeywordMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeywordMatch.ProtoReflect.Descriptor instead.
+func (*KeywordMatch) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *KeywordMatch) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *KeywordMatch) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *KeywordMatch) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *KeywordMatch) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *KeywordMatch) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *KeywordMatch) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *KeywordMatch) GetSeverity() KeywordSeverity {
+	if x != nil {
+		return x.Severity
+	}
+	return KeywordSeverity_KEYWORD_SEVERITY_UNSPECIFIED
+}
+
+func (x *KeywordMatch) GetAction() KeywordAction {
+	if x != nil {
+		return x.Action
+	}
+	return KeywordAction_KEYWORD_ACTION_UNSPECIFIED
+}
+
+func (x *KeywordMatch) GetSuppressedBy() string {
+	if x != nil {
+		return x.SuppressedBy
+	}
+	return ""
+}
+
+func (x *KeywordMatch) GetCaptures() map[string]string {
+	if x != nil {
+		return x.Captures
+	}
+	return nil
+}
+
+// a chunk was lost, the audio range lets clients resend it
+type StreamError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          StreamErrorCode        `protobuf:"varint,1,opt,name=code,proto3,enum=audio.StreamErrorCode" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ChunkId       uint64                 `protobuf:"varint,3,opt,name=chunk_id,json=chunkId,proto3" json:"chunk_id,omitempty"`
+	StartMs       int64                  `protobuf:"varint,4,opt,name=start_ms,json=startMs,proto3" json:"start_ms,omitempty"` // relative to the session start
+	EndMs         int64                  `protobuf:"varint,5,opt,name=end_ms,json=endMs,proto3" json:"end_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamError) Reset() {
+	*x = StreamError{}
+	mi := &file_audio_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamError) ProtoMessage() {}
+
+func (x *StreamError) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamError.ProtoReflect.Descriptor instead.
+func (*StreamError) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamError) GetCode() StreamErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return StreamErrorCode_STREAM_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *StreamError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StreamError) GetChunkId() uint64 {
+	if x != nil {
+		return x.ChunkId
+	}
+	return 0
+}
+
+func (x *StreamError) GetStartMs() int64 {
+	if x != nil {
+		return x.StartMs
+	}
+	return 0
+}
+
+func (x *StreamError) GetEndMs() int64 {
+	if x != nil {
+		return x.EndMs
+	}
+	return 0
+}
+
+// the session reached a strike level of its keyword policy
+type EscalationEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`      // level name from the policy, e.g. final_warning
+	Strikes       int32                  `protobuf:"varint,2,opt,name=strikes,proto3" json:"strikes,omitempty"` // strikes within the window
+	WindowMs      int64                  `protobuf:"varint,3,opt,name=window_ms,json=windowMs,proto3" json:"window_ms,omitempty"`
+	Terminate     bool                   `protobuf:"varint,4,opt,name=terminate,proto3" json:"terminate,omitempty"`                     // last message, the stream ends with status_code
+	StatusCode    int32                  `protobuf:"varint,5,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"` // grpc status code
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EscalationEvent) Reset() {
+	*x = EscalationEvent{}
+	mi := &file_audio_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EscalationEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EscalationEvent) ProtoMessage() {}
+
+func (x *EscalationEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EscalationEvent.ProtoReflect.Descriptor instead.
+func (*EscalationEvent) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EscalationEvent) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *EscalationEvent) GetStrikes() int32 {
+	if x != nil {
+		return x.Strikes
+	}
+	return 0
+}
+
+func (x *EscalationEvent) GetWindowMs() int64 {
+	if x != nil {
+		return x.WindowMs
+	}
+	return 0
+}
+
+func (x *EscalationEvent) GetTerminate() bool {
+	if x != nil {
+		return x.Terminate
+	}
+	return false
+}
+
+func (x *EscalationEvent) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+// personal data found in the transcript, offsets are unicode code points in Transcript.raw_text
+type PiiMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          PiiType                `protobuf:"varint,1,opt,name=type,proto3,enum=audio.PiiType" json:"type,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"` // as it appears in raw_text (masked too)
+	Start         int32                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`
+	Masked        bool                   `protobuf:"varint,5,opt,name=masked,proto3" json:"masked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PiiMatch) Reset() {
+	*x = PiiMatch{}
+	mi := &file_audio_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PiiMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PiiMatch) ProtoMessage() {}
+
+func (x *PiiMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PiiMatch.ProtoReflect.Descriptor instead.
+func (*PiiMatch) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PiiMatch) GetType() PiiType {
+	if x != nil {
+		return x.Type
+	}
+	return PiiType_PII_TYPE_UNSPECIFIED
+}
+
+func (x *PiiMatch) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *PiiMatch) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *PiiMatch) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *PiiMatch) GetMasked() bool {
+	if x != nil {
+		return x.Masked
+	}
+	return false
+}
+
+type Transcript struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Text              string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"` // formatted for display, use raw_text & status instead
+	Warning           bool                   `protobuf:"varint,2,opt,name=warning,proto3" json:"warning,omitempty"`
+	DetectedKeywords  []string               `protobuf:"bytes,3,rep,name=detected_keywords,json=detectedKeywords,proto3" json:"detected_keywords,omitempty"` // masked keywords as they appear in the text
+	ConfigAck         *StreamConfigAck       `protobuf:"bytes,4,opt,name=config_ack,json=configAck,proto3" json:"config_ack,omitempty"`                      // only set in reply to StreamConfig
+	Status            TranscriptStatus       `protobuf:"varint,5,opt,name=status,proto3,enum=audio.TranscriptStatus" json:"status,omitempty"`
+	RawText           string                 `protobuf:"bytes,6,opt,name=raw_text,json=rawText,proto3" json:"raw_text,omitempty"` // transcript text without display formatting, masked by KEYWORD_ACTION_MASK
+	Segments          []*TranscriptSegment   `protobuf:"bytes,7,rep,name=segments,proto3" json:"segments,omitempty"`
+	KeywordMatches    []*KeywordMatch        `protobuf:"bytes,8,rep,name=keyword_matches,json=keywordMatches,proto3" json:"keyword_matches,omitempty"`
+	Error             *StreamError           `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`                                                   // only set with TRANSCRIPT_STATUS_ERROR
+	ChunkId           uint64                 `protobuf:"varint,10,opt,name=chunk_id,json=chunkId,proto3" json:"chunk_id,omitempty"`                              // server assigned id of the processed audio chunk
+	Language          string                 `protobuf:"bytes,11,opt,name=language,proto3" json:"language,omitempty"`                                            // detected language, selects the keyword list
+	Action            KeywordAction          `protobuf:"varint,12,opt,name=action,proto3,enum=audio.KeywordAction" json:"action,omitempty"`                      // strongest action of keyword_matches
+	Escalation        *EscalationEvent       `protobuf:"bytes,13,opt,name=escalation,proto3" json:"escalation,omitempty"`                                        // only set when a strike level is reached
+	SuppressedMatches []*KeywordMatch        `protobuf:"bytes,14,rep,name=suppressed_matches,json=suppressedMatches,proto3" json:"suppressed_matches,omitempty"` // informational, cleared by allowlist or context exceptions
+	NormalizedText    string                 `protobuf:"bytes,15,opt,name=normalized_text,json=normalizedText,proto3" json:"normalized_text,omitempty"`          // raw_text as seen by keyword matching: folded case, digits, "$500" -> "500 dollars"
+	PiiMatches        []*PiiMatch            `protobuf:"bytes,16,rep,name=pii_matches,json=piiMatches,proto3" json:"pii_matches,omitempty"`                      // when pii detection is enabled on the server
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Transcript) Reset() {
+	*x = Transcript{}
+	mi := &file_audio_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transcript) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transcript) ProtoMessage() {}
+
+func (x *Transcript) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transcript.ProtoReflect.Descriptor instead.
+func (*Transcript) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Transcript) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Transcript) GetWarning() bool {
+	if x != nil {
+		return x.Warning
+	}
+	return false
+}
+
+func (x *Transcript) GetDetectedKeywords() []string {
+	if x != nil {
+		return x.DetectedKeywords
+	}
+	return nil
+}
+
+func (x *Transcript) GetConfigAck() *StreamConfigAck {
+	if x != nil {
+		return x.ConfigAck
+	}
+	return nil
+}
+
+func (x *Transcript) GetStatus() TranscriptStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TranscriptStatus_TRANSCRIPT_STATUS_UNSPECIFIED
+}
+
+func (x *Transcript) GetRawText() string {
+	if x != nil {
+		return x.RawText
+	}
+	return ""
+}
+
+func (x *Transcript) GetSegments() []*TranscriptSegment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+func (x *Transcript) GetKeywordMatches() []*KeywordMatch {
+	if x != nil {
+		return x.KeywordMatches
+	}
+	return nil
+}
+
+func (x *Transcript) GetError() *StreamError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *Transcript) GetChunkId() uint64 {
+	if x != nil {
+		return x.ChunkId
+	}
+	return 0
+}
+
+func (x *Transcript) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Transcript) GetAction() KeywordAction {
+	if x != nil {
+		return x.Action
+	}
+	return KeywordAction_KEYWORD_ACTION_UNSPECIFIED
+}
+
+func (x *Transcript) GetEscalation() *EscalationEvent {
+	if x != nil {
+		return x.Escalation
+	}
+	return nil
+}
+
+func (x *Transcript) GetSuppressedMatches() []*KeywordMatch {
+	if x != nil {
+		return x.SuppressedMatches
+	}
+	return nil
+}
+
+func (x *Transcript) GetNormalizedText() string {
+	if x != nil {
+		return x.NormalizedText
+	}
+	return ""
+}
+
+func (x *Transcript) GetPiiMatches() []*PiiMatch {
+	if x != nil {
+		return x.PiiMatches
+	}
+	return nil
+}
+
+type StrikeStateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // uuid v7 for user session
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AudioChunk) Reset() {
-	*x = AudioChunk{}
-	mi := &file_audio_proto_msgTypes[0]
+func (x *StrikeStateRequest) Reset() {
+	*x = StrikeStateRequest{}
+	mi := &file_audio_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AudioChunk) String() string {
+func (x *StrikeStateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AudioChunk) ProtoMessage() {}
+func (*StrikeStateRequest) ProtoMessage() {}
 
-func (x *AudioChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_audio_proto_msgTypes[0]
+func (x *StrikeStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -54,49 +1210,197 @@ func (x *AudioChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AudioChunk.ProtoReflect.Descriptor instead.
-func (*AudioChunk) Descriptor() ([]byte, []int) {
-	return file_audio_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use StrikeStateRequest.ProtoReflect.Descriptor instead.
+func (*StrikeStateRequest) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *AudioChunk) GetData() []byte {
+func (x *StrikeStateRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type StrikeState struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	SessionId        string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Strikes          int32                  `protobuf:"varint,2,opt,name=strikes,proto3" json:"strikes,omitempty"`                               // within the window
+	TotalStrikes     int32                  `protobuf:"varint,3,opt,name=total_strikes,json=totalStrikes,proto3" json:"total_strikes,omitempty"` // since the session start
+	Level            string                 `protobuf:"bytes,4,opt,name=level,proto3" json:"level,omitempty"`                                    // active level, empty below the first one
+	WindowMs         int64                  `protobuf:"varint,5,opt,name=window_ms,json=windowMs,proto3" json:"window_ms,omitempty"`
+	LastStrikeUnixMs int64                  `protobuf:"varint,6,opt,name=last_strike_unix_ms,json=lastStrikeUnixMs,proto3" json:"last_strike_unix_ms,omitempty"` // 0 without strikes
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *StrikeState) Reset() {
+	*x = StrikeState{}
+	mi := &file_audio_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StrikeState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StrikeState) ProtoMessage() {}
+
+func (x *StrikeState) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StrikeState.ProtoReflect.Descriptor instead.
+func (*StrikeState) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StrikeState) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *StrikeState) GetStrikes() int32 {
+	if x != nil {
+		return x.Strikes
+	}
+	return 0
+}
+
+func (x *StrikeState) GetTotalStrikes() int32 {
+	if x != nil {
+		return x.TotalStrikes
+	}
+	return 0
+}
+
+func (x *StrikeState) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *StrikeState) GetWindowMs() int64 {
+	if x != nil {
+		return x.WindowMs
+	}
+	return 0
+}
+
+func (x *StrikeState) GetLastStrikeUnixMs() int64 {
+	if x != nil {
+		return x.LastStrikeUnixMs
+	}
+	return 0
+}
+
+// a complete recording, split in chunks for the stream workers
+type TranscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Audio         []byte                 `protobuf:"bytes,1,opt,name=audio,proto3" json:"audio,omitempty"`   // a wav or flac file, detected by its header, or raw samples as declared by config
+	Config        *StreamConfig          `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"` // as for streams, the format of wav & flac files comes from their header
+	SessionId     string                 `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TranscribeRequest) Reset() {
+	*x = TranscribeRequest{}
+	mi := &file_audio_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TranscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranscribeRequest) ProtoMessage() {}
+
+func (x *TranscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranscribeRequest.ProtoReflect.Descriptor instead.
+func (*TranscribeRequest) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TranscribeRequest) GetAudio() []byte {
 	if x != nil {
-		return x.Data
+		return x.Audio
 	}
 	return nil
 }
 
-func (x *AudioChunk) GetSessionId() string {
+func (x *TranscribeRequest) GetConfig() *StreamConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *TranscribeRequest) GetSessionId() string {
 	if x != nil {
 		return x.SessionId
 	}
 	return ""
 }
 
-type Transcript struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Text             string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
-	Warning          bool                   `protobuf:"varint,2,opt,name=warning,proto3" json:"warning,omitempty"`
-	DetectedKeywords []string               `protobuf:"bytes,3,rep,name=detected_keywords,json=detectedKeywords,proto3" json:"detected_keywords,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+// transcript of a whole recording, offsets are relative to its start
+type TranscribeResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	RawText           string                 `protobuf:"bytes,1,opt,name=raw_text,json=rawText,proto3" json:"raw_text,omitempty"`             // chunk raw_texts joined by spaces
+	Status            TranscriptStatus       `protobuf:"varint,2,opt,name=status,proto3,enum=audio.TranscriptStatus" json:"status,omitempty"` // WARNING if a chunk warned, else ERROR if a chunk failed, EMPTY without speech
+	Language          string                 `protobuf:"bytes,3,opt,name=language,proto3" json:"language,omitempty"`                          // of the first chunk with speech
+	Segments          []*TranscriptSegment   `protobuf:"bytes,4,rep,name=segments,proto3" json:"segments,omitempty"`
+	KeywordMatches    []*KeywordMatch        `protobuf:"bytes,5,rep,name=keyword_matches,json=keywordMatches,proto3" json:"keyword_matches,omitempty"`          // offsets in raw_text
+	DetectedKeywords  []string               `protobuf:"bytes,6,rep,name=detected_keywords,json=detectedKeywords,proto3" json:"detected_keywords,omitempty"`    // masked keywords as they appear in the text
+	Action            KeywordAction          `protobuf:"varint,7,opt,name=action,proto3,enum=audio.KeywordAction" json:"action,omitempty"`                      // strongest action of keyword_matches
+	SuppressedMatches []*KeywordMatch        `protobuf:"bytes,8,rep,name=suppressed_matches,json=suppressedMatches,proto3" json:"suppressed_matches,omitempty"` // offsets in raw_text
+	PiiMatches        []*PiiMatch            `protobuf:"bytes,9,rep,name=pii_matches,json=piiMatches,proto3" json:"pii_matches,omitempty"`                      // offsets in raw_text
+	Chunks            []*Transcript          `protobuf:"bytes,10,rep,name=chunks,proto3" json:"chunks,omitempty"`                                               // in audio order, with errors & escalations
+	DurationMs        int64                  `protobuf:"varint,11,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Terminated        bool                   `protobuf:"varint,12,opt,name=terminated,proto3" json:"terminated,omitempty"` // stopped at a terminating action or strike level, the rest of the audio isn't transcribed
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *Transcript) Reset() {
-	*x = Transcript{}
-	mi := &file_audio_proto_msgTypes[1]
+func (x *TranscribeResponse) Reset() {
+	*x = TranscribeResponse{}
+	mi := &file_audio_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Transcript) String() string {
+func (x *TranscribeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Transcript) ProtoMessage() {}
+func (*TranscribeResponse) ProtoMessage() {}
 
-func (x *Transcript) ProtoReflect() protoreflect.Message {
-	mi := &file_audio_proto_msgTypes[1]
+func (x *TranscribeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_audio_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -107,49 +1411,265 @@ func (x *Transcript) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Transcript.ProtoReflect.Descriptor instead.
-func (*Transcript) Descriptor() ([]byte, []int) {
-	return file_audio_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use TranscribeResponse.ProtoReflect.Descriptor instead.
+func (*TranscribeResponse) Descriptor() ([]byte, []int) {
+	return file_audio_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *Transcript) GetText() string {
+func (x *TranscribeResponse) GetRawText() string {
 	if x != nil {
-		return x.Text
+		return x.RawText
 	}
 	return ""
 }
 
-func (x *Transcript) GetWarning() bool {
+func (x *TranscribeResponse) GetStatus() TranscriptStatus {
 	if x != nil {
-		return x.Warning
+		return x.Status
 	}
-	return false
+	return TranscriptStatus_TRANSCRIPT_STATUS_UNSPECIFIED
 }
 
-func (x *Transcript) GetDetectedKeywords() []string {
+func (x *TranscribeResponse) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *TranscribeResponse) GetSegments() []*TranscriptSegment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+func (x *TranscribeResponse) GetKeywordMatches() []*KeywordMatch {
+	if x != nil {
+		return x.KeywordMatches
+	}
+	return nil
+}
+
+func (x *TranscribeResponse) GetDetectedKeywords() []string {
 	if x != nil {
 		return x.DetectedKeywords
 	}
 	return nil
 }
 
+func (x *TranscribeResponse) GetAction() KeywordAction {
+	if x != nil {
+		return x.Action
+	}
+	return KeywordAction_KEYWORD_ACTION_UNSPECIFIED
+}
+
+func (x *TranscribeResponse) GetSuppressedMatches() []*KeywordMatch {
+	if x != nil {
+		return x.SuppressedMatches
+	}
+	return nil
+}
+
+func (x *TranscribeResponse) GetPiiMatches() []*PiiMatch {
+	if x != nil {
+		return x.PiiMatches
+	}
+	return nil
+}
+
+func (x *TranscribeResponse) GetChunks() []*Transcript {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *TranscribeResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *TranscribeResponse) GetTerminated() bool {
+	if x != nil {
+		return x.Terminated
+	}
+	return false
+}
+
 var File_audio_proto protoreflect.FileDescriptor
 
 const file_audio_proto_rawDesc = "" +
 	"\n" +
-	"\vaudio.proto\x12\x05audio\"?\n" +
+	"\vaudio.proto\x12\x05audio\"\xff\x02\n" +
+	"\fStreamConfig\x12\x1f\n" +
+	"\vsample_rate\x18\x01 \x01(\rR\n" +
+	"sampleRate\x12\x1a\n" +
+	"\bchannels\x18\x02 \x01(\rR\bchannels\x120\n" +
+	"\bencoding\x18\x03 \x01(\x0e2\x14.audio.AudioEncodingR\bencoding\x12\x1a\n" +
+	"\blanguage\x18\x04 \x01(\tR\blanguage\x12\x1c\n" +
+	"\ttranslate\x18\x05 \x01(\bR\ttranslate\x12*\n" +
+	"\x11keyword_policy_id\x18\x06 \x01(\tR\x0fkeywordPolicyId\x12=\n" +
+	"\bmetadata\x18\a \x03(\v2!.audio.StreamConfig.MetadataEntryR\bmetadata\x12\x1e\n" +
+	"\n" +
+	"vocabulary\x18\b \x03(\tR\n" +
+	"vocabulary\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"{\n" +
 	"\n" +
-	"AudioChunk\x12\x12\n" +
-	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1d\n" +
+	"AudioChunk\x12\x14\n" +
+	"\x04data\x18\x01 \x01(\fH\x00R\x04data\x12-\n" +
+	"\x06config\x18\x03 \x01(\v2\x13.audio.StreamConfigH\x00R\x06config\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x02 \x01(\tR\tsessionId\"g\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionIdB\t\n" +
+	"\apayload\"E\n" +
+	"\x0fStreamConfigAck\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"Y\n" +
+	"\x11TranscriptSegment\x12\x19\n" +
+	"\bstart_ms\x18\x01 \x01(\x03R\astartMs\x12\x15\n" +
+	"\x06end_ms\x18\x02 \x01(\x03R\x05endMs\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\"\x99\x03\n" +
+	"\fKeywordMatch\x12\x18\n" +
+	"\akeyword\x18\x01 \x01(\tR\akeyword\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\x05R\x03end\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12\x14\n" +
+	"\x05score\x18\x05 \x01(\x02R\x05score\x12\x1a\n" +
+	"\bcategory\x18\x06 \x01(\tR\bcategory\x122\n" +
+	"\bseverity\x18\a \x01(\x0e2\x16.audio.KeywordSeverityR\bseverity\x12,\n" +
+	"\x06action\x18\b \x01(\x0e2\x14.audio.KeywordActionR\x06action\x12#\n" +
+	"\rsuppressed_by\x18\t \x01(\tR\fsuppressedBy\x12=\n" +
+	"\bcaptures\x18\n" +
+	" \x03(\v2!.audio.KeywordMatch.CapturesEntryR\bcaptures\x1a;\n" +
+	"\rCapturesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa0\x01\n" +
+	"\vStreamError\x12*\n" +
+	"\x04code\x18\x01 \x01(\x0e2\x16.audio.StreamErrorCodeR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x19\n" +
+	"\bchunk_id\x18\x03 \x01(\x04R\achunkId\x12\x19\n" +
+	"\bstart_ms\x18\x04 \x01(\x03R\astartMs\x12\x15\n" +
+	"\x06end_ms\x18\x05 \x01(\x03R\x05endMs\"\x9d\x01\n" +
+	"\x0fEscalationEvent\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\x12\x18\n" +
+	"\astrikes\x18\x02 \x01(\x05R\astrikes\x12\x1b\n" +
+	"\twindow_ms\x18\x03 \x01(\x03R\bwindowMs\x12\x1c\n" +
+	"\tterminate\x18\x04 \x01(\bR\tterminate\x12\x1f\n" +
+	"\vstatus_code\x18\x05 \x01(\x05R\n" +
+	"statusCode\"\x82\x01\n" +
+	"\bPiiMatch\x12\"\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0e.audio.PiiTypeR\x04type\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x04 \x01(\x05R\x03end\x12\x16\n" +
+	"\x06masked\x18\x05 \x01(\bR\x06masked\"\xc4\x05\n" +
 	"\n" +
 	"Transcript\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\x12\x18\n" +
 	"\awarning\x18\x02 \x01(\bR\awarning\x12+\n" +
-	"\x11detected_keywords\x18\x03 \x03(\tR\x10detectedKeywords2O\n" +
+	"\x11detected_keywords\x18\x03 \x03(\tR\x10detectedKeywords\x125\n" +
+	"\n" +
+	"config_ack\x18\x04 \x01(\v2\x16.audio.StreamConfigAckR\tconfigAck\x12/\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x17.audio.TranscriptStatusR\x06status\x12\x19\n" +
+	"\braw_text\x18\x06 \x01(\tR\arawText\x124\n" +
+	"\bsegments\x18\a \x03(\v2\x18.audio.TranscriptSegmentR\bsegments\x12<\n" +
+	"\x0fkeyword_matches\x18\b \x03(\v2\x13.audio.KeywordMatchR\x0ekeywordMatches\x12(\n" +
+	"\x05error\x18\t \x01(\v2\x12.audio.StreamErrorR\x05error\x12\x19\n" +
+	"\bchunk_id\x18\n" +
+	" \x01(\x04R\achunkId\x12\x1a\n" +
+	"\blanguage\x18\v \x01(\tR\blanguage\x12,\n" +
+	"\x06action\x18\f \x01(\x0e2\x14.audio.KeywordActionR\x06action\x126\n" +
+	"\n" +
+	"escalation\x18\r \x01(\v2\x16.audio.EscalationEventR\n" +
+	"escalation\x12B\n" +
+	"\x12suppressed_matches\x18\x0e \x03(\v2\x13.audio.KeywordMatchR\x11suppressedMatches\x12'\n" +
+	"\x0fnormalized_text\x18\x0f \x01(\tR\x0enormalizedText\x120\n" +
+	"\vpii_matches\x18\x10 \x03(\v2\x0f.audio.PiiMatchR\n" +
+	"piiMatches\"3\n" +
+	"\x12StrikeStateRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\xcd\x01\n" +
+	"\vStrikeState\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x18\n" +
+	"\astrikes\x18\x02 \x01(\x05R\astrikes\x12#\n" +
+	"\rtotal_strikes\x18\x03 \x01(\x05R\ftotalStrikes\x12\x14\n" +
+	"\x05level\x18\x04 \x01(\tR\x05level\x12\x1b\n" +
+	"\twindow_ms\x18\x05 \x01(\x03R\bwindowMs\x12-\n" +
+	"\x13last_strike_unix_ms\x18\x06 \x01(\x03R\x10lastStrikeUnixMs\"u\n" +
+	"\x11TranscribeRequest\x12\x14\n" +
+	"\x05audio\x18\x01 \x01(\fR\x05audio\x12+\n" +
+	"\x06config\x18\x02 \x01(\v2\x13.audio.StreamConfigR\x06config\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\"\xad\x04\n" +
+	"\x12TranscribeResponse\x12\x19\n" +
+	"\braw_text\x18\x01 \x01(\tR\arawText\x12/\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x17.audio.TranscriptStatusR\x06status\x12\x1a\n" +
+	"\blanguage\x18\x03 \x01(\tR\blanguage\x124\n" +
+	"\bsegments\x18\x04 \x03(\v2\x18.audio.TranscriptSegmentR\bsegments\x12<\n" +
+	"\x0fkeyword_matches\x18\x05 \x03(\v2\x13.audio.KeywordMatchR\x0ekeywordMatches\x12+\n" +
+	"\x11detected_keywords\x18\x06 \x03(\tR\x10detectedKeywords\x12,\n" +
+	"\x06action\x18\a \x01(\x0e2\x14.audio.KeywordActionR\x06action\x12B\n" +
+	"\x12suppressed_matches\x18\b \x03(\v2\x13.audio.KeywordMatchR\x11suppressedMatches\x120\n" +
+	"\vpii_matches\x18\t \x03(\v2\x0f.audio.PiiMatchR\n" +
+	"piiMatches\x12)\n" +
+	"\x06chunks\x18\n" +
+	" \x03(\v2\x11.audio.TranscriptR\x06chunks\x12\x1f\n" +
+	"\vduration_ms\x18\v \x01(\x03R\n" +
+	"durationMs\x12\x1e\n" +
+	"\n" +
+	"terminated\x18\f \x01(\bR\n" +
+	"terminated*\xe5\x01\n" +
+	"\rAudioEncoding\x12\x1e\n" +
+	"\x1aAUDIO_ENCODING_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14AUDIO_ENCODING_PCM16\x10\x01\x12\x18\n" +
+	"\x14AUDIO_ENCODING_PCM24\x10\x02\x12\x18\n" +
+	"\x14AUDIO_ENCODING_PCM32\x10\x03\x12\x1a\n" +
+	"\x16AUDIO_ENCODING_FLOAT32\x10\x04\x12\x18\n" +
+	"\x14AUDIO_ENCODING_MULAW\x10\x05\x12\x17\n" +
+	"\x13AUDIO_ENCODING_ALAW\x10\x06\x12\x17\n" +
+	"\x13AUDIO_ENCODING_FLAC\x10\a*\xa8\x01\n" +
+	"\x10TranscriptStatus\x12!\n" +
+	"\x1dTRANSCRIPT_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14TRANSCRIPT_STATUS_OK\x10\x01\x12\x1d\n" +
+	"\x19TRANSCRIPT_STATUS_WARNING\x10\x02\x12\x1b\n" +
+	"\x17TRANSCRIPT_STATUS_ERROR\x10\x03\x12\x1b\n" +
+	"\x17TRANSCRIPT_STATUS_EMPTY\x10\x04*\xa4\x01\n" +
+	"\x0fKeywordSeverity\x12 \n" +
+	"\x1cKEYWORD_SEVERITY_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14KEYWORD_SEVERITY_LOW\x10\x01\x12\x1b\n" +
+	"\x17KEYWORD_SEVERITY_MEDIUM\x10\x02\x12\x19\n" +
+	"\x15KEYWORD_SEVERITY_HIGH\x10\x03\x12\x1d\n" +
+	"\x19KEYWORD_SEVERITY_CRITICAL\x10\x04*\x9c\x01\n" +
+	"\rKeywordAction\x12\x1e\n" +
+	"\x1aKEYWORD_ACTION_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13KEYWORD_ACTION_WARN\x10\x01\x12\x17\n" +
+	"\x13KEYWORD_ACTION_MASK\x10\x02\x12\x1b\n" +
+	"\x17KEYWORD_ACTION_ESCALATE\x10\x03\x12\x1c\n" +
+	"\x18KEYWORD_ACTION_TERMINATE\x10\x04*\xc4\x01\n" +
+	"\x0fStreamErrorCode\x12!\n" +
+	"\x1dSTREAM_ERROR_CODE_UNSPECIFIED\x10\x00\x12&\n" +
+	"\"STREAM_ERROR_CODE_AUDIO_CONVERSION\x10\x01\x12#\n" +
+	"\x1fSTREAM_ERROR_CODE_TRANSCRIPTION\x10\x02\x12\x1d\n" +
+	"\x19STREAM_ERROR_CODE_TIMEOUT\x10\x03\x12\"\n" +
+	"\x1eSTREAM_ERROR_CODE_WORKERS_BUSY\x10\x04*q\n" +
+	"\aPiiType\x12\x18\n" +
+	"\x14PII_TYPE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0ePII_TYPE_EMAIL\x10\x01\x12\x12\n" +
+	"\x0ePII_TYPE_PHONE\x10\x02\x12\x11\n" +
+	"\rPII_TYPE_IBAN\x10\x03\x12\x11\n" +
+	"\rPII_TYPE_CARD\x10\x042\x9a\x02\n" +
 	"\rSpeechService\x12>\n" +
-	"\x10TranscribeStream\x12\x11.audio.AudioChunk\x1a\x11.audio.Transcript\"\x00(\x010\x01B\x14Z\x12protobuf/;protobufb\x06proto3"
+	"\x10TranscribeStream\x12\x11.audio.AudioChunk\x1a\x11.audio.Transcript\"\x00(\x010\x01\x12A\n" +
+	"\x0eGetStrikeState\x12\x19.audio.StrikeStateRequest\x1a\x12.audio.StrikeState\"\x00\x12C\n" +
+	"\n" +
+	"Transcribe\x12\x18.audio.TranscribeRequest\x1a\x19.audio.TranscribeResponse\"\x00\x12A\n" +
+	"\x0eTranscribeLong\x12\x18.audio.TranscribeRequest\x1a\x11.audio.Transcript\"\x000\x01B\x14Z\x12protobuf/;protobufb\x06proto3"
 
 var (
 	file_audio_proto_rawDescOnce sync.Once
@@ -163,19 +1683,70 @@ func file_audio_proto_rawDescGZIP() []byte {
 	return file_audio_proto_rawDescData
 }
 
-var file_audio_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_audio_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_audio_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_audio_proto_goTypes = []any{
-	(*AudioChunk)(nil), // 0: audio.AudioChunk
-	(*Transcript)(nil), // 1: audio.Transcript
+	(AudioEncoding)(0),         // 0: audio.AudioEncoding
+	(TranscriptStatus)(0),      // 1: audio.TranscriptStatus
+	(KeywordSeverity)(0),       // 2: audio.KeywordSeverity
+	(KeywordAction)(0),         // 3: audio.KeywordAction
+	(StreamErrorCode)(0),       // 4: audio.StreamErrorCode
+	(PiiType)(0),               // 5: audio.PiiType
+	(*StreamConfig)(nil),       // 6: audio.StreamConfig
+	(*AudioChunk)(nil),         // 7: audio.AudioChunk
+	(*StreamConfigAck)(nil),    // 8: audio.StreamConfigAck
+	(*TranscriptSegment)(nil),  // 9: audio.TranscriptSegment
+	(*KeywordMatch)(nil),       // 10: audio.KeywordMatch
+	(*StreamError)(nil),        // 11: audio.StreamError
+	(*EscalationEvent)(nil),    // 12: audio.EscalationEvent
+	(*PiiMatch)(nil),           // 13: audio.PiiMatch
+	(*Transcript)(nil),         // 14: audio.Transcript
+	(*StrikeStateRequest)(nil), // 15: audio.StrikeStateRequest
+	(*StrikeState)(nil),        // 16: audio.StrikeState
+	(*TranscribeRequest)(nil),  // 17: audio.TranscribeRequest
+	(*TranscribeResponse)(nil), // 18: audio.TranscribeResponse
+	nil,                        // 19: audio.StreamConfig.MetadataEntry
+	nil,                        // 20: audio.KeywordMatch.CapturesEntry
 }
 var file_audio_proto_depIdxs = []int32{
-	0, // 0: audio.SpeechService.TranscribeStream:input_type -> audio.AudioChunk
-	1, // 1: audio.SpeechService.TranscribeStream:output_type -> audio.Transcript
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: audio.StreamConfig.encoding:type_name -> audio.AudioEncoding
+	19, // 1: audio.StreamConfig.metadata:type_name -> audio.StreamConfig.MetadataEntry
+	6,  // 2: audio.AudioChunk.config:type_name -> audio.StreamConfig
+	2,  // 3: audio.KeywordMatch.severity:type_name -> audio.KeywordSeverity
+	3,  // 4: audio.KeywordMatch.action:type_name -> audio.KeywordAction
+	20, // 5: audio.KeywordMatch.captures:type_name -> audio.KeywordMatch.CapturesEntry
+	4,  // 6: audio.StreamError.code:type_name -> audio.StreamErrorCode
+	5,  // 7: audio.PiiMatch.type:type_name -> audio.PiiType
+	8,  // 8: audio.Transcript.config_ack:type_name -> audio.StreamConfigAck
+	1,  // 9: audio.Transcript.status:type_name -> audio.TranscriptStatus
+	9,  // 10: audio.Transcript.segments:type_name -> audio.TranscriptSegment
+	10, // 11: audio.Transcript.keyword_matches:type_name -> audio.KeywordMatch
+	11, // 12: audio.Transcript.error:type_name -> audio.StreamError
+	3,  // 13: audio.Transcript.action:type_name -> audio.KeywordAction
+	12, // 14: audio.Transcript.escalation:type_name -> audio.EscalationEvent
+	10, // 15: audio.Transcript.suppressed_matches:type_name -> audio.KeywordMatch
+	13, // 16: audio.Transcript.pii_matches:type_name -> audio.PiiMatch
+	6,  // 17: audio.TranscribeRequest.config:type_name -> audio.StreamConfig
+	1,  // 18: audio.TranscribeResponse.status:type_name -> audio.TranscriptStatus
+	9,  // 19: audio.TranscribeResponse.segments:type_name -> audio.TranscriptSegment
+	10, // 20: audio.TranscribeResponse.keyword_matches:type_name -> audio.KeywordMatch
+	3,  // 21: audio.TranscribeResponse.action:type_name -> audio.KeywordAction
+	10, // 22: audio.TranscribeResponse.suppressed_matches:type_name -> audio.KeywordMatch
+	13, // 23: audio.TranscribeResponse.pii_matches:type_name -> audio.PiiMatch
+	14, // 24: audio.TranscribeResponse.chunks:type_name -> audio.Transcript
+	7,  // 25: audio.SpeechService.TranscribeStream:input_type -> audio.AudioChunk
+	15, // 26: audio.SpeechService.GetStrikeState:input_type -> audio.StrikeStateRequest
+	17, // 27: audio.SpeechService.Transcribe:input_type -> audio.TranscribeRequest
+	17, // 28: audio.SpeechService.TranscribeLong:input_type -> audio.TranscribeRequest
+	14, // 29: audio.SpeechService.TranscribeStream:output_type -> audio.Transcript
+	16, // 30: audio.SpeechService.GetStrikeState:output_type -> audio.StrikeState
+	18, // 31: audio.SpeechService.Transcribe:output_type -> audio.TranscribeResponse
+	14, // 32: audio.SpeechService.TranscribeLong:output_type -> audio.Transcript
+	29, // [29:33] is the sub-list for method output_type
+	25, // [25:29] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_audio_proto_init() }
@@ -183,18 +1754,23 @@ func file_audio_proto_init() {
 	if File_audio_proto != nil {
 		return
 	}
+	file_audio_proto_msgTypes[1].OneofWrappers = []any{
+		(*AudioChunk_Data)(nil),
+		(*AudioChunk_Config)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_audio_proto_rawDesc), len(file_audio_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      6,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_audio_proto_goTypes,
 		DependencyIndexes: file_audio_proto_depIdxs,
+		EnumInfos:         file_audio_proto_enumTypes,
 		MessageInfos:      file_audio_proto_msgTypes,
 	}.Build()
 	File_audio_proto = out.File
@@ -20,6 +20,9 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	SpeechService_TranscribeStream_FullMethodName = "/audio.SpeechService/TranscribeStream"
+	SpeechService_GetStrikeState_FullMethodName   = "/audio.SpeechService/GetStrikeState"
+	SpeechService_Transcribe_FullMethodName       = "/audio.SpeechService/Transcribe"
+	SpeechService_TranscribeLong_FullMethodName   = "/audio.SpeechService/TranscribeLong"
 )
 
 // SpeechServiceClient is the client API for SpeechService service.
@@ -28,6 +31,12 @@ const (
 type SpeechServiceClient interface {
 	// bidirectional streaming for real-time feedback
 	TranscribeStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AudioChunk, Transcript], error)
+	// strike state of a live stream or file transcription, NOT_FOUND once it ended
+	GetStrikeState(ctx context.Context, in *StrikeStateRequest, opts ...grpc.CallOption) (*StrikeState, error)
+	// a complete recording, the whole transcript in one reply
+	Transcribe(ctx context.Context, in *TranscribeRequest, opts ...grpc.CallOption) (*TranscribeResponse, error)
+	// a complete recording, the transcript of each chunk as soon as it's done, for long files
+	TranscribeLong(ctx context.Context, in *TranscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Transcript], error)
 }
 
 type speechServiceClient struct {
@@ -51,12 +60,57 @@ func (c *speechServiceClient) TranscribeStream(ctx context.Context, opts ...grpc
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SpeechService_TranscribeStreamClient = grpc.BidiStreamingClient[AudioChunk, Transcript]
 
+func (c *speechServiceClient) GetStrikeState(ctx context.Context, in *StrikeStateRequest, opts ...grpc.CallOption) (*StrikeState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StrikeState)
+	err := c.cc.Invoke(ctx, SpeechService_GetStrikeState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *speechServiceClient) Transcribe(ctx context.Context, in *TranscribeRequest, opts ...grpc.CallOption) (*TranscribeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TranscribeResponse)
+	err := c.cc.Invoke(ctx, SpeechService_Transcribe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *speechServiceClient) TranscribeLong(ctx context.Context, in *TranscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Transcript], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SpeechService_ServiceDesc.Streams[1], SpeechService_TranscribeLong_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TranscribeRequest, Transcript]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SpeechService_TranscribeLongClient = grpc.ServerStreamingClient[Transcript]
+
 // SpeechServiceServer is the server API for SpeechService service.
 // All implementations must embed UnimplementedSpeechServiceServer
 // for forward compatibility.
 type SpeechServiceServer interface {
 	// bidirectional streaming for real-time feedback
 	TranscribeStream(grpc.BidiStreamingServer[AudioChunk, Transcript]) error
+	// strike state of a live stream or file transcription, NOT_FOUND once it ended
+	GetStrikeState(context.Context, *StrikeStateRequest) (*StrikeState, error)
+	// a complete recording, the whole transcript in one reply
+	Transcribe(context.Context, *TranscribeRequest) (*TranscribeResponse, error)
+	// a complete recording, the transcript of each chunk as soon as it's done, for long files
+	TranscribeLong(*TranscribeRequest, grpc.ServerStreamingServer[Transcript]) error
 	mustEmbedUnimplementedSpeechServiceServer()
 }
 
@@ -70,6 +124,15 @@ type UnimplementedSpeechServiceServer struct{}
 func (UnimplementedSpeechServiceServer) TranscribeStream(grpc.BidiStreamingServer[AudioChunk, Transcript]) error {
 	return status.Error(codes.Unimplemented, "method TranscribeStream not implemented")
 }
+func (UnimplementedSpeechServiceServer) GetStrikeState(context.Context, *StrikeStateRequest) (*StrikeState, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStrikeState not implemented")
+}
+func (UnimplementedSpeechServiceServer) Transcribe(context.Context, *TranscribeRequest) (*TranscribeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Transcribe not implemented")
+}
+func (UnimplementedSpeechServiceServer) TranscribeLong(*TranscribeRequest, grpc.ServerStreamingServer[Transcript]) error {
+	return status.Error(codes.Unimplemented, "method TranscribeLong not implemented")
+}
 func (UnimplementedSpeechServiceServer) mustEmbedUnimplementedSpeechServiceServer() {}
 func (UnimplementedSpeechServiceServer) testEmbeddedByValue()                       {}
 
@@ -98,13 +161,69 @@ func _SpeechService_TranscribeStream_Handler(srv interface{}, stream grpc.Server
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SpeechService_TranscribeStreamServer = grpc.BidiStreamingServer[AudioChunk, Transcript]
 
+func _SpeechService_GetStrikeState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StrikeStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeechServiceServer).GetStrikeState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpeechService_GetStrikeState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeechServiceServer).GetStrikeState(ctx, req.(*StrikeStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpeechService_Transcribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TranscribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeechServiceServer).Transcribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpeechService_Transcribe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeechServiceServer).Transcribe(ctx, req.(*TranscribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpeechService_TranscribeLong_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TranscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SpeechServiceServer).TranscribeLong(m, &grpc.GenericServerStream[TranscribeRequest, Transcript]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SpeechService_TranscribeLongServer = grpc.ServerStreamingServer[Transcript]
+
 // SpeechService_ServiceDesc is the grpc.ServiceDesc for SpeechService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var SpeechService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "audio.SpeechService",
 	HandlerType: (*SpeechServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStrikeState",
+			Handler:    _SpeechService_GetStrikeState_Handler,
+		},
+		{
+			MethodName: "Transcribe",
+			Handler:    _SpeechService_Transcribe_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "TranscribeStream",
@@ -112,6 +231,11 @@ var SpeechService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "TranscribeLong",
+			Handler:       _SpeechService_TranscribeLong_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "audio.proto",
 }
@@ -0,0 +1,173 @@
+package unit_test
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	pkg_convert "showcase-backend-audio_transcriber-go/pkg/convert"
+)
+
+// sine returns seconds of a mono sine wave
+func sine(rate int, freq, amplitude, seconds float64) []float32 {
+	out := make([]float32, int(float64(rate)*seconds))
+	for i := range out {
+		out[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return out
+}
+
+// toneLevel correlates the samples with a sine & cosine of freq, returns the amplitude found
+func toneLevel(samples []float32, rate int, freq float64) float64 {
+	var re, im float64
+	for i, s := range samples {
+		angle := 2 * math.Pi * freq * float64(i) / float64(rate)
+		re += float64(s) * math.Cos(angle)
+		im += float64(s) * math.Sin(angle)
+	}
+	return 2 * math.Hypot(re, im) / float64(len(samples))
+}
+
+// skip the filter warm up & the tail
+func steady(samples []float32) []float32 {
+	return samples[len(samples)/10 : len(samples)*9/10]
+}
+
+func TestResamplerKeepsTone(t *testing.T) {
+	for _, rate := range []int{8000, 22050, 44100, 48000} {
+		t.Run(fmt.Sprintf("%d", rate), func(t *testing.T) {
+			r, err := pkg_convert.NewResampler(rate, 16000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := r.Process(sine(rate, 1000, 0.5, 1))
+
+			if n := len(out); n < 16000-100 || n > 16000 {
+				t.Errorf("expected about 16000 samples, got %d", n)
+			}
+			if level := toneLevel(steady(out), 16000, 1000); math.Abs(level-0.5) > 0.01 {
+				t.Errorf("1 kHz level %.4f, expected 0.5", level)
+			}
+			if level := toneLevel(steady(out), 16000, 1100); level > 0.01 {
+				t.Errorf("tone leaked to 1.1 kHz: %.4f", level)
+			}
+		})
+	}
+}
+
+func TestResamplerOddRates(t *testing.T) {
+	// gcd 1 with 16000, the filter table must stay small
+	for _, rate := range []int{8001, 44101, 191999} {
+		t.Run(fmt.Sprintf("%d", rate), func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			r, err := pkg_convert.NewResampler(rate, 16000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runtime.ReadMemStats(&after)
+			if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 4<<20 {
+				t.Errorf("filter table of %d bytes", alloc)
+			}
+
+			out := r.Process(sine(rate, 1000, 0.5, 1))
+			if level := toneLevel(steady(out), 16000, 1000); math.Abs(level-0.5) > 0.01 {
+				t.Errorf("1 kHz level %.4f, expected 0.5", level)
+			}
+			if level := toneLevel(steady(out), 16000, 1100); level > 0.01 {
+				t.Errorf("tone leaked to 1.1 kHz: %.4f", level)
+			}
+		})
+	}
+}
+
+func TestResamplerRejectsAliases(t *testing.T) {
+	// 12 kHz is above the 8 kHz nyquist of the output, it must not fold back to 4 kHz
+	r, _ := pkg_convert.NewResampler(48000, 16000)
+	out := r.Process(sine(48000, 12000, 0.5, 1))
+	if level := toneLevel(steady(out), 16000, 4000); level > 0.001 {
+		t.Errorf("aliased level %.5f", level)
+	}
+}
+
+func TestResamplerChunked(t *testing.T) {
+	in := sine(44100, 440, 0.8, 0.5)
+
+	whole, _ := pkg_convert.NewResampler(44100, 16000)
+	want := append(whole.Process(in), whole.Flush()...)
+
+	chunked, _ := pkg_convert.NewResampler(44100, 16000)
+	var got []float32
+	for i := 0; i < len(in); i += 333 {
+		got = append(got, chunked.Process(in[i:min(i+333, len(in))])...)
+	}
+	got = append(got, chunked.Flush()...)
+
+	if len(got) != len(want) {
+		t.Fatalf("chunked length %d, one shot %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+			t.Fatalf("sample %d differs: %f vs %f", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDownmix(t *testing.T) {
+	got := pkg_convert.Downmix([]float32{1, 0, 0.5, 0.5, -1, 1, 0.2}, 2)
+	want := []float32{0.5, 0.5, 0}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestFormatValidate(t *testing.T) {
+	tests := []struct {
+		format  pkg_convert.Format
+		wantErr bool
+	}{
+		{pkg_convert.Format{SampleRate: 16000, Channels: 1}, false},
+		{pkg_convert.Format{SampleRate: 44100, Channels: 2}, false},
+		{pkg_convert.Format{SampleRate: 192000, Channels: 8}, false},
+		{pkg_convert.Format{SampleRate: 4000, Channels: 1}, true},
+		{pkg_convert.Format{SampleRate: 48000, Channels: 9}, true},
+		{pkg_convert.Format{SampleRate: 48000, Channels: 0}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.format.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: error = %v, wantErr %v", tt.format, err, tt.wantErr)
+		}
+	}
+}
+
+// real time factor: processing time / audio duration, must stay far below 1
+func BenchmarkConverterRealTime(b *testing.B) {
+	for _, format := range []pkg_convert.Format{
+		{SampleRate: 8000, Channels: 1},
+		{SampleRate: 44100, Channels: 2},
+		{SampleRate: 48000, Channels: 2},
+	} {
+		// one second of interleaved audio
+		mono := sine(format.SampleRate, 440, 0.5, 1)
+		in := make([]float32, 0, len(mono)*format.Channels)
+		for _, s := range mono {
+			for c := 0; c < format.Channels; c++ {
+				in = append(in, s)
+			}
+		}
+
+		b.Run(fmt.Sprintf("%dhz_%dch", format.SampleRate, format.Channels), func(b *testing.B) {
+			converter, err := pkg_convert.NewConverter(format, 16000)
+			if err != nil {
+				b.Fatal(err)
+			}
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				converter.Convert(in)
+			}
+			b.ReportMetric(time.Since(start).Seconds()/float64(b.N), "rtf")
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package unit_test
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"showcase-backend-audio_transcriber-go/pkg"
+)
+
+func decoder(t *testing.T, name string) pkg.Decoder {
+	t.Helper()
+	d, ok := pkg.LookupDecoder(name)
+	if !ok {
+		t.Fatalf("no %s decoder registered", name)
+	}
+	return d
+}
+
+func TestDecoderNames(t *testing.T) {
+	want := []string{"alaw", "float32", "mulaw", "pcm16", "pcm24", "pcm32"}
+	if got := pkg.DecoderNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// itu-t g.711 code <-> linear pairs: segment edges, zero & full scale
+func TestMulawReferenceTable(t *testing.T) {
+	table := []struct {
+		code   byte
+		linear int16
+	}{
+		{0x00, -32124}, {0x0F, -16764}, {0x10, -15996}, {0x70, -120}, {0x7E, -8}, {0x7F, 0},
+		{0x80, 32124}, {0x8F, 16764}, {0x90, 15996}, {0xF0, 120}, {0xFE, 8}, {0xFF, 0},
+	}
+	for _, tt := range table {
+		if got := pkg.MulawDecode(tt.code); got != tt.linear {
+			t.Errorf("decode 0x%02X: expected %d, got %d", tt.code, tt.linear, got)
+		}
+	}
+
+	// every code survives decode -> encode, except negative zero
+	for code := 0; code < 256; code++ {
+		if code == 0x7F {
+			continue
+		}
+		if got := pkg.MulawEncode(pkg.MulawDecode(byte(code))); got != byte(code) {
+			t.Errorf("round trip 0x%02X -> 0x%02X", code, got)
+		}
+	}
+	if got := pkg.MulawEncode(32767); got != 0x80 {
+		t.Errorf("full scale clips to 0x80, got 0x%02X", got)
+	}
+}
+
+func TestAlawReferenceTable(t *testing.T) {
+	table := []struct {
+		code   byte
+		linear int16
+	}{
+		{0x55, -8}, {0xD5, 8}, {0x54, -24}, {0xD4, 24}, {0x45, -264}, {0xC5, 264},
+		{0x2A, -32256}, {0xAA, 32256}, {0x35, -8448}, {0xB5, 8448},
+	}
+	for _, tt := range table {
+		if got := pkg.AlawDecode(tt.code); got != tt.linear {
+			t.Errorf("decode 0x%02X: expected %d, got %d", tt.code, tt.linear, got)
+		}
+	}
+
+	for code := 0; code < 256; code++ {
+		if got := pkg.AlawEncode(pkg.AlawDecode(byte(code))); got != byte(code) {
+			t.Errorf("round trip 0x%02X -> 0x%02X", code, got)
+		}
+	}
+	if got := pkg.AlawEncode(-32768); got != 0x2A {
+		t.Errorf("negative full scale clips to 0x2A, got 0x%02X", got)
+	}
+}
+
+// linear -> g.711 -> linear stays within half a quantization step (1/16 of the segment)
+func TestG711QuantizationError(t *testing.T) {
+	for v := -32768; v <= 32767; v += 7 {
+		sample := int16(v)
+		step := math.Max(16, math.Abs(float64(v))/16)
+		if got := pkg.MulawDecode(pkg.MulawEncode(sample)); math.Abs(float64(got)-float64(min(max(v, -32635), 32635))) > step {
+			t.Fatalf("mu-law %d -> %d", v, got)
+		}
+		if got := pkg.AlawDecode(pkg.AlawEncode(sample)); math.Abs(float64(got)-float64(v)) > step {
+			t.Fatalf("a-law %d -> %d", v, got)
+		}
+	}
+}
+
+func TestLinearDecoders(t *testing.T) {
+	pcm24 := func(v int32) []byte { return []byte{byte(v), byte(v >> 8), byte(v >> 16)} }
+	pcm32 := func(v int32) []byte { return binary.LittleEndian.AppendUint32(nil, uint32(v)) }
+	float := func(f float32) []byte { return binary.LittleEndian.AppendUint32(nil, math.Float32bits(f)) }
+
+	tests := []struct {
+		encoding string
+		data     []byte
+		want     float32
+	}{
+		{"pcm16", []byte{0x00, 0x80}, -1},
+		{"pcm16", []byte{0x00, 0x40}, 0.5},
+		{"pcm24", pcm24(-8388608), -1},
+		{"pcm24", pcm24(4194304), 0.5},
+		{"pcm24", pcm24(-1), -1.0 / 8388608},
+		{"pcm32", pcm32(math.MinInt32), -1},
+		{"pcm32", pcm32(-1073741824), -0.5},
+		{"float32", float(0.25), 0.25},
+		{"float32", float(-0.75), -0.75},
+		{"float32", float(1.5), 1},
+		{"float32", float(float32(math.NaN())), 0},
+		{"mulaw", []byte{0x80}, 32124.0 / 32768},
+		{"alaw", []byte{0x2A}, -32256.0 / 32768},
+	}
+	for _, tt := range tests {
+		got, err := decoder(t, tt.encoding).Decode(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.encoding, err)
+			continue
+		}
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s % X: expected %v, got %v", tt.encoding, tt.data, tt.want, got)
+		}
+	}
+}
+
+// pcm24 round trip over the full range
+func TestPcm24RoundTrip(t *testing.T) {
+	d := decoder(t, "pcm24")
+	for v := int32(-8388608); v < 8388608; v += 4099 {
+		got, _ := d.Decode([]byte{byte(v), byte(v >> 8), byte(v >> 16)})
+		if back := int32(math.Round(float64(got[0]) * 8388608)); back != v {
+			t.Fatalf("%d -> %d", v, back)
+		}
+	}
+}
+
+func TestDecoderRejectsPartialSample(t *testing.T) {
+	if _, err := decoder(t, "pcm24").Decode(make([]byte, 7)); err == nil {
+		t.Error("expected an error for 7 bytes of pcm24")
+	}
+	if _, err := decoder(t, "mulaw").Decode(make([]byte, 7)); err != nil {
+		t.Errorf("8-bit audio has no partial sample: %v", err)
+	}
+}
@@ -0,0 +1,671 @@
+package unit_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"math"
+	"math/bits"
+	"math/rand"
+	"strings"
+	"testing"
+
+	pkg_flac "showcase-backend-audio_transcriber-go/pkg/flac"
+)
+
+// --- test encoder, written from the format spec (rfc 9639) to produce every subframe & header variant ---
+
+type bitWriter struct {
+	buf   []byte
+	nbits uint
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	for n > 0 {
+		n--
+		if w.nbits%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>n&1 == 1 {
+			w.buf[len(w.buf)-1] |= 0x80 >> (w.nbits % 8)
+		}
+		w.nbits++
+	}
+}
+
+func (w *bitWriter) writeSigned(v int64, n uint) { w.write(uint64(v)&(1<<n-1), n) }
+
+func (w *bitWriter) writeUnary(q uint64) {
+	for ; q > 0; q-- {
+		w.write(0, 1)
+	}
+	w.write(1, 1)
+}
+
+func (w *bitWriter) align() { w.nbits = uint(len(w.buf)) * 8 }
+
+// bit by bit crcs, independent of the decoder tables
+func specCRC(data []byte, poly uint32, width uint) uint32 {
+	var crc uint32
+	top := uint32(1) << (width - 1)
+	for _, b := range data {
+		crc ^= uint32(b) << (width - 8)
+		for range 8 {
+			if crc&top != 0 {
+				crc = crc<<1 ^ poly
+			} else {
+				crc <<= 1
+			}
+			crc &= 1<<width - 1
+		}
+	}
+	return crc
+}
+
+// subframe encodes one channel
+type subframe struct {
+	kind      string // constant, verbatim, fixed, lpc
+	order     int
+	coefs     []int64 // lpc, coefs[0] weights the previous sample
+	precision uint
+	shift     int
+	wasted    uint
+	partition uint64 // residual partition order
+	escape    bool   // unencoded residual partitions
+	rice2     bool   // 5-bit rice parameters
+}
+
+type flacFrame struct {
+	channels   [][]int64 // left, right, ... before decorrelation
+	assignment int       // 0 independent, 8 left/side, 9 side/right, 10 mid/side
+	subframes  []subframe
+	rateCode   uint64 // 0 takes the stream rate
+	sizeCode   bool   // explicit sample size code, stream info otherwise
+}
+
+type flacStream struct {
+	sampleRate int
+	bps        int
+	frames     []flacFrame
+	variable   bool // variable blocking, coded sample numbers
+}
+
+func (s flacStream) channels() int { return len(s.frames[0].channels) }
+
+// encode returns the stream, the md5 is computed over the input samples
+func (s flacStream) encode() []byte {
+	sum := md5.New()
+	var frames []byte
+	total, minBlock, maxBlock := 0, 65535, 0
+	for i, f := range s.frames {
+		n := len(f.channels[0])
+		for j := range n {
+			for _, ch := range f.channels {
+				for b := range (s.bps + 7) / 8 {
+					sum.Write([]byte{byte(ch[j] >> (8 * b))})
+				}
+			}
+		}
+		number := uint64(i)
+		if s.variable {
+			number = uint64(total)
+		}
+		frames = append(frames, s.encodeFrame(f, number)...)
+		total += n
+		minBlock, maxBlock = min(minBlock, n), max(maxBlock, n)
+	}
+
+	info := binaryBE16(uint16(minBlock))
+	info = append(info, binaryBE16(uint16(maxBlock))...)
+	info = append(info, 0, 0, 0, 0, 0, 0) // frame sizes unknown
+	w := &bitWriter{}
+	w.write(uint64(s.sampleRate), 20)
+	w.write(uint64(s.channels()-1), 3)
+	w.write(uint64(s.bps-1), 5)
+	w.write(uint64(total), 36)
+	info = append(info, w.buf...)
+	info = append(info, sum.Sum(nil)...)
+
+	out := []byte("fLaC")
+	// a padding block after STREAMINFO, skipped by the decoder
+	out = append(out, 0x00, 0, 0, 34)
+	out = append(out, info...)
+	out = append(out, 0x81, 0, 0, 5, 0, 0, 0, 0, 0)
+	return append(out, frames...)
+}
+
+func binaryBE16(v uint16) []byte { return []byte{byte(v >> 8), byte(v)} }
+
+func (s flacStream) encodeFrame(f flacFrame, number uint64) []byte {
+	n := len(f.channels[0])
+	w := &bitWriter{}
+	w.write(0x3FFE, 14)
+	w.write(0, 1)
+	if s.variable {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+
+	var sizeCode, sizeExtra uint64
+	var sizeExtraBits uint
+	switch {
+	case n == 192:
+		sizeCode = 1
+	case n%576 == 0 && bits.OnesCount(uint(n/576)) == 1 && n <= 4608:
+		sizeCode = 2 + uint64(bits.TrailingZeros(uint(n/576)))
+	case n%256 == 0 && bits.OnesCount(uint(n/256)) == 1 && n <= 32768:
+		sizeCode = 8 + uint64(bits.TrailingZeros(uint(n/256)))
+	case n <= 256:
+		sizeCode, sizeExtra, sizeExtraBits = 6, uint64(n-1), 8
+	default:
+		sizeCode, sizeExtra, sizeExtraBits = 7, uint64(n-1), 16
+	}
+	w.write(sizeCode, 4)
+	w.write(f.rateCode, 4)
+	if f.assignment == 0 {
+		w.write(uint64(len(f.channels)-1), 4)
+	} else {
+		w.write(uint64(f.assignment), 4)
+	}
+	if f.sizeCode {
+		w.write(uint64(map[int]int{8: 1, 12: 2, 16: 4, 20: 5, 24: 6, 32: 7}[s.bps]), 3)
+	} else {
+		w.write(0, 3)
+	}
+	w.write(0, 1)
+	w.buf = append(w.buf, codedNumber(number)...)
+	w.nbits += uint(len(codedNumber(number))) * 8
+	w.write(sizeExtra, sizeExtraBits)
+	switch f.rateCode {
+	case 12:
+		w.write(uint64(s.sampleRate/1000), 8)
+	case 13:
+		w.write(uint64(s.sampleRate), 16)
+	case 14:
+		w.write(uint64(s.sampleRate/10), 16)
+	}
+	w.write(uint64(specCRC(w.buf, 0x07, 8)), 8)
+
+	// decorrelation, the side channel gets one more bit
+	chans := f.channels
+	bps := []uint{}
+	for range chans {
+		bps = append(bps, uint(s.bps))
+	}
+	if f.assignment != 0 {
+		left, right := chans[0], chans[1]
+		side := make([]int64, n)
+		mid := make([]int64, n)
+		for i := range n {
+			side[i] = left[i] - right[i]
+			mid[i] = (left[i] + right[i]) >> 1
+		}
+		switch f.assignment {
+		case 8:
+			chans, bps[1] = [][]int64{left, side}, bps[1]+1
+		case 9:
+			chans, bps[0] = [][]int64{side, right}, bps[0]+1
+		case 10:
+			chans, bps[1] = [][]int64{mid, side}, bps[1]+1
+		}
+	}
+	for i, ch := range chans {
+		f.subframes[i].encode(w, ch, bps[i])
+	}
+
+	w.align()
+	crc := specCRC(w.buf, 0x8005, 16)
+	return append(w.buf, byte(crc>>8), byte(crc))
+}
+
+func codedNumber(v uint64) []byte {
+	if v < 0x80 {
+		return []byte{byte(v)}
+	}
+	// payload bits: 11 for 2 bytes, +5 per extra byte
+	size := 2
+	for v >= 1<<(5*size+1) {
+		size++
+	}
+	out := make([]byte, size)
+	for i := size - 1; i > 0; i-- {
+		out[i] = 0x80 | byte(v&0x3F)
+		v >>= 6
+	}
+	out[0] = byte(0xFF<<(8-size)) | byte(v)
+	return out
+}
+
+func (sf subframe) encode(w *bitWriter, samples []int64, bps uint) {
+	kinds := map[string]uint64{"constant": 0, "verbatim": 1, "fixed": 0x08 | uint64(sf.order), "lpc": 0x20 | uint64(sf.order-1)}
+	w.write(kinds[sf.kind], 7) // zero padding bit & type
+	if sf.wasted > 0 {
+		w.write(1, 1)
+		w.writeUnary(uint64(sf.wasted - 1))
+	} else {
+		w.write(0, 1)
+	}
+
+	s := make([]int64, len(samples))
+	for i, v := range samples {
+		s[i] = v >> sf.wasted
+	}
+	bps -= sf.wasted
+
+	switch sf.kind {
+	case "constant":
+		w.writeSigned(s[0], bps)
+		return
+	case "verbatim":
+		for _, v := range s {
+			w.writeSigned(v, bps)
+		}
+		return
+	}
+
+	for _, v := range s[:sf.order] {
+		w.writeSigned(v, bps)
+	}
+	residual := make([]int64, len(s))
+	for i := sf.order; i < len(s); i++ {
+		var pred int64
+		switch {
+		case sf.kind == "lpc":
+			for j, c := range sf.coefs {
+				pred += c * s[i-1-j]
+			}
+			pred >>= sf.shift
+		case sf.order == 1:
+			pred = s[i-1]
+		case sf.order == 2:
+			pred = 2*s[i-1] - s[i-2]
+		case sf.order == 3:
+			pred = 3*s[i-1] - 3*s[i-2] + s[i-3]
+		case sf.order == 4:
+			pred = 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+		}
+		residual[i] = s[i] - pred
+	}
+	if sf.kind == "lpc" {
+		w.write(uint64(sf.precision-1), 4)
+		w.writeSigned(int64(sf.shift), 5)
+		for _, c := range sf.coefs {
+			w.writeSigned(c, sf.precision)
+		}
+	}
+
+	paramBits := uint(4)
+	if sf.rice2 {
+		paramBits = 5
+		w.write(1, 2)
+	} else {
+		w.write(0, 2)
+	}
+	// the largest order up to the requested one the block size allows
+	partition := sf.partition
+	for partition > 0 && (len(s)%(1<<partition) != 0 || len(s)>>partition < sf.order) {
+		partition--
+	}
+	w.write(partition, 4)
+	parts := 1 << partition
+	for p := range parts {
+		start, end := p*len(s)/parts, (p+1)*len(s)/parts
+		if p == 0 {
+			start = sf.order
+		}
+		part := residual[start:end]
+		if sf.escape {
+			n := uint(0)
+			for _, v := range part {
+				if v != 0 {
+					n = max(n, uint(bits.Len64(uint64(v^v>>63)))+1)
+				}
+			}
+			w.write(1<<paramBits-1, paramBits)
+			w.write(uint64(n), 5)
+			for _, v := range part {
+				w.writeSigned(v, n)
+			}
+			continue
+		}
+		var sumAbs uint64
+		for _, v := range part {
+			sumAbs += uint64(max(v, -v))
+		}
+		param := uint(0)
+		if len(part) > 0 {
+			param = min(uint(bits.Len64(sumAbs/uint64(len(part)))), 1<<paramBits-2)
+		}
+		w.write(uint64(param), paramBits)
+		for _, v := range part {
+			u := uint64(v<<1) ^ uint64(v>>63)
+			w.writeUnary(u >> param)
+			w.write(u&(1<<param-1), param)
+		}
+	}
+}
+
+// testSignal is a sine with noise, within bps bits & a multiple of 1<<wasted
+func testSignal(n, bps int, seed int64, wasted uint) []int64 {
+	rng := rand.New(rand.NewSource(seed))
+	peak := float64(int64(1)<<(bps-1)) * 0.6
+	out := make([]int64, n)
+	for i := range out {
+		v := peak*math.Sin(float64(i)*0.05+float64(seed)) + peak/20*rng.NormFloat64()
+		out[i] = int64(v) >> wasted << wasted
+	}
+	return out
+}
+
+// decoded floats back to integers of bps bits
+func flacInts(samples []float32, bps int) []int64 {
+	out := make([]int64, len(samples))
+	for i, s := range samples {
+		out[i] = int64(math.Round(float64(s) * float64(int64(1)<<(bps-1))))
+	}
+	return out
+}
+
+func interleave(channels [][]int64) []int64 {
+	var out []int64
+	for i := range channels[0] {
+		for _, ch := range channels {
+			out = append(out, ch[i])
+		}
+	}
+	return out
+}
+
+func checkFlac(t *testing.T, s flacStream) {
+	t.Helper()
+	samples, info, err := pkg_flac.DecodeAll(bytes.NewReader(s.encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Channels != s.channels() || info.BitsPerSample != s.bps || info.SampleRate != s.sampleRate {
+		t.Errorf("unexpected stream info %+v", info)
+	}
+	var want []int64
+	for _, f := range s.frames {
+		want = append(want, interleave(f.channels)...)
+	}
+	got := flacInts(samples, s.bps)
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d samples, expected %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: expected %d, got %d", i, want[i], got[i])
+		}
+	}
+}
+
+// --- tests ---
+
+// rfc 9639 appendix d.1: one stereo sample, verbatim subframes with wasted bits
+func TestFlacSpecExample(t *testing.T) {
+	file, _ := hex.DecodeString("664c614380000022100010000000" + "0f00000f0ac442f000000001" +
+		"3e84b41807dc690307586a3dad1a2e0f" + "fff869180000bf0358fd03128baa9a")
+	samples, info, err := pkg_flac.DecodeAll(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SampleRate != 44100 || info.Channels != 2 || info.BitsPerSample != 16 || info.TotalSamples != 1 {
+		t.Errorf("unexpected stream info %+v", info)
+	}
+	if got := flacInts(samples, 16); len(got) != 2 || got[0] != 25588 || got[1] != 10416 {
+		t.Errorf("expected [25588 10416], got %v", got)
+	}
+}
+
+func TestFlacSubframeTypes(t *testing.T) {
+	lpc8 := []int64{1200, -300, 150, -80, 40, -20, 10, -5}
+	lpc32 := make([]int64, 32)
+	lpc32[0], lpc32[1] = 3900, -1900
+
+	tests := map[string]subframe{
+		"verbatim":        {kind: "verbatim"},
+		"fixed0":          {kind: "fixed", order: 0},
+		"fixed1":          {kind: "fixed", order: 1},
+		"fixed2":          {kind: "fixed", order: 2, partition: 3},
+		"fixed3":          {kind: "fixed", order: 3},
+		"fixed4":          {kind: "fixed", order: 4, partition: 2},
+		"lpc1":            {kind: "lpc", order: 1, coefs: []int64{15}, precision: 5, shift: 4},
+		"lpc8":            {kind: "lpc", order: 8, coefs: lpc8, precision: 12, shift: 10, partition: 4},
+		"lpc32":           {kind: "lpc", order: 32, coefs: lpc32, precision: 13, shift: 11},
+		"escape":          {kind: "fixed", order: 2, partition: 1, escape: true},
+		"rice2":           {kind: "lpc", order: 2, coefs: []int64{3900, -1900}, precision: 13, shift: 11, rice2: true},
+		"rice2 escape":    {kind: "fixed", order: 1, rice2: true, escape: true},
+		"wasted bits":     {kind: "fixed", order: 2, wasted: 3},
+		"wasted verbatim": {kind: "verbatim", wasted: 1},
+	}
+	for name, sf := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, bps := range []int{16, 24} {
+				var frames []flacFrame
+				// block sizes of every header code: 192, 576 * 2^n, 256 * 2^n, 8 & 16 bit sizes
+				for i, n := range []int{1024, 192, 576, 100, 1000, 4608} {
+					signal := testSignal(n, bps, int64(i), sf.wasted)
+					frames = append(frames, flacFrame{channels: [][]int64{signal}, subframes: []subframe{sf}, sizeCode: i%2 == 0})
+				}
+				checkFlac(t, flacStream{sampleRate: 16000, bps: bps, frames: frames})
+			}
+		})
+	}
+
+	// constant blocks, silence & dc
+	checkFlac(t, flacStream{sampleRate: 8000, bps: 16, frames: []flacFrame{
+		{channels: [][]int64{make([]int64, 4096)}, subframes: []subframe{{kind: "constant"}}},
+		{channels: [][]int64{testSignal(300, 16, 1, 0)[:1]}, subframes: []subframe{{kind: "verbatim"}}},
+	}})
+	dc := make([]int64, 256)
+	for i := range dc {
+		dc[i] = -1234
+	}
+	checkFlac(t, flacStream{sampleRate: 8000, bps: 16, frames: []flacFrame{{channels: [][]int64{dc}, subframes: []subframe{{kind: "constant"}}}}})
+}
+
+func TestFlacChannelAssignments(t *testing.T) {
+	stereo := func(bps int) [][]int64 {
+		return [][]int64{testSignal(2048, bps, 1, 0), testSignal(2048, bps, 2, 0)}
+	}
+	fixed := subframe{kind: "fixed", order: 2, partition: 2}
+
+	for _, bps := range []int{16, 24} {
+		for _, assignment := range []int{0, 8, 9, 10} {
+			checkFlac(t, flacStream{sampleRate: 44100, bps: bps, frames: []flacFrame{
+				{channels: stereo(bps), assignment: assignment, subframes: []subframe{fixed, fixed}},
+				{channels: stereo(bps), assignment: assignment, subframes: []subframe{{kind: "verbatim"}, fixed}},
+			}})
+		}
+	}
+
+	// 8 independent channels, 24-bit
+	var channels [][]int64
+	var subframes []subframe
+	for ch := range 8 {
+		channels = append(channels, testSignal(1152, 24, int64(ch), 0))
+		subframes = append(subframes, subframe{kind: "fixed", order: ch % 5})
+	}
+	checkFlac(t, flacStream{sampleRate: 48000, bps: 24, frames: []flacFrame{{channels: channels, subframes: subframes}}})
+}
+
+func TestFlacHeaderVariants(t *testing.T) {
+	sf := []subframe{{kind: "fixed", order: 1}}
+	for _, tt := range []struct {
+		rate     int
+		rateCode uint64
+	}{
+		{44100, 9}, {16000, 5}, {8000, 12}, {11025, 13}, {22050, 6}, {32000, 14},
+	} {
+		var frames []flacFrame
+		for i := range 3 {
+			frames = append(frames, flacFrame{channels: [][]int64{testSignal(256, 16, int64(i), 0)}, subframes: sf, rateCode: tt.rateCode})
+		}
+		// long streams need multi byte coded numbers
+		checkFlac(t, flacStream{sampleRate: tt.rate, bps: 16, frames: frames, variable: true})
+	}
+
+	var frames []flacFrame
+	for i := range 200 {
+		frames = append(frames, flacFrame{channels: [][]int64{testSignal(16, 16, int64(i), 0)}, subframes: sf})
+	}
+	checkFlac(t, flacStream{sampleRate: 16000, bps: 16, frames: frames})
+}
+
+func testStream() flacStream {
+	var frames []flacFrame
+	for i := range 6 {
+		frames = append(frames, flacFrame{
+			channels:   [][]int64{testSignal(1152, 16, int64(i), 0), testSignal(1152, 16, int64(i+10), 0)},
+			assignment: 10,
+			subframes:  []subframe{{kind: "fixed", order: 2}, {kind: "lpc", order: 2, coefs: []int64{3900, -1900}, precision: 13, shift: 11}},
+		})
+	}
+	return flacStream{sampleRate: 48000, bps: 16, frames: frames}
+}
+
+func TestFlacChunkedDecoding(t *testing.T) {
+	file := testStream().encode()
+	want, _, err := pkg_flac.DecodeAll(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{1, 7, 333, 4096} {
+		d := pkg_flac.NewDecoder()
+		var got []float32
+		for i := 0; i < len(file); i += size {
+			samples, err := d.Decode(file[i:min(i+size, len(file))])
+			if err != nil {
+				t.Fatalf("chunks of %d: %v", size, err)
+			}
+			got = append(got, samples...)
+		}
+		if err := d.Close(); err != nil {
+			t.Errorf("chunks of %d: %v", size, err)
+		}
+		if !slicesEqual(got, want) {
+			t.Errorf("chunks of %d: decoded %d samples, expected %d", size, len(got), len(want))
+		}
+		if info, ok := d.Info(); !ok || info.Channels != 2 || info.TotalSamples != 6*1152 {
+			t.Errorf("unexpected stream info %+v", info)
+		}
+	}
+}
+
+func slicesEqual(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFlacMD5Mismatch(t *testing.T) {
+	file := testStream().encode()
+	// md5 is the last 16 bytes of STREAMINFO, after the marker, the block header & 18 bytes
+	file[4+4+18] ^= 0xFF
+
+	_, _, err := pkg_flac.DecodeAll(bytes.NewReader(file))
+	if err == nil || !strings.Contains(err.Error(), "md5 mismatch") {
+		t.Errorf("expected md5 mismatch, got %v", err)
+	}
+}
+
+func TestFlacCorruptFrame(t *testing.T) {
+	s := testStream()
+	file := s.encode()
+	header := len(file) - len(bytes.Join(func() [][]byte {
+		var frames [][]byte
+		for i, f := range s.frames {
+			frames = append(frames, s.encodeFrame(f, uint64(i)))
+		}
+		return frames
+	}(), nil))
+
+	// a bit flip inside the second frame fails its crc
+	frameSize := len(s.encodeFrame(s.frames[0], 0))
+	file[header+frameSize+100] ^= 0x10
+
+	d := pkg_flac.NewDecoder()
+	samples, err := d.Decode(file)
+	if err == nil || !strings.Contains(err.Error(), "crc") {
+		t.Errorf("expected a crc error, got %v", err)
+	}
+	// the other 5 frames are still decoded
+	if len(samples) != 5*1152*2 {
+		t.Errorf("expected 5 frames after resync, got %d samples", len(samples))
+	}
+	if err := d.Close(); err == nil || !strings.Contains(err.Error(), "1 corrupt frames") {
+		t.Errorf("expected the corrupt frame at close, got %v", err)
+	}
+}
+
+func TestFlacErrors(t *testing.T) {
+	file := testStream().encode()
+
+	tests := []struct {
+		name string
+		file []byte
+		want string
+	}{
+		{"truncated", file[:len(file)-5], "truncated"},
+		{"header only", file[:20], "ended before its stream info"},
+		{"not flac", []byte("RIFF....WAVEfmt "), "not a flac stream"},
+		{"no streaminfo", append([]byte("fLaC\x81\x00\x00\x00"), 0), "expected STREAMINFO"},
+		{"short streaminfo", []byte("fLaC\x80\x00\x00\x10"), "STREAMINFO of 16 bytes"},
+	}
+	for _, tt := range tests {
+		_, _, err := pkg_flac.DecodeAll(bytes.NewReader(tt.file))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error with %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	info, err := pkg_flac.ReadStreamInfo(bytes.NewReader(file))
+	if err != nil || info.SampleRate != 48000 || info.Duration().Milliseconds() != 144 {
+		t.Errorf("stream info %+v, %v", info, err)
+	}
+}
+
+func TestFlacHeaderLimits(t *testing.T) {
+	// streaminfo of a valid stream, more metadata blocks follow
+	header := append([]byte(nil), testStream().encode()[:4+4+34]...)
+	header[4] &^= 0x80
+
+	blocks := append([]byte(nil), header...)
+	for range 300 {
+		blocks = append(blocks, 0x01, 0, 0, 0) // empty padding
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"id3 of 256 MB", []byte("ID3\x04\x00\x00\x7F\x7F\x7F\x7F"), "id3 tag of"},
+		{"16 MB block", append(append([]byte(nil), header...), 0x01, 0xFF, 0xFF, 0xFF), "metadata of more than"},
+		{"endless blocks", blocks, "metadata blocks"},
+	}
+	for _, tt := range tests {
+		// refused as soon as the declared sizes are known, not once buffered
+		d := pkg_flac.NewDecoder()
+		if _, err := d.Decode(tt.data); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error with %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func BenchmarkFlacDecode(b *testing.B) {
+	file := testStream().encode()
+	b.SetBytes(int64(len(file)))
+	for i := 0; i < b.N; i++ {
+		pkg_flac.DecodeAll(bytes.NewReader(file))
+	}
+}
@@ -0,0 +1,66 @@
+package unit_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"showcase-backend-audio_transcriber-go/pkg"
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+)
+
+const benchTranscript = "hello everyone welcome back to the stream, today we are cooking pasta " +
+	"and later I will show you how to transfer the money to the winner of the giveaway, " +
+	"don't forget to like and subscribe, the train to the city leaves at five"
+
+// benchKeywords generates n unique two word terms, plus the ones present in the transcript
+func benchKeywords(n int) []string {
+	terms := []string{"transfer the money", "train"}
+	for i := len(terms); i < n; i++ {
+		terms = append(terms, fmt.Sprintf("term%d word%d", i, i%97))
+	}
+	return terms
+}
+
+// matching time should stay flat when the list grows
+func BenchmarkMatcherListSize(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000, 50000} {
+		matcher, err := pkg_keyword.NewMatcher(pkg_keyword.Words(benchKeywords(n)...))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("keywords=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				matcher.Match(benchTranscript)
+			}
+		})
+	}
+}
+
+// matching time should grow linearly with the transcript length
+func BenchmarkMatcherTextLength(b *testing.B) {
+	matcher, err := pkg_keyword.NewMatcher(pkg_keyword.Words(benchKeywords(10000)...))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, repeat := range []int{1, 4, 16} {
+		text := strings.Repeat(benchTranscript+" ", repeat)
+		b.Run(fmt.Sprintf("chars=%d", len(text)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				matcher.Match(text)
+			}
+		})
+	}
+}
+
+// baseline, loops & lowercases every keyword per transcript
+func BenchmarkContainsKeywordsListSize(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		keywords := benchKeywords(n)
+		b.Run(fmt.Sprintf("keywords=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pkg.ContainsKeywords(benchTranscript, keywords)
+			}
+		})
+	}
+}
@@ -0,0 +1,630 @@
+package unit_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	pkg_keyword "showcase-backend-audio_transcriber-go/pkg/keyword"
+)
+
+func newMatcher(t *testing.T, keywords []pkg_keyword.Keyword) *pkg_keyword.Matcher {
+	t.Helper()
+	matcher, err := pkg_keyword.NewMatcher(keywords)
+	if err != nil {
+		t.Fatalf("NewMatcher() error = %v", err)
+	}
+	return matcher
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := pkg_keyword.Tokenize("Don't — wire the money, señor!")
+	want := []pkg_keyword.Token{
+		{Text: "don't", Start: 0, End: 5},
+		{Text: "wire", Start: 8, End: 12},
+		{Text: "the", Start: 13, End: 16},
+		{Text: "money", Start: 17, End: 22},
+		{Text: "señor", Start: 24, End: 29},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("expected %d tokens, got %v", len(want), tokens)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("token %d: got %+v, want %+v", i, tokens[i], want[i])
+		}
+	}
+}
+
+func TestMatcherWholeWord(t *testing.T) {
+	matcher := newMatcher(t, pkg_keyword.Words("train"))
+
+	for _, text := range []string{"we are training", "a muscle strain", "time constraint"} {
+		if matches := matcher.Match(text); len(matches) != 0 {
+			t.Errorf("%q: unexpected matches %v", text, matches)
+		}
+	}
+
+	matches := matcher.Match("Take the Train.")
+	if len(matches) != 1 || matches[0].Text != "Train" || matches[0].Start != 9 || matches[0].End != 14 {
+		t.Errorf("unexpected matches %v", matches)
+	}
+}
+
+func TestMatcherPhraseIgnoresPunctuation(t *testing.T) {
+	matcher := newMatcher(t, pkg_keyword.Words("transfer the money"))
+
+	matches := matcher.Match("Please transfer, the money... now")
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %v", matches)
+	}
+	if matches[0].Keyword != "transfer the money" || matches[0].Text != "transfer, the money" {
+		t.Errorf("unexpected match %+v", matches[0])
+	}
+
+	if matches := matcher.Match("transfer all the money"); len(matches) != 0 {
+		t.Errorf("phrase should not match with a word in between, got %v", matches)
+	}
+}
+
+func TestMatcherFuzzy(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "transfer", Mode: pkg_keyword.ModeFuzzy},
+		{Term: "money", Mode: pkg_keyword.ModeFuzzy},
+	})
+
+	matches := matcher.Match("please trans fur the mony")
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %v", matches)
+	}
+	if matches[0].Keyword != "transfer" || matches[0].Text != "trans fur" {
+		t.Errorf("unexpected match %+v", matches[0])
+	}
+	if matches[1].Keyword != "money" || matches[1].Text != "mony" {
+		t.Errorf("unexpected match %+v", matches[1])
+	}
+	for _, match := range matches {
+		if match.Score <= 0 || match.Score >= 1 {
+			t.Errorf("approximate match %q should score in (0, 1), got %v", match.Text, match.Score)
+		}
+	}
+
+	if matches := matcher.Match("the money"); len(matches) != 1 || matches[0].Score != 1 {
+		t.Errorf("exact hit should score 1, got %v", matches)
+	}
+	if matches := matcher.Match("monkey business"); len(matches) != 1 {
+		t.Errorf("one edit should be tolerated, got %v", matches)
+	}
+	if matches := matcher.Match("many transformers"); len(matches) != 0 {
+		t.Errorf("unexpected matches %v", matches)
+	}
+
+	// a short word before the keyword fits the wider window too, the exact hit wins
+	for text, want := range map[string]string{"give me a money now": "money", "please to transfer it": "transfer"} {
+		matches := matcher.Match(text)
+		if len(matches) != 1 || matches[0].Text != want || matches[0].Score != 1 {
+			t.Errorf("%q: expected the exact hit %q, got %v", text, want, matches)
+		}
+	}
+}
+
+func TestMatcherFuzzyPhonetic(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "phone", Mode: pkg_keyword.ModeFuzzy, Distance: 1, Phonetic: true},
+	})
+
+	// "fone" is 2 edits away but sounds the same
+	matches := matcher.Match("call my fone")
+	if len(matches) != 1 || matches[0].Text != "fone" || matches[0].Score >= 1 {
+		t.Errorf("unexpected matches %v", matches)
+	}
+
+	// near-homophones share the consonant codes, not the word
+	money := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "money", Mode: pkg_keyword.ModeFuzzy, Distance: 1, Phonetic: true},
+	})
+	for _, text := range []string{"the man", "many people", "what you mean", "a moon", "mine"} {
+		if matches := money.Match(text); len(matches) != 0 {
+			t.Errorf("%q: unexpected matches %v", text, matches)
+		}
+	}
+	if matches := money.Match("send the monney"); len(matches) != 1 {
+		t.Errorf("expected one edit to match, got %v", matches)
+	}
+
+	strict := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "phone", Mode: pkg_keyword.ModeFuzzy, Distance: 1},
+	})
+	if matches := strict.Match("call my fone"); len(matches) != 0 {
+		t.Errorf("without phonetic expected no match, got %v", matches)
+	}
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	tests := []struct {
+		word      string
+		primary   string
+		alternate string
+	}{
+		{"Smith", "SM0", "XMT"},
+		{"Schmidt", "XMT", "SMT"},
+		{"knight", "NT", "NT"},
+		{"phone", "FN", "FN"},
+		{"transfer", "TRNSFR", "TRNSFR"},
+		{"transfur", "TRNSFR", "TRNSFR"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		primary, alternate := pkg_keyword.DoubleMetaphone(tt.word)
+		if primary != tt.primary || alternate != tt.alternate {
+			t.Errorf("DoubleMetaphone(%q) = %q, %q, want %q, %q", tt.word, primary, alternate, tt.primary, tt.alternate)
+		}
+	}
+}
+
+func TestMatcherStem(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "transfer", Mode: pkg_keyword.ModeWord, Stem: true},
+		{Term: "wire money", Mode: pkg_keyword.ModeWord, Stem: true},
+	})
+
+	text := "he transferred it, transfers and wired monies"
+	terms := pkg_keyword.MatchedTerms(matcher.Match(text))
+	if len(terms) != 1 || terms[0] != "transfer" {
+		t.Errorf("unexpected terms %v", terms)
+	}
+	if matches := matcher.Match(text); len(matches) != 2 {
+		t.Errorf("expected 2 transfer matches, got %v", matches)
+	}
+
+	if matches := matcher.Match("we wired moneys"); len(matches) != 1 {
+		t.Errorf("expected stemmed phrase match, got %v", matches)
+	}
+}
+
+func TestMatcherSubstringMode(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{{Term: "train", Mode: pkg_keyword.ModeSubstring}})
+
+	matches := matcher.Match("Training for a strain")
+	if len(matches) != 2 {
+		t.Fatalf("expected legacy substring matches, got %v", matches)
+	}
+	if matches[0].Text != "Train" || matches[1].Start != 16 {
+		t.Errorf("unexpected matches %v", matches)
+	}
+}
+
+func TestKeywordUnmarshalJSON(t *testing.T) {
+	var keywords []pkg_keyword.Keyword
+	err := json.Unmarshal([]byte(`["train", {"term": "money", "stem": true}, {"term": "bomb", "mode": "substring"}]`), &keywords)
+	if err != nil {
+		t.Fatalf("unmarshal error = %v", err)
+	}
+
+	want := []pkg_keyword.Keyword{
+		{Term: "train", Mode: pkg_keyword.ModeWord},
+		{Term: "money", Mode: pkg_keyword.ModeWord, Stem: true},
+		{Term: "bomb", Mode: pkg_keyword.ModeSubstring},
+	}
+	for i := range want {
+		if keywords[i] != want[i] {
+			t.Errorf("keyword %d: got %+v, want %+v", i, keywords[i], want[i])
+		}
+	}
+
+	if _, err := pkg_keyword.NewMatcher([]pkg_keyword.Keyword{{Term: "x", Mode: "glob"}}); err == nil {
+		t.Error("expected unknown mode to fail")
+	}
+}
+
+func TestMatcherOverlappingPatterns(t *testing.T) {
+	matcher := newMatcher(t, append(
+		pkg_keyword.Words("money", "the money", "transfer the money"),
+		pkg_keyword.Keyword{Term: "he", Mode: pkg_keyword.ModeSubstring},
+		pkg_keyword.Keyword{Term: "hers", Mode: pkg_keyword.ModeSubstring},
+	))
+
+	terms := pkg_keyword.MatchedTerms(matcher.Match("transfer the money"))
+	if len(terms) != 4 {
+		t.Errorf("expected nested phrases and substrings, got %v", terms)
+	}
+
+	matches := matcher.Match("ushers")
+	if len(matches) != 2 || matches[0].Keyword != "he" || matches[1].Keyword != "hers" {
+		t.Errorf("unexpected substring matches %v", matches)
+	}
+}
+
+// enLists builds a single english word list
+func enLists(terms ...string) pkg_keyword.Lists {
+	return pkg_keyword.Lists{
+		Forbidden:       map[string][]pkg_keyword.Keyword{"en": pkg_keyword.Words(terms...)},
+		DefaultLanguage: "en",
+	}
+}
+
+func TestEngineUpdate(t *testing.T) {
+	engine, err := pkg_keyword.NewEngine(enLists("train"))
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	snapshot, _ := engine.Matcher("en")
+	if err := engine.Update(enLists("money")); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// in-flight matches keep the snapshot they loaded
+	if len(snapshot.Match("the train")) != 1 {
+		t.Error("old snapshot should still match the old list")
+	}
+	current, _ := engine.Matcher("en")
+	if len(current.Match("the train")) != 0 || len(current.Match("money")) != 1 {
+		t.Error("engine should match the new list")
+	}
+
+	// a failed update keeps the previous list
+	version := engine.Version()
+	if err := engine.Update(pkg_keyword.Lists{Forbidden: map[string][]pkg_keyword.Keyword{"en": {{Term: ""}}}, DefaultLanguage: "en"}); err == nil {
+		t.Error("expected empty term to fail")
+	}
+	current, _ = engine.Matcher("en")
+	if engine.Version() != version || len(current.Match("money")) != 1 {
+		t.Error("failed update must keep the previous matcher")
+	}
+}
+
+func TestEngineConcurrentUpdate(t *testing.T) {
+	engine, _ := pkg_keyword.NewEngine(enLists("train"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			engine.Update(enLists("train", "money"))
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		matcher, _ := engine.Matcher("en")
+		if len(matcher.Match("take the train")) != 1 {
+			t.Fatal("train should match in every snapshot")
+		}
+	}
+	<-done
+}
+
+func TestEngineLanguageLists(t *testing.T) {
+	engine, err := pkg_keyword.NewEngine(pkg_keyword.Lists{
+		Forbidden: map[string][]pkg_keyword.Keyword{
+			"en": pkg_keyword.Words("transfer the money"),
+			"id": pkg_keyword.Words("transfer uang", "kirim uang"),
+		},
+		DefaultLanguage: "en",
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	matcher, language := engine.Matcher("ID")
+	if language != "id" || len(matcher.Match("tolong kirim uang sekarang")) != 1 {
+		t.Errorf("expected indonesian list, got %q", language)
+	}
+
+	// no japanese list, fall back to the default
+	matcher, language = engine.Matcher("ja")
+	if language != "en" || len(matcher.Match("transfer the money")) != 1 {
+		t.Errorf("expected default english list, got %q", language)
+	}
+
+	lists := enLists("train")
+	lists.DefaultLanguage = "id"
+	if _, err := pkg_keyword.NewEngine(lists); err == nil {
+		t.Error("expected missing default list to fail")
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "damn", Category: "profanity", Severity: pkg_keyword.SeverityLow},
+		{Term: "gift card", Category: "scam", Severity: pkg_keyword.SeverityCritical},
+		{Term: "money"},
+	})
+	policy := pkg_keyword.Policy{
+		Rules: []pkg_keyword.PolicyRule{
+			{Category: "profanity", Action: pkg_keyword.ActionMask},
+			{MinSeverity: pkg_keyword.SeverityHigh, Action: pkg_keyword.ActionEscalate},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	matches, action := policy.Apply(matcher.Match("damn, send money in a gift card"))
+	if action != pkg_keyword.ActionEscalate {
+		t.Errorf("expected escalate, got %q", action)
+	}
+	want := []pkg_keyword.Action{pkg_keyword.ActionMask, pkg_keyword.ActionWarn, pkg_keyword.ActionEscalate}
+	if len(matches) != len(want) {
+		t.Fatalf("expected %d matches, got %v", len(want), matches)
+	}
+	for i := range want {
+		if matches[i].Action != want[i] {
+			t.Errorf("match %q: got action %q, want %q", matches[i].Keyword, matches[i].Action, want[i])
+		}
+	}
+	if matches[1].Severity != pkg_keyword.SeverityMedium {
+		t.Errorf("expected default medium severity, got %q", matches[1].Severity)
+	}
+
+	if masked := pkg_keyword.MaskText("damn, send money in a gift card", matches); masked != "****, send money in a **** ****" {
+		t.Errorf("unexpected masked text %q", masked)
+	}
+
+	if _, action := policy.Apply(nil); action != "" {
+		t.Errorf("expected no action without matches, got %q", action)
+	}
+}
+
+func TestMaskingStyles(t *testing.T) {
+	text := "so damn, send money now"
+	matches := []pkg_keyword.Match{
+		{Keyword: "damn", Start: 3, End: 7, Category: "profanity", Action: pkg_keyword.ActionWarn},
+		{Keyword: "send money", Start: 9, End: 19, Category: "scam", Action: pkg_keyword.ActionMask},
+		{Keyword: "money", Start: 14, End: 19, Action: pkg_keyword.ActionWarn, Captures: map[string]string{"what": "money"}},
+	}
+
+	tests := []struct {
+		masking pkg_keyword.Masking
+		text    string
+		last    string // text of the last match in the masked text
+	}{
+		{pkg_keyword.Masking{}, "so damn, **** ***** now", "*****"},
+		{pkg_keyword.Masking{All: true}, "so ****, **** ***** now", "*****"},
+		{pkg_keyword.Masking{Style: pkg_keyword.MaskCategory}, "so damn, [scam] now", "[scam]"},
+		{pkg_keyword.Masking{Style: pkg_keyword.MaskCategory, All: true}, "so [profanity], [scam] now", "[scam]"},
+		{pkg_keyword.Masking{Style: pkg_keyword.MaskReplacement, Replacement: "<beep>", All: true}, "so <beep>, <beep> now", "<beep>"},
+	}
+	for _, tt := range tests {
+		masked := tt.masking.Apply(text, matches)
+		if masked.Text != tt.text {
+			t.Errorf("%+v: masked text %q, want %q", tt.masking, masked.Text, tt.text)
+		}
+		moved := masked.Matches(matches)
+		if got := moved[2].Text; got != tt.last {
+			t.Errorf("%+v: last match text %q, want %q", tt.masking, got, tt.last)
+		}
+		if tt.masking.All && moved[2].Captures["what"] == "money" {
+			t.Errorf("%+v: captures of masked matches must be masked", tt.masking)
+		}
+		if moved[1].Keyword != tt.masking.Label(matches[1]) || moved[1].Keyword == "send money" {
+			t.Errorf("%+v: keyword of a masked match %q, want its label", tt.masking, moved[1].Keyword)
+		}
+		if !tt.masking.All && moved[0].Keyword != "damn" {
+			t.Errorf("%+v: keyword of an unmasked match changed to %q", tt.masking, moved[0].Keyword)
+		}
+	}
+
+	// offsets after a replacement shift with it
+	masked := pkg_keyword.Masking{Style: pkg_keyword.MaskCategory}.Apply(text, matches)
+	if start, end := masked.Span(20, 23); start != 16 || end != 19 {
+		t.Errorf("Span(20, 23) = %d, %d, want 16, 19", start, end)
+	}
+
+	if err := (pkg_keyword.Masking{Style: pkg_keyword.MaskReplacement}).Validate(); err == nil {
+		t.Error("replacement style without text should be invalid")
+	}
+	if err := (pkg_keyword.Masking{Style: "blur"}).Validate(); err == nil {
+		t.Error("unknown style should be invalid")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	invalid := []pkg_keyword.Policy{
+		{DefaultAction: "ban"},
+		{Rules: []pkg_keyword.PolicyRule{{Category: "scam"}}},
+		{Rules: []pkg_keyword.PolicyRule{{MinSeverity: "extreme", Action: pkg_keyword.ActionWarn}}},
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("expected error for %+v", policy)
+		}
+	}
+
+	if _, err := pkg_keyword.NewMatcher([]pkg_keyword.Keyword{{Term: "scam", Severity: "extreme"}}); err == nil {
+		t.Error("expected error for unknown keyword severity")
+	}
+}
+
+func TestStrikeTrackerSlidingWindow(t *testing.T) {
+	policy := pkg_keyword.StrikePolicy{
+		WindowSec: 300,
+		Levels: []pkg_keyword.StrikeLevel{
+			{Name: "final_warning", Strikes: 3},
+			{Name: "end", Strikes: 5, Terminate: true},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	tracker := pkg_keyword.NewStrikeTracker(policy)
+	start := time.Unix(0, 0)
+
+	var reached []string
+	for _, at := range []time.Duration{0, time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		if _, level := tracker.Strike(start.Add(at)); level != nil {
+			reached = append(reached, level.Name)
+		}
+	}
+	if len(reached) != 1 || reached[0] != "final_warning" {
+		t.Errorf("expected final_warning once, got %v", reached)
+	}
+
+	// the first two strikes leave the window
+	state := tracker.State(start.Add(6*time.Minute + time.Second))
+	if state.Strikes != 2 || state.Total != 4 || state.Level != "" {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	// back to 3 strikes, the level is reached again
+	if _, level := tracker.Strike(start.Add(6*time.Minute + 30*time.Second)); level == nil || level.Name != "final_warning" {
+		t.Errorf("expected final_warning again, got %v", level)
+	}
+}
+
+func TestStrikePolicyValidate(t *testing.T) {
+	invalid := []pkg_keyword.StrikePolicy{
+		{WindowSec: -1},
+		{Levels: []pkg_keyword.StrikeLevel{{Strikes: 3}}},
+		{Levels: []pkg_keyword.StrikeLevel{{Name: "a", Strikes: 3}, {Name: "b", Strikes: 3}}},
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("expected error for %+v", policy)
+		}
+	}
+}
+
+func TestExceptionsAllowAndContext(t *testing.T) {
+	lists := enLists("train", "money")
+	lists.Exceptions = map[string]pkg_keyword.Exceptions{
+		"en": {
+			Allow: []string{"train station"},
+			Context: []pkg_keyword.ContextRule{
+				{Keyword: "money", After: []string{"back guarantee"}},
+				{Keyword: "train", Before: []string{"personal"}},
+			},
+		},
+	}
+	engine, err := pkg_keyword.NewEngine(lists)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	matches, suppressed := engine.Snapshot().Match("en", "Meet at the Train Station, money back guarantee, then train")
+	if len(matches) != 1 || matches[0].Keyword != "train" || matches[0].Start != 54 {
+		t.Errorf("expected only the last train, got %v", matches)
+	}
+	if len(suppressed) != 2 || suppressed[0].SuppressedBy == "" || suppressed[1].SuppressedBy == "" {
+		t.Fatalf("expected 2 suppressed matches with reasons, got %v", suppressed)
+	}
+	if suppressed[0].Keyword != "train" || suppressed[1].Keyword != "money" {
+		t.Errorf("unexpected suppressed matches %v", suppressed)
+	}
+
+	if matches, _ := engine.Snapshot().Match("en", "my personal train"); len(matches) != 0 {
+		t.Errorf("before context should clear the match, got %v", matches)
+	}
+	if matches, _ := engine.Snapshot().Match("en", "train personal"); len(matches) != 1 {
+		t.Errorf("before context must precede the keyword, got %v", matches)
+	}
+
+	// a policy adds its own exceptions on top
+	policy := pkg_keyword.Policy{Exceptions: map[string]pkg_keyword.Exceptions{"en": {Allow: []string{"then train"}}}}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	kept, cleared := policy.Suppress("en", "then train", []pkg_keyword.Match{{Keyword: "train", Start: 5, End: 10}})
+	if len(kept) != 0 || len(cleared) != 1 {
+		t.Errorf("policy exception should clear the match, got %v / %v", kept, cleared)
+	}
+
+	invalid := []pkg_keyword.Exceptions{
+		{Allow: []string{"..."}},
+		{Context: []pkg_keyword.ContextRule{{Keyword: "train"}}},
+		{Context: []pkg_keyword.ContextRule{{After: []string{"station"}}}},
+	}
+	for _, exceptions := range invalid {
+		if _, err := pkg_keyword.NewExceptionSet(exceptions); err == nil {
+			t.Errorf("expected error for %+v", exceptions)
+		}
+	}
+}
+
+func TestMatcherTemplate(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "send {amount:number} dollars", Name: "send money", Mode: pkg_keyword.ModeTemplate, Category: "scam"},
+		{Term: "account {number:digits}", Mode: pkg_keyword.ModeTemplate},
+	})
+
+	matches := matcher.Match("Okay, SEND 1,500 dollars to account 4 1 1 2-7 now")
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %v", matches)
+	}
+	if matches[0].Keyword != "send money" || matches[0].Text != "SEND 1,500 dollars" || matches[0].Start != 6 || matches[0].Captures["amount"] != "1500" {
+		t.Errorf("unexpected match %+v", matches[0])
+	}
+	if matches[0].Category != "scam" {
+		t.Errorf("pattern matches must carry the keyword category, got %q", matches[0].Category)
+	}
+	if matches[1].Captures["number"] != "4 1 1 2-7" {
+		t.Errorf("unexpected digits capture %q", matches[1].Captures["number"])
+	}
+
+	// templates are bounded by words
+	if matches := matcher.Match("resend 5 dollars"); len(matches) != 0 {
+		t.Errorf("unexpected matches %v", matches)
+	}
+}
+
+func TestMatcherNormalizesText(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: "five hundred dollars"},
+		{Term: "can't stop"},
+		{Term: "send {amount:number} dollars", Name: "send money", Mode: pkg_keyword.ModeTemplate},
+	})
+
+	tests := []struct {
+		text     string
+		keywords []string
+		matched  string // text of the first match, in the original
+	}{
+		{"Send $500 please", []string{"send money", "five hundred dollars"}, "Send $500"},
+		{"only 5 hundred bucks", []string{"five hundred dollars"}, "5 hundred bucks"},
+		{"ＦＩＶＥ HUNDRED dollars", []string{"five hundred dollars"}, "ＦＩＶＥ HUNDRED dollars"},
+		{"they cannot... they can not stop", []string{"can't stop"}, "can not stop"},
+		{"$5,000", []string{}, ""},
+	}
+	for _, tt := range tests {
+		matches := matcher.Match(tt.text)
+		if got := pkg_keyword.MatchedTerms(matches); !reflect.DeepEqual(got, tt.keywords) {
+			t.Errorf("Match(%q) keywords = %v, want %v", tt.text, got, tt.keywords)
+			continue
+		}
+		if len(matches) > 0 && matches[0].Text != tt.matched {
+			t.Errorf("Match(%q) text = %q, want %q", tt.text, matches[0].Text, tt.matched)
+		}
+	}
+}
+
+func TestMatcherRegex(t *testing.T) {
+	matcher := newMatcher(t, []pkg_keyword.Keyword{
+		{Term: `(?P<user>[\w.]+)\s+at\s+(?P<domain>\w+)\s+dot\s+com`, Name: "spoken email", Mode: pkg_keyword.ModeRegex},
+	})
+
+	// offsets are runes
+	matches := matcher.Match("écris à jean.doe at example dot com")
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %v", matches)
+	}
+	if matches[0].Start != 8 || matches[0].End != 35 || matches[0].Captures["user"] != "jean.doe" || matches[0].Captures["domain"] != "example" {
+		t.Errorf("unexpected match %+v", matches[0])
+	}
+}
+
+func TestKeywordInvalidPatternFailsLoad(t *testing.T) {
+	invalid := []string{
+		`{"term": "send (money", "mode": "regex"}`,
+		`{"term": "a*", "mode": "regex"}`,
+		`{"term": "send {amount:numbr} dollars", "mode": "template"}`,
+		`{"term": "send {amount dollars", "mode": "template"}`,
+		`{"term": "{a} and {a}", "mode": "template"}`,
+	}
+	for _, raw := range invalid {
+		var kw pkg_keyword.Keyword
+		if err := json.Unmarshal([]byte(raw), &kw); err == nil {
+			t.Errorf("expected error for %s", raw)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package unit_test
+
+import (
+	"testing"
+
+	"showcase-backend-audio_transcriber-go/pkg"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Send $500 now", "send 500 dollars now"},
+		{"send five hundred dollars", "send 500 dollars"},
+		{"send 5 hundred bucks", "send 500 dollars"},
+		{"a thousand and twenty five euros", "1025 euros"},
+		{"it costs £1,500.50", "it costs 1500.50 pounds"},
+		{"code 0044", "code 0044"},
+		{"one dollar", "1 dollar"},
+		{"two point", "2 point"},
+		{"the twenty first of May", "the 21st of may"},
+		{"the second time, wait a second", "the 2nd time, wait a second"},
+		{"nineteen ninety", "19 90"},
+		{"four one one two", "4 1 1 2"},
+		{"I don't know, it's what they'll say", "i do not know, it is what they will say"},
+		{"WON’T", "will not"},
+		{"Ｈｅｌｌｏ ＳＴＲＡẞＥ", "hello strasse"},
+		{"ﬁle №5", "file no5"},
+		{"John's car", "john's car"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := pkg.Normalize(tt.input)
+			if got.Text != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got.Text, tt.want)
+			}
+			if got.Original != tt.input {
+				t.Errorf("Original = %q, want %q", got.Original, tt.input)
+			}
+		})
+	}
+}
+
+func TestNormalizeOriginalSpan(t *testing.T) {
+	n := pkg.Normalize("Please send $500 today")
+	if n.Text != "please send 500 dollars today" {
+		t.Fatalf("Text = %q", n.Text)
+	}
+
+	original := []rune(n.Original)
+	spans := []struct {
+		start, end int
+		want       string
+	}{
+		{0, 6, "Please"},       // case folded rune by rune
+		{7, 11, "send"},        // untouched
+		{12, 23, "$500"},       // rewritten "500 dollars"
+		{16, 23, "$500"},       // part of a rewrite covers all of it
+		{12, 29, "$500 today"}, // across a rewrite
+		{24, 29, "today"},      // after a rewrite, offsets shift back
+	}
+	for _, s := range spans {
+		start, end := n.OriginalSpan(s.start, s.end)
+		if got := string(original[start:end]); got != s.want {
+			t.Errorf("OriginalSpan(%d, %d) = %q, want %q", s.start, s.end, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package unit_test
+
+import (
+	"testing"
+
+	pkg_pii "showcase-backend-audio_transcriber-go/pkg/pii"
+)
+
+func TestDetectPII(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		typ   pkg_pii.Type
+		found string // text of the finding, empty when nothing is expected
+		value string
+	}{
+		{"card", "my card is 4111 1111 1111 1111 thanks", pkg_pii.TypeCard, "4111 1111 1111 1111", "4111111111111111"},
+		{"card dashes", "4012-8888-8888-1881", pkg_pii.TypeCard, "4012-8888-8888-1881", "4012888888881881"},
+		{"spoken card", "it's four one one one one one one one one one one one one one one one ok",
+			pkg_pii.TypeCard, "four one one one one one one one one one one one one one one one", "4111111111111111"},
+		{"card failing luhn", "4111 1111 1111 1112", "", "", ""},
+		{"phone", "call me at (555) 123-4567", pkg_pii.TypePhone, "555) 123-4567", "5551234567"},
+		{"international phone", "call +62 812 3456 789", pkg_pii.TypePhone, "+62 812 3456 789", "+628123456789"},
+		{"spoken phone", "plus one five five five one two three four five six seven",
+			pkg_pii.TypePhone, "plus one five five five one two three four five six seven", "+15551234567"},
+		{"short number", "send 500 dollars to 1234", "", "", ""},
+		{"email", "write to John.Doe@Example.com today", pkg_pii.TypeEmail, "John.Doe@Example.com", "john.doe@example.com"},
+		{"spoken email", "it's john dot doe at example dot co dot uk right",
+			pkg_pii.TypeEmail, "john dot doe at example dot co dot uk", "john.doe@example.co.uk"},
+		{"no email", "meet me at the station", "", "", ""},
+		{"iban", "send it to DE89 3704 0044 0532 0130 00 please", pkg_pii.TypeIBAN, "DE89 3704 0044 0532 0130 00", "DE89370400440532013000"},
+		{"iban bad checksum", "DE88 3704 0044 0532 0130 00", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := pkg_pii.Detect(tt.text, nil)
+			if tt.found == "" {
+				if len(findings) != 0 {
+					t.Fatalf("expected nothing, got %+v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 finding, got %+v", findings)
+			}
+			f := findings[0]
+			if f.Type != tt.typ || f.Text != tt.found || f.Value != tt.value {
+				t.Errorf("got %s %q (%s), want %s %q (%s)", f.Type, f.Text, f.Value, tt.typ, tt.found, tt.value)
+			}
+			if runes := []rune(tt.text); string(runes[f.Start:f.End]) != f.Text {
+				t.Errorf("offsets %d-%d don't point at %q", f.Start, f.End, f.Text)
+			}
+		})
+	}
+}
+
+func TestDetectPIITypes(t *testing.T) {
+	text := "card 4111 1111 1111 1111, mail jane@example.org"
+	if findings := pkg_pii.Detect(text, nil); len(findings) != 2 || findings[0].Type != pkg_pii.TypeCard || findings[1].Type != pkg_pii.TypeEmail {
+		t.Errorf("expected card & email in order, got %+v", findings)
+	}
+	if findings := pkg_pii.Detect(text, []pkg_pii.Type{pkg_pii.TypeEmail}); len(findings) != 1 || findings[0].Type != pkg_pii.TypeEmail {
+		t.Errorf("expected email only, got %+v", findings)
+	}
+
+	if err := (pkg_pii.Config{Types: []pkg_pii.Type{"ssn"}}).Validate(); err == nil {
+		t.Error("unknown type should be invalid")
+	}
+}
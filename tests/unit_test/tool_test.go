@@ -6,6 +6,30 @@ import (
 	"showcase-backend-audio_transcriber-go/pkg"
 )
 
+func TestFloat32ToBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample float32
+		want   int16
+	}{
+		{"exact", 0.5, 16384},
+		{"rounds up", 1.6 / 32768, 2},
+		{"rounds down", 1.4 / 32768, 1},
+		{"rounds negative", -1.6 / 32768, -2},
+		{"clips above", 1.5, 32767},
+		{"clips below", -1.5, -32768},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := pkg.Float32ToBytes([]float32{tt.sample})
+			if got := int16(data[0]) | int16(data[1])<<8; got != tt.want {
+				t.Errorf("Float32ToBytes(%v) = %d, want %d", tt.sample, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBytesToFloat32(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -71,3 +95,27 @@ func TestInt16SliceToBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeOverlappingText(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		next string
+		want string
+	}{
+		{"no previous", "", "hello there", "hello there"},
+		{"overlap", "please send the", "send the money now", "money now"},
+		{"punctuation and case", "I will transfer.", "Transfer, the money", "the money"},
+		{"cut last word", "wire the mon", "the money today", "money today"},
+		{"no overlap", "good morning", "how are you", "how are you"},
+		{"full duplicate", "thank you", "thank you", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkg.MergeOverlappingText(tt.prev, tt.next); got != tt.want {
+				t.Errorf("MergeOverlappingText(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package unit_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"showcase-backend-audio_transcriber-go/pkg"
+	pkg_audio "showcase-backend-audio_transcriber-go/pkg/audio"
+)
+
+// pcmTone returns ms of 16 kHz pcm16, a 440 Hz tone or silence when amplitude is 0
+func pcmTone(ms int, amplitude float64) []byte {
+	samples := make([]int16, 16*ms)
+	for i := range samples {
+		samples[i] = int16(amplitude * 32767 * math.Sin(2*math.Pi*440*float64(i)/16000))
+	}
+	return pkg.Int16SliceToBytes(samples)
+}
+
+func TestVadSingleUtterance(t *testing.T) {
+	vad := pkg_audio.NewVad(pkg_audio.VadConfig{})
+
+	var utterances []pkg_audio.VadUtterance
+	utterances = append(utterances, vad.Write(pcmTone(600, 0))...)
+	utterances = append(utterances, vad.Write(pcmTone(900, 0.3))...)
+	if len(utterances) != 0 {
+		t.Fatalf("utterance completed before hangover: %d", len(utterances))
+	}
+	if !vad.InSpeech() {
+		t.Fatal("expected vad to be in speech")
+	}
+
+	utterances = append(utterances, vad.Write(pcmTone(600, 0))...)
+	if len(utterances) != 1 {
+		t.Fatalf("expected 1 utterance, got %d", len(utterances))
+	}
+
+	// 900 ms of speech + 500 ms hangover
+	if got := len(utterances[0].Audio) / 32; got < 1350 || got > 1450 {
+		t.Errorf("unexpected utterance length %d ms", got)
+	}
+	if got := utterances[0].Start; got < 570*time.Millisecond || got > 630*time.Millisecond {
+		t.Errorf("unexpected utterance start %v", got)
+	}
+}
+
+func TestVadDropsShortNoise(t *testing.T) {
+	vad := pkg_audio.NewVad(pkg_audio.VadConfig{MinUtteranceMs: 300})
+
+	utterances := vad.Write(append(pcmTone(90, 0.3), pcmTone(1000, 0)...))
+	if len(utterances) != 0 {
+		t.Errorf("expected short burst to be dropped, got %d utterances", len(utterances))
+	}
+}
+
+func TestVadMaxUtteranceSplits(t *testing.T) {
+	vad := pkg_audio.NewVad(pkg_audio.VadConfig{MaxUtteranceMs: 1000})
+
+	utterances := vad.Write(pcmTone(2500, 0.3))
+	if len(utterances) != 2 {
+		t.Fatalf("expected 2 forced utterances, got %d", len(utterances))
+	}
+
+	// forced split, the second utterance starts right where the first ends
+	if want := pkg_audio.SamplesDuration(len(utterances[0].Audio) / 2); utterances[1].Start != want {
+		t.Errorf("expected second utterance to start at %v, got %v", want, utterances[1].Start)
+	}
+
+	if tail := vad.Flush(); tail == nil || len(tail.Audio) == 0 {
+		t.Error("expected flush to return the remaining 500 ms")
+	}
+}
@@ -0,0 +1,203 @@
+package unit_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pkg_wav "showcase-backend-audio_transcriber-go/pkg/wav"
+)
+
+// riffChunk builds a chunk with an explicit size, padding is up to the caller
+func riffChunk(id string, size uint32, body []byte) []byte {
+	return append(binary.LittleEndian.AppendUint32([]byte(id), size), body...)
+}
+
+// pcmFmt is a plain fmt chunk body
+func pcmFmt(tag, channels uint16, rate uint32, bits uint16) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, tag)
+	b = binary.LittleEndian.AppendUint16(b, channels)
+	b = binary.LittleEndian.AppendUint32(b, rate)
+	b = binary.LittleEndian.AppendUint32(b, rate*uint32(channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, channels*bits/8)
+	return binary.LittleEndian.AppendUint16(b, bits)
+}
+
+func riffFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return riffChunk("RIFF", uint32(len(body)), body)
+}
+
+func TestWavRoundTrip(t *testing.T) {
+	formats := []pkg_wav.Format{
+		{Encoding: "pcm16", SampleRate: 16000, Channels: 1},
+		{Encoding: "pcm24", SampleRate: 48000, Channels: 2},
+		{Encoding: "pcm32", SampleRate: 44100, Channels: 1},
+		{Encoding: "float32", SampleRate: 22050, Channels: 6},
+		{Encoding: "mulaw", SampleRate: 8000, Channels: 1},
+		{Encoding: "alaw", SampleRate: 8000, Channels: 3},
+	}
+	info := map[string]string{"INAM": "call 42", "ISFT": "audio_client"}
+
+	for _, format := range formats {
+		t.Run(format.Encoding, func(t *testing.T) {
+			// 3 frames, odd sized for 8-bit mono
+			data := make([]byte, 3*format.BlockAlign())
+			for i := range data {
+				data[i] = byte(i * 7)
+			}
+
+			var file bytes.Buffer
+			if err := pkg_wav.Write(&file, format, info, data); err != nil {
+				t.Fatal(err)
+			}
+			if file.Len()%2 != 0 {
+				t.Errorf("odd file length %d, chunks must be padded", file.Len())
+			}
+
+			r, err := pkg_wav.NewReader(bytes.NewReader(file.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Format != format {
+				t.Errorf("expected %+v, got %+v", format, r.Format)
+			}
+			if !reflect.DeepEqual(r.Info, info) {
+				t.Errorf("expected info %v, got %v", info, r.Info)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil || !bytes.Equal(got, data) || r.Truncated() {
+				t.Errorf("data mismatch: %v, truncated %v", err, r.Truncated())
+			}
+		})
+	}
+}
+
+func TestWavWritesExtensible(t *testing.T) {
+	for _, tt := range []struct {
+		format pkg_wav.Format
+		tag    uint16
+	}{
+		{pkg_wav.Format{Encoding: "pcm16", SampleRate: 16000, Channels: 2}, 0x0001},
+		{pkg_wav.Format{Encoding: "pcm24", SampleRate: 16000, Channels: 1}, 0xFFFE},
+		{pkg_wav.Format{Encoding: "pcm16", SampleRate: 16000, Channels: 4}, 0xFFFE},
+		{pkg_wav.Format{Encoding: "float32", SampleRate: 16000, Channels: 1}, 0x0003},
+	} {
+		var file bytes.Buffer
+		pkg_wav.Write(&file, tt.format, nil, nil)
+		if tag := binary.LittleEndian.Uint16(file.Bytes()[20:]); tag != tt.tag {
+			t.Errorf("%+v: format tag 0x%04X, expected 0x%04X", tt.format, tag, tt.tag)
+		}
+	}
+}
+
+func TestWavReaderSkipsChunks(t *testing.T) {
+	// odd sized unknown chunk with its padding byte, LIST of another type, fmt after them
+	file := riffFile(
+		riffChunk("junk", 3, []byte{1, 2, 3, 0}),
+		riffChunk("LIST", 8, []byte("adtlabcd")),
+		riffChunk("fmt ", 16, pcmFmt(1, 1, 8000, 16)),
+		riffChunk("data", 4, []byte{1, 0, 2, 0}),
+		riffChunk("LIST", 4, []byte("INFO")),
+	)
+
+	r, err := pkg_wav.NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Format != (pkg_wav.Format{Encoding: "pcm16", SampleRate: 8000, Channels: 1}) || len(r.Info) != 0 {
+		t.Errorf("unexpected header %+v %v", r.Format, r.Info)
+	}
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, []byte{1, 0, 2, 0}) {
+		t.Errorf("the data must stop at the chunk end, got %v", got)
+	}
+	if r.Duration() != 250*time.Microsecond {
+		t.Errorf("duration %v", r.Duration())
+	}
+}
+
+func TestWavReaderTruncated(t *testing.T) {
+	file := riffFile(riffChunk("fmt ", 16, pcmFmt(1, 1, 16000, 16)), riffChunk("data", 1000, make([]byte, 40)))
+
+	r, err := pkg_wav.NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || len(got) != 40 {
+		t.Errorf("expected the 40 bytes present, got %d (%v)", len(got), err)
+	}
+	if !r.Truncated() {
+		t.Error("expected truncated")
+	}
+
+	// streaming writers leave the size unknown, data runs until eof
+	file = riffFile(riffChunk("fmt ", 16, pcmFmt(1, 1, 16000, 16)), riffChunk("data", 0xFFFFFFFF, make([]byte, 40)))
+	r, _ = pkg_wav.NewReader(bytes.NewReader(file))
+	if got, _ := io.ReadAll(r); len(got) != 40 || r.Truncated() || r.DataSize != -1 {
+		t.Errorf("unknown size: got %d bytes, truncated %v, size %d", len(got), r.Truncated(), r.DataSize)
+	}
+}
+
+func TestWavReaderErrors(t *testing.T) {
+	extensible := func(guid []byte) []byte {
+		b := pcmFmt(0xFFFE, 2, 48000, 24)
+		b = binary.LittleEndian.AppendUint16(b, 22)
+		b = binary.LittleEndian.AppendUint16(b, 24)
+		b = binary.LittleEndian.AppendUint32(b, 3)
+		return append(b, guid...)
+	}
+	badAlign := pcmFmt(1, 2, 16000, 16)
+	binary.LittleEndian.PutUint16(badAlign[12:], 3)
+
+	tests := []struct {
+		name string
+		file []byte
+		want string
+	}{
+		{"empty", nil, "truncated wav riff header"},
+		{"not riff", append([]byte("RIFX"), make([]byte, 8)...), "expected RIFF"},
+		{"not wave", riffChunk("RIFF", 4, []byte("AVI ")), "expected WAVE"},
+		{"no fmt", riffFile(), "no fmt chunk"},
+		{"no data", riffFile(riffChunk("fmt ", 16, pcmFmt(1, 1, 16000, 16))), "no data chunk"},
+		{"data first", riffFile(riffChunk("data", 2, []byte{0, 0})), "data chunk before the fmt chunk"},
+		{"short fmt", riffFile(riffChunk("fmt ", 4, []byte{1, 0, 1, 0})), "fmt chunk too short"},
+		{"truncated fmt", riffFile(riffChunk("fmt ", 16, []byte{1, 0})), "truncated wav \"fmt \" chunk"},
+		{"8-bit pcm", riffFile(riffChunk("fmt ", 16, pcmFmt(1, 1, 8000, 8))), "unsupported wav format 0x0001 with 8 bits"},
+		{"mp3", riffFile(riffChunk("fmt ", 16, pcmFmt(0x55, 1, 8000, 16))), "unsupported wav format 0x0055"},
+		{"block align", riffFile(riffChunk("fmt ", 16, badAlign)), "block align 3"},
+		{"zero channels", riffFile(riffChunk("fmt ", 16, pcmFmt(1, 0, 8000, 16))), "0 channels"},
+		{"short extensible", riffFile(riffChunk("fmt ", 18, append(pcmFmt(0xFFFE, 1, 8000, 16), 0, 0))), "extensible fmt chunk too short"},
+		{"unknown guid", riffFile(riffChunk("fmt ", 40, extensible(make([]byte, 16)))), "unknown wav extensible sub format"},
+	}
+	for _, tt := range tests {
+		_, err := pkg_wav.NewReader(bytes.NewReader(tt.file))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error with %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// the same extensible header with the pcm guid is fine
+	guid := append([]byte{1, 0}, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+	r, err := pkg_wav.NewReader(bytes.NewReader(riffFile(riffChunk("fmt ", 40, extensible(guid)), riffChunk("data", 0, nil))))
+	if err != nil || r.Format != (pkg_wav.Format{Encoding: "pcm24", SampleRate: 48000, Channels: 2}) {
+		t.Errorf("extensible pcm24: %+v, %v", r, err)
+	}
+}
+
+func TestWavWriteRejectsPartialFrames(t *testing.T) {
+	err := pkg_wav.Write(io.Discard, pkg_wav.Format{Encoding: "pcm16", SampleRate: 16000, Channels: 2}, nil, make([]byte, 6))
+	if err == nil {
+		t.Error("expected an error for 1.5 frames")
+	}
+	if err := pkg_wav.Write(io.Discard, pkg_wav.Format{Encoding: "pcm8", SampleRate: 16000, Channels: 1}, nil, nil); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}